@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"backend/internal/ai"
+	"backend/internal/database"
+	"backend/internal/events"
+	"backend/internal/executor"
+	"backend/internal/handlers"
+	"backend/internal/languages"
+	"backend/internal/queue"
+	"backend/internal/storage"
+
+	"github.com/joho/godotenv"
+)
+
+// The judge process claims queued submissions from MongoDB, runs them against
+// the executors and writes submission_results. Run as many of these as needed
+// alongside the API server; they coordinate through leases on the
+// submissions collection.
+func main() {
+	// Load environment variables
+	err := godotenv.Load(".env")
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Error loading .env file: %v. Using environment variables instead.\n", err)
+	}
+
+	// Get MongoDB URI from environment
+	mongoURI := os.Getenv("MONGO_URI")
+	if mongoURI == "" {
+		log.Fatal("MONGO_URI not set in environment variables")
+	}
+
+	// Initialize MongoDB connection
+	err = database.ConnectDB(mongoURI)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer database.DisconnectDB()
+
+	// Submission code and test logs go to the store chosen by SOURCE_STORE
+	sources, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	handlers.Sources = sources
+
+	// The AI client generates input/output parsers for problems that do not
+	// have them cached yet
+	if err := ai.InitAIClient(context.Background()); err != nil {
+		log.Fatalf("Failed to initialize AI client: %v", err)
+	}
+
+	if err := queue.EnsureIndexes(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if err := events.EnsureIndexes(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	// Recover submissions left PENDING by the old in-process judge or by a
+	// worker that died without its lease being picked up yet
+	if requeued, err := queue.RequeueStale(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	} else if requeued > 0 {
+		log.Printf("Re-enqueued %d stale pending submissions", requeued)
+	}
+
+	// JUDGE_* settings may come from .env, which is loaded after package init
+	concurrency := queue.WorkerCount
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_WORKERS")); err == nil && v > 0 {
+		concurrency = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_TEST_PARALLELISM")); err == nil && v > 0 {
+		handlers.TestParallelism = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("JUDGE_FAIL_FAST")); err == nil {
+		handlers.FailFast = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_MAX_STORED_OUTPUT_KB")); err == nil && v > 0 {
+		handlers.MaxStoredOutputBytes = v * 1024
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Tests run on the backend chosen by EXECUTOR_BACKEND, and on the executor
+	// containers are spread across the instances of EXECUTOR_POOLS
+	executor.Default, err = executor.NewFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := executor.LoadPools(); err != nil {
+		log.Fatal(err)
+	}
+	executor.StartHealthChecks(ctx)
+
+	// Time limits are scaled by the multipliers the executors report
+	languages.StartRefresher(ctx)
+
+	pool := queue.NewPool(queue.DefaultWorkerID(), concurrency, handlers.ProcessSubmission)
+	pool.Start(ctx)
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		pool.RunHeartbeat(ctx)
+		close(heartbeatDone)
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down judge, waiting for in-flight submissions to finish...")
+	pool.Wait()
+	<-heartbeatDone
+	log.Println("Judge stopped")
+}
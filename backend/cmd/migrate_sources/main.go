@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/storage"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// migrate_sources copies the files of every ./submissions/<id> directory,
+// i.e. the submitted code and test_results.log, into the source store chosen
+// by SOURCE_STORE. Run it before switching the servers to SOURCE_STORE=gridfs.
+// Files already in the store are overwritten, so it is safe to run again.
+//
+// Usage: SOURCE_STORE=gridfs go run ./cmd/migrate_sources [-dir ./submissions] [-delete] [-dry-run]
+func main() {
+	dir := flag.String("dir", storage.DefaultLocalDir, "directory holding one subdirectory per submission")
+	deleteMigrated := flag.Bool("delete", false, "remove each submission directory once all of its files are stored")
+	dryRun := flag.Bool("dry-run", false, "only list the files that would be migrated")
+	flag.Parse()
+
+	// Load environment variables
+	err := godotenv.Load(".env")
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Error loading .env file: %v. Using environment variables instead.\n", err)
+	}
+
+	mongoURI := os.Getenv("MONGO_URI")
+	if mongoURI == "" {
+		log.Fatal("MONGO_URI not set in environment variables")
+	}
+	if err := database.ConnectDB(mongoURI); err != nil {
+		log.Fatal(err)
+	}
+	defer database.DisconnectDB()
+
+	store, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if local, ok := store.(*storage.LocalStore); ok && filepath.Clean(local.Dir) == filepath.Clean(*dir) {
+		log.Fatalf("SOURCE_STORE points at %s already; set SOURCE_STORE=gridfs or another SOURCE_STORE_DIR", *dir)
+	}
+
+	entries, err := os.ReadDir(*dir)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *dir, err)
+	}
+
+	var migrated, files, failed int
+	for _, entry := range entries {
+		submissionID, err := primitive.ObjectIDFromHex(entry.Name())
+		if !entry.IsDir() || err != nil {
+			log.Printf("Skipping %s: not a submission directory", entry.Name())
+			continue
+		}
+
+		n, err := migrateSubmission(store, filepath.Join(*dir, entry.Name()), submissionID, *dryRun)
+		files += n
+		if err != nil {
+			log.Printf("Failed to migrate submission %s: %v", entry.Name(), err)
+			failed++
+			continue
+		}
+		migrated++
+
+		if *deleteMigrated && !*dryRun {
+			if err := os.RemoveAll(filepath.Join(*dir, entry.Name())); err != nil {
+				log.Printf("Failed to remove %s: %v", entry.Name(), err)
+			}
+		}
+	}
+
+	fmt.Printf("Migrated %d submissions (%d files), %d failed\n", migrated, files, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// migrateSubmission stores every file in dir under submissionID and returns
+// how many it stored.
+func migrateSubmission(store storage.SourceStore, dir string, submissionID primitive.ObjectID, dryRun bool) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	stored := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if dryRun {
+			fmt.Printf("%s/%s\n", submissionID.Hex(), entry.Name())
+			stored++
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return stored, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err = store.Put(ctx, submissionID, entry.Name(), data)
+		cancel()
+		if err != nil {
+			return stored, err
+		}
+		stored++
+	}
+	return stored, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/signature"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// migrate_test_cases converts the test cases of every problem with a
+// signature from free text such as "nums = [2,7,11,15], target = 9" to the
+// canonical format, one JSON value per parameter and one per line, see
+// signature.ConvertInput. The free text is kept in legacy_input and
+// legacy_expected_output. Tests it cannot convert are left as they are and
+// flagged with format_error, for an admin to fix by hand. Tests already in the
+// canonical format are skipped, so it is safe to run again.
+//
+// Usage: go run ./cmd/migrate_test_cases [-problem two-sum] [-dry-run]
+func main() {
+	problemID := flag.String("problem", "", "only migrate the test cases of this problem_id")
+	dryRun := flag.Bool("dry-run", false, "only report what would be converted or flagged")
+	flag.Parse()
+
+	// Load environment variables
+	err := godotenv.Load(".env")
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Error loading .env file: %v. Using environment variables instead.\n", err)
+	}
+
+	mongoURI := os.Getenv("MONGO_URI")
+	if mongoURI == "" {
+		log.Fatal("MONGO_URI not set in environment variables")
+	}
+	if err := database.ConnectDB(mongoURI); err != nil {
+		log.Fatal(err)
+	}
+	defer database.DisconnectDB()
+
+	filter := bson.M{"signature": bson.M{"$ne": nil}}
+	if *problemID != "" {
+		filter["problem_id"] = *problemID
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	cursor, err := database.GetCollection("OJ", "problems").Find(ctx, filter)
+	if err != nil {
+		log.Fatalf("Failed to find problems: %v", err)
+	}
+	var problems []models.Problem
+	err = cursor.All(ctx, &problems)
+	cancel()
+	if err != nil {
+		log.Fatalf("Failed to read problems: %v", err)
+	}
+
+	var converted, flagged, unchanged int
+	for _, problem := range problems {
+		c, f, u, err := migrateProblem(problem, *dryRun)
+		if err != nil {
+			log.Fatalf("Failed to migrate the test cases of %s: %v", problem.ProblemID, err)
+		}
+		converted += c
+		flagged += f
+		unchanged += u
+	}
+
+	fmt.Printf("Converted %d test cases of %d problems, %d already canonical, %d flagged\n", converted, len(problems), unchanged, flagged)
+	if flagged > 0 {
+		os.Exit(1)
+	}
+}
+
+// migrateProblem converts the test cases of problem and returns how many it
+// converted, flagged and left unchanged.
+func migrateProblem(problem models.Problem, dryRun bool) (converted, flagged, unchanged int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("OJ", "test_cases")
+	cursor, err := collection.Find(ctx, bson.M{"problem_db_id": problem.ID})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var testCases []models.TestCase
+	if err := cursor.All(ctx, &testCases); err != nil {
+		return 0, 0, 0, err
+	}
+
+	for _, tc := range testCases {
+		input, expectedOutput, convErr := signature.ConvertTestCase(*problem.Signature, tc.Input, tc.ExpectedOutput)
+		var update bson.M
+		switch {
+		case convErr != nil:
+			fmt.Printf("%s test %d (%s): %v\n", problem.ProblemID, tc.SequenceNumber, tc.ID.Hex(), convErr)
+			flagged++
+			update = bson.M{"$set": bson.M{"format_error": convErr.Error()}}
+		case input == tc.Input && expectedOutput == tc.ExpectedOutput && tc.FormatError == "":
+			unchanged++
+			continue
+		default:
+			converted++
+			set := bson.M{"input": input, "expected_output": expectedOutput}
+			// Keep the oldest free text if an earlier run converted it already
+			if tc.LegacyInput == "" && tc.LegacyExpectedOutput == "" {
+				set["legacy_input"] = tc.Input
+				set["legacy_expected_output"] = tc.ExpectedOutput
+			}
+			update = bson.M{"$set": set, "$unset": bson.M{"format_error": ""}}
+		}
+		if dryRun {
+			continue
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": tc.ID}, update); err != nil {
+			return converted, flagged, unchanged, err
+		}
+	}
+	return converted, flagged, unchanged, nil
+}
@@ -6,13 +6,19 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"backend/internal/ai"
 	"backend/internal/database"
+	"backend/internal/events"
+	"backend/internal/executor"
 	"backend/internal/handlers"
+	"backend/internal/languages"
 	"backend/internal/middleware"
 	"backend/internal/models"
+	"backend/internal/queue"
+	"backend/internal/storage"
 	"context"
 
 	"github.com/joho/godotenv"
@@ -42,6 +48,13 @@ func main() {
 	}
 	defer database.DisconnectDB()
 
+	// Submission code and test logs go to the store chosen by SOURCE_STORE
+	sources, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	handlers.Sources = sources
+
 	// Initialize AI Client
 	if err := ai.InitAIClient(context.Background()); err != nil {
 		log.Fatalf("Failed to initialize AI client: %v", err)
@@ -52,6 +65,39 @@ func main() {
 		log.Fatalf("Failed to initialize rate limit collection: %v", err)
 	}
 
+	// The API server only enqueues submissions; judging happens in the
+	// separate cmd/judge process
+	if err := queue.EnsureIndexes(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if err := events.EnsureIndexes(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	// Runs from the editor go to the backend chosen by EXECUTOR_BACKEND, and
+	// on the executor containers to the instances of EXECUTOR_POOLS
+	executor.Default, err = executor.NewFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := executor.LoadPools(); err != nil {
+		log.Fatal(err)
+	}
+	executor.StartHealthChecks(context.Background())
+
+	// GET /api/languages shows what the executors report about their toolchains
+	languages.StartRefresher(context.Background())
+
+	// Code runs from the editor execute their tests in parallel too, and have
+	// their output truncated like submissions; JUDGE_* settings may come from
+	// .env, which is loaded after package init
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_TEST_PARALLELISM")); err == nil && v > 0 {
+		handlers.TestParallelism = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_MAX_STORED_OUTPUT_KB")); err == nil && v > 0 {
+		handlers.MaxStoredOutputBytes = v * 1024
+	}
+
 	// Set JWT key
 	secret := os.Getenv("JWT_SECRET_KEY")
 	if secret == "" {
@@ -122,6 +168,8 @@ func main() {
 	http.HandleFunc("/problems", middleware.WithCORS(middleware.CacheControlMiddleware(handlers.GetProblemsHandler, 300)))
 	http.HandleFunc("/api/problems", middleware.WithCORS(middleware.CacheControlMiddleware(handlers.GetProblemsHandler, 300)))
 
+	http.HandleFunc("/api/languages", middleware.WithCORS(middleware.CacheControlMiddleware(handlers.GetLanguagesHandler, 60)))
+
 	// Handle problem routes with path parameters
 	http.HandleFunc("/problems/", middleware.WithCORS(func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasSuffix(r.URL.Path, "/stats") {
@@ -198,6 +246,16 @@ func main() {
 	http.HandleFunc("/api/admin/languages/generate", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.AdminAuthMiddleware(handlers.AdminGenerateLanguageStats))))
 	http.HandleFunc("/api/admin/skills/generate", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.AdminAuthMiddleware(handlers.AdminGenerateSkillStats))))
 
+	// Problem checker, interactor and signature routes
+	http.HandleFunc("/api/admin/problems/checker", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.AdminAuthMiddleware(handlers.SetProblemCheckerHandler))))
+	http.HandleFunc("/api/admin/problems/interactor", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.AdminAuthMiddleware(handlers.SetProblemInteractorHandler))))
+	http.HandleFunc("/api/admin/problems/signature", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.AdminAuthMiddleware(handlers.SetProblemSignatureHandler))))
+
+	// Judge worker status
+	http.HandleFunc("/api/admin/judges", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.AdminAuthMiddleware(handlers.GetJudgeWorkersHandler))))
+	http.HandleFunc("/api/admin/executors", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.AdminAuthMiddleware(handlers.GetExecutorPoolsHandler))))
+	http.HandleFunc("/api/admin/rejudge", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.AdminAuthMiddleware(handlers.RejudgeHandler))))
+
 	// Rate limit administration routes
 	http.HandleFunc("/api/rate-limits", middleware.WithCORS(middleware.JWTAuthMiddleware(handlers.GetUserRateLimitsHandler)))
 	http.HandleFunc("/api/admin/rate-limits", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.AdminAuthMiddleware(handlers.AdminUpdateUserRateLimitsHandler))))
@@ -211,7 +269,14 @@ func main() {
 	http.HandleFunc("/api/submissions", middleware.WithCORS(handlers.GetSubmissionsHandler))
 
 	http.HandleFunc("/submissions/", middleware.WithCORS(handlers.GetSubmissionDetailsHandler))
-	http.HandleFunc("/api/submissions/", middleware.WithCORS(handlers.GetSubmissionDetailsHandler))
+	http.HandleFunc("/api/submissions/", middleware.WithCORS(func(w http.ResponseWriter, r *http.Request) {
+		// Handle /api/submissions/{id}/events, the live judging stream
+		if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/events") {
+			handlers.SubmissionEventsHandler(w, r)
+			return
+		}
+		handlers.GetSubmissionDetailsHandler(w, r)
+	}))
 
 	http.HandleFunc("/submit", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.RateLimitMiddleware(models.ServiceCodeSubmission)(handlers.SubmitSolutionHandler))))
 	http.HandleFunc("/api/submit", middleware.WithCORS(middleware.JWTAuthMiddleware(middleware.RateLimitMiddleware(models.ServiceCodeSubmission)(handlers.SubmitSolutionHandler))))
@@ -1149,7 +1149,7 @@ func ExecuteCodeInExecutor(ctx context.Context, code, language, input string) (s
 	log.Printf("Executing code in executor: %s", code)
 	log.Printf("Language: %s", language)
 	log.Printf("Input: %s", TruncateForLogging(input, 1000))
-	result, err := ExecuteCode(language, code, input)
+	result, err := runCode(ctx, language, code, input)
 	if err != nil {
 		return "", fmt.Errorf("execution failed: %w", err)
 	}
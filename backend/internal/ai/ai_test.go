@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+
+	"backend/internal/executor"
 )
 
 func TestTruncateForLogging(t *testing.T) {
@@ -108,12 +110,12 @@ func TestFixPythonExpressions(t *testing.T) {
 			name: "Handle list comprehension",
 			input: `{
 				"test_case_1": {
-					"input": "''.join([chr(i) codesorted(32, 127)]) * 100"
+					"input": "''.join([chr(i) for i in range(32, 127)]) * 100"
 				}
 			}`,
 			expected: `{
 				"test_case_1": {
-					"input": "''.join([chr(i) codesorted(32, 127)]) * 100", "python": true
+					"input": "''.join([chr(i) for i in range(32, 127)]) * 100", "python": true
 				}
 			}`,
 		},
@@ -121,12 +123,12 @@ func TestFixPythonExpressions(t *testing.T) {
 			name: "Handle complex Python expression",
 			input: `{
 				"test_case_1": {
-					"input": "''.join([chr(i % 26 + ord('a')) codesorted(100000)])"
+					"input": "''.join([chr(i % 26 + ord('a')) for i in range(100000)])"
 				}
 			}`,
 			expected: `{
 				"test_case_1": {
-					"input": "''.join([chr(i % 26 + ord('a')) codesorted(100000)])", "python": true
+					"input": "''.join([chr(i % 26 + ord('a')) for i in range(100000)])", "python": true
 				}
 			}`,
 		},
@@ -161,76 +163,6 @@ func normalizeWhitespace(s string) string {
 	return s
 }
 
-func TestCleanJSONString(t *testing.T) {
-	testCases := []struct {
-		name  string
-		input string
-		check func(t *testing.T, result string)
-	}{
-		{
-			name:  "Handle asterisks",
-			input: `{"key": "value", "comment": "*** important ***"}`,
-			check: func(t *testing.T, result string) {
-				if strings.Contains(result, "*") {
-					t.Errorf("Result should not contain asterisks: %s", result)
-				}
-			},
-		},
-		{
-			name:  "Handle trailing commas in objects",
-			input: `{"key1": "value1", "key2": "value2",}`,
-			check: func(t *testing.T, result string) {
-				// Try to parse the result as JSON
-				var obj map[string]interface{}
-				if err := json.Unmarshal([]byte(result), &obj); err != nil {
-					t.Errorf("Result should be valid JSON: %v", err)
-				}
-			},
-		},
-		{
-			name:  "Handle trailing commas in arrays",
-			input: `{"array": [1, 2, 3,]}`,
-			check: func(t *testing.T, result string) {
-				// Try to parse the result as JSON
-				var obj map[string]interface{}
-				if err := json.Unmarshal([]byte(result), &obj); err != nil {
-					t.Errorf("Result should be valid JSON: %v", err)
-				}
-			},
-		},
-		{
-			name:  "Handle Python booleans",
-			input: `{"key": "value", "python": True}`,
-			check: func(t *testing.T, result string) {
-				if strings.Contains(result, "True") {
-					t.Errorf("Result should not contain Python boolean 'True': %s", result)
-				}
-				if !strings.Contains(result, "true") {
-					t.Errorf("Result should contain JSON boolean 'true': %s", result)
-				}
-			},
-		},
-		{
-			name:  "Handle non-printable characters",
-			input: "{\n\t\"key\": \"value\",\n\t\"bad\": \"\u0000\"\n}",
-			check: func(t *testing.T, result string) {
-				// Try to parse the result as JSON
-				var obj map[string]interface{}
-				if err := json.Unmarshal([]byte(result), &obj); err != nil {
-					t.Errorf("Result should be valid JSON: %v", err)
-				}
-			},
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			result := cleanJSONString(tc.input)
-			tc.check(t, result)
-		})
-	}
-}
-
 func TestGenerateTestCases_MockResponse(t *testing.T) {
 	// Skip if no AI client is available
 	if client == nil {
@@ -241,7 +173,7 @@ func TestGenerateTestCases_MockResponse(t *testing.T) {
 	ctx := context.Background()
 	problemStatement := "Given an array of integers nums and an integer target, return indices of the two numbers such that they add up to target."
 
-	testCases, err := GenerateTestCases(ctx, problemStatement)
+	testCases, err := GenerateTestCases(ctx, problemStatement, "")
 
 	if err != nil {
 		t.Fatalf("GenerateTestCases failed: %v", err)
@@ -258,3 +190,59 @@ func TestGenerateTestCases_MockResponse(t *testing.T) {
 
 	t.Logf("Successfully generated %d test cases", len(testCases))
 }
+
+// useLocalExecutor runs code for the test on the executors started on this
+// machine, skipping it unless Python can be run there.
+func useLocalExecutor(t *testing.T) {
+	dir, err := executor.FindExecutorsDir()
+	if err != nil {
+		t.Fatalf("Failed to find the executors: %v", err)
+	}
+	local := executor.NewLocal(dir)
+	if err := local.Available("python"); err != nil {
+		t.Skip(err)
+	}
+	previous := executor.Default
+	executor.Default = local
+	t.Cleanup(func() {
+		executor.Default = previous
+		local.Close()
+	})
+}
+
+func TestEvaluatePythonInExecutor(t *testing.T) {
+	useLocalExecutor(t)
+
+	got, err := EvaluatePythonInExecutor(context.Background(), "2 ** 100")
+	if err != nil {
+		t.Fatalf("EvaluatePythonInExecutor failed: %v", err)
+	}
+	if got != "1267650600228229401496703205376" {
+		t.Errorf("Expected 2 ** 100, got %s", got)
+	}
+
+	if _, err := EvaluatePythonInExecutor(context.Background(), "1 / 0"); err == nil {
+		t.Error("Expected an error for a division by zero")
+	}
+}
+
+func TestExecuteBruteForceSolution(t *testing.T) {
+	useLocalExecutor(t)
+
+	solution := "a, b = map(int, input().split())\nprint(a + b)\n"
+	testCases := map[string]interface{}{
+		"small": map[string]interface{}{"input": "1 2"},
+		"large": map[string]interface{}{"input": "1000000000 1000000000"},
+		"bad":   map[string]interface{}{"input": "x y"},
+	}
+	outputs, err := ExecuteBruteForceSolution(context.Background(), solution, "python", "", testCases)
+	if err != nil {
+		t.Fatalf("ExecuteBruteForceSolution failed: %v", err)
+	}
+	if strings.TrimSpace(outputs["small"]) != "3" || strings.TrimSpace(outputs["large"]) != "2000000000" {
+		t.Errorf("Unexpected outputs %v", outputs)
+	}
+	if outputs["bad"] != "<execution-failed: runtime_error>" {
+		t.Errorf("Expected the bad input to fail, got %q", outputs["bad"])
+	}
+}
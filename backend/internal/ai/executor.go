@@ -2,84 +2,26 @@ package ai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strings"
-	"time"
 
+	"backend/internal/executor"
 	"backend/internal/types"
 )
 
-// ExecuteCode runs code in a Docker container or AWS Lambda and returns the result
-func ExecuteCode(language string, code string, input string) (*types.ExecutionResult, error) {
-	// Create an execution request
-	execReq := types.ExecutionRequest{
+// defaultTimeLimitMs is the CPU time limit of the runs made here
+const defaultTimeLimitMs = 10000
+
+// runCode runs code against input with executor.Default and the default
+// limits
+func runCode(ctx context.Context, language, code, input string) (*types.ExecutionResult, error) {
+	return executor.Default.Execute(ctx, types.ExecutionRequest{
 		Language:    language,
 		Code:        code,
 		Input:       input,
-		TimeLimitMs: 10000, // 10 seconds
-	}
-
-	// For Python, use AWS Lambda instead of local executor
-	if language == "python" {
-		return ExecuteCodeWithLambda(execReq)
-	}
-
-	// For other languages, use the local executor as before
-	// Convert the request to JSON
-	reqBody, err := json.Marshal(execReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal execution request: %w", err)
-	}
-
-	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
-	// Determine the executor URL based on language
-	var executorURL string
-	switch language {
-	case "javascript":
-		executorURL = "http://localhost:8002/execute"
-	case "cpp":
-		executorURL = "http://localhost:8003/execute"
-	case "java":
-		executorURL = "http://localhost:8004/execute"
-	default:
-		return nil, fmt.Errorf("unsupported language: %s", language)
-	}
-
-	// Create an HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", executorURL, strings.NewReader(string(reqBody)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute code: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check if the request was successful
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("executor service returned status %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	// Parse the response
-	var result types.ExecutionResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode execution result: %w", err)
-	}
-
-	return &result, nil
+		TimeLimitMs: defaultTimeLimitMs,
+	})
 }
 
 // ExecuteBruteForceSolution executes a brute force solution against a set of test cases
@@ -112,7 +54,7 @@ func ExecuteBruteForceSolution(ctx context.Context, solution string, language st
 		}
 
 		// Execute the solution against the test case
-		result, err := ExecuteCode(language, solution, input)
+		result, err := runCode(ctx, language, solution, input)
 		if err != nil {
 			log.Printf("Failed to execute solution for test case %s: %v", testName, err)
 			expectedOutputs[testName] = fmt.Sprintf("<execution-error: %v>", err)
@@ -144,7 +86,7 @@ print(%s)
 `, expression)
 
 	// The function takes no input, so the input parameter is empty.
-	result, err := ExecuteCode("python", code, "")
+	result, err := runCode(ctx, "python", code, "")
 	if err != nil {
 		return "", fmt.Errorf("python expression execution failed: %w", err)
 	}
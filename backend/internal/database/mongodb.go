@@ -167,6 +167,82 @@ func GetGeneratedCode(ctx context.Context, problemID, language string) (*Generat
 	return &result, nil
 }
 
+// JudgeProgram is a program the judge runs for a problem: its custom output
+// checker, or the interactor of an interactive problem. These are kept in
+// problem_artifacts next to the generated parsers, told apart by Kind; the
+// language is stored as checker_language so GetGeneratedCode never matches them.
+type JudgeProgram struct {
+	ProblemID string    `bson:"problem_id"`
+	Kind      string    `bson:"kind"`
+	Language  string    `bson:"checker_language"`
+	Code      string    `bson:"code"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+const (
+	checkerArtifactKind    = "checker"
+	interactorArtifactKind = "interactor"
+)
+
+func SaveCheckerCode(ctx context.Context, problemID, language, code string) error {
+	return saveJudgeProgram(ctx, checkerArtifactKind, problemID, language, code)
+}
+
+func GetCheckerCode(ctx context.Context, problemID string) (*JudgeProgram, error) {
+	return getJudgeProgram(ctx, checkerArtifactKind, problemID)
+}
+
+func SaveInteractorCode(ctx context.Context, problemID, language, code string) error {
+	return saveJudgeProgram(ctx, interactorArtifactKind, problemID, language, code)
+}
+
+func GetInteractorCode(ctx context.Context, problemID string) (*JudgeProgram, error) {
+	return getJudgeProgram(ctx, interactorArtifactKind, problemID)
+}
+
+func saveJudgeProgram(ctx context.Context, kind, problemID, language, code string) error {
+	if DB == nil {
+		return fmt.Errorf("mongodb client is not initialized")
+	}
+
+	collection := GetCollection("OJ", "problem_artifacts")
+	program := JudgeProgram{
+		ProblemID: problemID,
+		Kind:      kind,
+		Language:  language,
+		Code:      code,
+		UpdatedAt: time.Now(),
+	}
+
+	opts := options.Update().SetUpsert(true)
+	filter := bson.M{"problem_id": problemID, "kind": kind}
+	_, err := collection.UpdateOne(ctx, filter, bson.M{"$set": program}, opts)
+	if err != nil {
+		return fmt.Errorf("failed to save %s for problem %s: %w", kind, problemID, err)
+	}
+	return nil
+}
+
+func getJudgeProgram(ctx context.Context, kind, problemID string) (*JudgeProgram, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("mongodb client is not initialized")
+	}
+
+	collection := GetCollection("OJ", "problem_artifacts")
+	filter := bson.M{"problem_id": problemID, "kind": kind}
+
+	var result JudgeProgram
+	err := collection.FindOne(ctx, filter).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("no %s found for problem_id: %s", kind, problemID)
+		}
+		return nil, fmt.Errorf("failed to fetch %s: %w", kind, err)
+	}
+
+	return &result, nil
+}
+
 func DisconnectDB() {
 	if DB == nil {
 		return
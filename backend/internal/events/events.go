@@ -0,0 +1,127 @@
+// Package events records the progress of judging a submission, so clients can
+// follow it live and pick up where they left off after reconnecting. Events
+// are stored in MongoDB because the judge that publishes them usually runs in
+// a different process from the API server streaming them.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func eventsCollection() *mongo.Collection {
+	return database.GetCollection("OJ", "submission_events")
+}
+
+// EnsureIndexes creates the index Since reads events through.
+func EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := eventsCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "submission_id", Value: 1}, {Key: "seq", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create submission event indexes: %w", err)
+	}
+	return nil
+}
+
+// Publish records an event for a submission. Sequence numbers are taken from
+// the event_seq counter on the submission, so events published by different
+// workers for the same submission still get distinct, increasing numbers.
+func Publish(ctx context.Context, submissionID primitive.ObjectID, eventType models.SubmissionEventType, data map[string]interface{}) error {
+	var counter struct {
+		EventSeq int64 `bson:"event_seq"`
+	}
+	err := database.GetCollection("OJ", "submissions").FindOneAndUpdate(ctx,
+		bson.M{"_id": submissionID},
+		bson.M{"$inc": bson.M{"event_seq": 1}},
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.After).
+			SetProjection(bson.M{"event_seq": 1}),
+	).Decode(&counter)
+	if err != nil {
+		return fmt.Errorf("failed to number %s event of submission %s: %w", eventType, submissionID.Hex(), err)
+	}
+
+	event := models.SubmissionEvent{
+		SubmissionID: submissionID,
+		Seq:          counter.EventSeq,
+		Type:         eventType,
+		Data:         data,
+		CreatedAt:    time.Now(),
+	}
+	if _, err := eventsCollection().InsertOne(ctx, event); err != nil {
+		return fmt.Errorf("failed to store %s event of submission %s: %w", eventType, submissionID.Hex(), err)
+	}
+	return nil
+}
+
+// Since returns the events of a submission numbered after afterSeq, oldest
+// first. Pass 0 to get every event.
+func Since(ctx context.Context, submissionID primitive.ObjectID, afterSeq int64) ([]models.SubmissionEvent, error) {
+	cursor, err := eventsCollection().Find(ctx,
+		bson.M{"submission_id": submissionID, "seq": bson.M{"$gt": afterSeq}},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events of submission %s: %w", submissionID.Hex(), err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.SubmissionEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode events of submission %s: %w", submissionID.Hex(), err)
+	}
+	return events, nil
+}
+
+// ParseLastEventID reads the Last-Event-ID a reconnecting client sends, which
+// is the Seq of the last event it saw. Anything unparseable means the client
+// has seen nothing yet.
+func ParseLastEventID(id string) int64 {
+	seq, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+	if err != nil || seq < 0 {
+		return 0
+	}
+	return seq
+}
+
+// WriteSSE writes event in the Server-Sent Events format. Events without a
+// Seq, such as verdicts made up for submissions judged before events were
+// recorded, are sent without an ID so they do not move the client's
+// Last-Event-ID.
+func WriteSSE(w io.Writer, event models.SubmissionEvent) error {
+	payload := event.Data
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+
+	var b strings.Builder
+	if event.Seq > 0 {
+		fmt.Fprintf(&b, "id: %d\n", event.Seq)
+	}
+	fmt.Fprintf(&b, "event: %s\n", event.Type)
+	fmt.Fprintf(&b, "data: %s\n\n", data)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
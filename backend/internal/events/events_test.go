@@ -0,0 +1,47 @@
+package events
+
+import (
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestWriteSSE(t *testing.T) {
+	var b strings.Builder
+	err := WriteSSE(&b, models.SubmissionEvent{
+		Seq:  3,
+		Type: models.EventRunning,
+		Data: map[string]interface{}{"test": 2, "total": 5},
+	})
+	if err != nil {
+		t.Fatalf("WriteSSE failed: %v", err)
+	}
+	want := "id: 3\nevent: running\ndata: {\"test\":2,\"total\":5}\n\n"
+	if b.String() != want {
+		t.Errorf("WriteSSE wrote %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	if err := WriteSSE(&b, models.SubmissionEvent{Type: models.EventVerdict}); err != nil {
+		t.Fatalf("WriteSSE failed: %v", err)
+	}
+	if want := "event: verdict\ndata: {}\n\n"; b.String() != want {
+		t.Errorf("WriteSSE without a Seq wrote %q, want %q", b.String(), want)
+	}
+}
+
+func TestParseLastEventID(t *testing.T) {
+	cases := map[string]int64{
+		"":    0,
+		"7":   7,
+		" 12": 12,
+		"-1":  0,
+		"abc": 0,
+	}
+	for id, want := range cases {
+		if got := ParseLastEventID(id); got != want {
+			t.Errorf("ParseLastEventID(%q) = %d, want %d", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend/internal/languages"
+	"backend/internal/types"
+)
+
+// Docker runs code on the executor containers of each language, spreading
+// the requests across the instances of the language's Pool.
+type Docker struct {
+	// send sends a request to an executor of the language, Post unless set
+	send func(ctx context.Context, language, path string, payload, result interface{}) (string, error)
+}
+
+// NewDocker returns the Executor of the executor containers.
+func NewDocker() *Docker {
+	return &Docker{}
+}
+
+func (d *Docker) Execute(ctx context.Context, req types.ExecutionRequest) (*types.ExecutionResult, error) {
+	// Executors allow up to twice the CPU limit in wall-clock time, so give
+	// them that plus some headroom for compilation. An interactive run lasts
+	// as long as the slower of the solution and the interactor
+	timeLimitMs := req.TimeLimitMs
+	if req.Interactor != nil && req.Interactor.TimeLimitMs > timeLimitMs {
+		timeLimitMs = req.Interactor.TimeLimitMs
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Duration(timeLimitMs)*time.Millisecond+15*time.Second)
+	defer cancel()
+
+	var result types.ExecutionResult
+	version, err := d.post(ctx, req.Language, "/execute", req, &result)
+	if err != nil {
+		return nil, err
+	}
+	if err := normalize(&result); err != nil {
+		return nil, err
+	}
+	result.ExecutorVersion = version
+	return &result, nil
+}
+
+func (d *Docker) ExecuteBatch(ctx context.Context, req types.BatchExecutionRequest) (*types.BatchExecutionResult, error) {
+	if !d.SupportsBatch(req.Language) {
+		return nil, fmt.Errorf("%w for %s", ErrBatchUnsupported, req.Language)
+	}
+	return d.executeBatch(ctx, req)
+}
+
+// executeBatch sends req to an executor of its language, whether or not
+// SupportsBatch says the language is run in batches.
+func (d *Docker) executeBatch(ctx context.Context, req types.BatchExecutionRequest) (*types.BatchExecutionResult, error) {
+	// Enough for every test to use its whole wall-clock allowance one after
+	// another, plus compilation
+	timeout := 15 * time.Second
+	for _, test := range req.Tests {
+		timeout += 2 * time.Duration(test.TimeLimitMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result types.BatchExecutionResult
+	version, err := d.post(ctx, req.Language, "/execute/batch", req, &result)
+	if err != nil {
+		return nil, err
+	}
+	result.ExecutorVersion = version
+	for i := range result.Results {
+		if err := normalize(&result.Results[i]); err != nil {
+			return nil, err
+		}
+		result.Results[i].ExecutorVersion = version
+	}
+	return &result, nil
+}
+
+func (d *Docker) post(ctx context.Context, language, path string, payload, result interface{}) (string, error) {
+	if d.send != nil {
+		return d.send(ctx, language, path, payload, result)
+	}
+	return Post(ctx, language, path, payload, result)
+}
+
+// SupportsBatch is set for the languages of the registry marked Batch, and
+// for Python once a pool of executors is configured for it.
+func (d *Docker) SupportsBatch(language string) bool {
+	lang, ok := languages.Lookup(language)
+	return ok && (lang.Batch || Pooled(lang.Name))
+}
+
+func (d *Docker) SupportsSignature(language string) bool {
+	lang, ok := languages.Lookup(language)
+	return ok && lang.Signature
+}
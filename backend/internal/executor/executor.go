@@ -0,0 +1,94 @@
+// Package executor runs code for the judge, the editor and the AI features.
+// Everything goes through an Executor: the executor containers over HTTP, AWS
+// Lambda for Python, or local toolchains, as chosen by EXECUTOR_BACKEND.
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"backend/internal/types"
+)
+
+// Statuses of a types.ExecutionResult. Every Executor reports these, whatever
+// its backend calls them.
+const (
+	StatusSuccess             = "success"
+	StatusCompileError        = "compile_error"
+	StatusRuntimeError        = "runtime_error"
+	StatusTimeLimitExceeded   = "time_limit_exceeded"
+	StatusMemoryLimitExceeded = "memory_limit_exceeded"
+	StatusOutputLimitExceeded = "output_limit_exceeded"
+	StatusSecurityViolation   = "security_violation"
+	// Verdicts of interactive runs
+	StatusWrongAnswer        = "wrong_answer"
+	StatusQueryLimitExceeded = "query_limit_exceeded"
+	StatusInteractorError    = "interactor_error"
+)
+
+// Executor runs code against inputs. A run that went wrong because of the
+// code, e.g. a crash or a time limit, is a result with that status; an error
+// means the code could not be run at all, e.g. the backend was unreachable,
+// and the run may be retried.
+type Executor interface {
+	// Execute runs req.Code against req.Input.
+	Execute(ctx context.Context, req types.ExecutionRequest) (*types.ExecutionResult, error)
+	// ExecuteBatch runs req.Code against each of req.Tests, building it once.
+	// It fails with ErrBatchUnsupported unless SupportsBatch(req.Language).
+	ExecuteBatch(ctx context.Context, req types.BatchExecutionRequest) (*types.BatchExecutionResult, error)
+	// SupportsBatch reports whether ExecuteBatch can run code in language.
+	SupportsBatch(language string) bool
+	// SupportsSignature reports whether runs in language can be given a
+	// function signature to generate the harness for, see
+	// types.ExecutionRequest.Signature.
+	SupportsSignature(language string) bool
+}
+
+// ErrBatchUnsupported is returned by ExecuteBatch for languages it cannot run
+// in batches, which are run one test at a time instead.
+var ErrBatchUnsupported = errors.New("batch execution is not supported")
+
+// Default is the Executor code is run with. The servers replace it with the
+// one configured through EXECUTOR_BACKEND once the environment is loaded.
+var Default Executor = NewLambda(NewDocker())
+
+// NewFromEnv returns the Executor configured through EXECUTOR_BACKEND:
+// "lambda", the default, runs Python on AWS Lambda and everything Lambda
+// cannot run on the executor containers; "docker" runs everything on the
+// containers; and "local" runs code on the executors built and started on
+// this machine, see Local, for development without Docker.
+func NewFromEnv() (Executor, error) {
+	switch kind := os.Getenv("EXECUTOR_BACKEND"); kind {
+	case "", "lambda":
+		return NewLambda(NewDocker()), nil
+	case "docker":
+		return NewDocker(), nil
+	case "local":
+		dir := os.Getenv("EXECUTOR_LOCAL_DIR")
+		if dir == "" {
+			var err error
+			if dir, err = FindExecutorsDir(); err != nil {
+				return nil, err
+			}
+		}
+		return NewLocal(dir), nil
+	default:
+		return nil, fmt.Errorf("unknown EXECUTOR_BACKEND %q, use lambda, docker or local", kind)
+	}
+}
+
+// normalize brings the statuses of older executors to the ones above. A
+// result without a status says nothing about the code, so it is an error.
+func normalize(result *types.ExecutionResult) error {
+	switch result.Status {
+	case "":
+		return errors.New("executor returned a result without a status")
+	case "compilation_error":
+		result.Status = StatusCompileError
+	case "timeout":
+		result.Status = StatusTimeLimitExceeded
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"backend/internal/models"
+	"backend/internal/types"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	for backend, want := range map[string]string{
+		"":       "*executor.Lambda",
+		"lambda": "*executor.Lambda",
+		"docker": "*executor.Docker",
+		"local":  "*executor.Local",
+	} {
+		t.Setenv("EXECUTOR_BACKEND", backend)
+		e, err := NewFromEnv()
+		if err != nil {
+			t.Fatalf("EXECUTOR_BACKEND=%q: %v", backend, err)
+		}
+		if got := fmt.Sprintf("%T", e); got != want {
+			t.Errorf("EXECUTOR_BACKEND=%q gave %s, want %s", backend, got, want)
+		}
+	}
+
+	t.Setenv("EXECUTOR_BACKEND", "kubernetes")
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("unknown EXECUTOR_BACKEND was accepted")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for status, want := range map[string]string{
+		"success":           StatusSuccess,
+		"compilation_error": StatusCompileError,
+		"timeout":           StatusTimeLimitExceeded,
+		"runtime_error":     StatusRuntimeError,
+	} {
+		result := &types.ExecutionResult{Status: status}
+		if err := normalize(result); err != nil {
+			t.Fatalf("normalize(%q): %v", status, err)
+		}
+		if result.Status != want {
+			t.Errorf("normalize(%q) = %q, want %q", status, result.Status, want)
+		}
+	}
+	if err := normalize(&types.ExecutionResult{}); err == nil {
+		t.Error("result without a status was accepted")
+	}
+}
+
+// Only plain Python runs go to Lambda; the rest must reach the fallback
+// without touching AWS.
+func TestLambdaFallsBack(t *testing.T) {
+	fallback := &Fake{Batch: true, Signature: true}
+	l := NewLambda(fallback)
+
+	requests := []types.ExecutionRequest{
+		{Language: "cpp", Code: "int main() {}"},
+		{Language: "python", Code: "def f(): pass", Signature: &models.FunctionSignature{}},
+		{Language: "python", Code: "print(1)", Complete: true},
+	}
+	for _, req := range requests {
+		if _, err := l.Execute(context.Background(), req); err != nil {
+			t.Fatalf("Execute(%s): %v", req.Language, err)
+		}
+	}
+	if got := len(fallback.Requests()); got != len(requests) {
+		t.Errorf("fallback got %d runs, want %d", got, len(requests))
+	}
+	if !l.SupportsBatch("python") || !l.SupportsSignature("python") {
+		t.Error("Lambda does not report what its fallback supports")
+	}
+}
+
+func TestFakeBatchStopsOnFailure(t *testing.T) {
+	f := &Fake{
+		Batch: true,
+		Run: func(req types.ExecutionRequest) (*types.ExecutionResult, error) {
+			if req.Input == "crash" {
+				return &types.ExecutionResult{Status: StatusRuntimeError}, nil
+			}
+			return &types.ExecutionResult{Status: StatusSuccess, Output: req.Input}, nil
+		},
+	}
+	result, err := f.ExecuteBatch(context.Background(), types.BatchExecutionRequest{
+		Language:      "python",
+		Tests:         []types.BatchTestInput{{Input: "1"}, {Input: "crash"}, {Input: "3"}},
+		StopOnFailure: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Results) != 2 || result.Results[1].Status != StatusRuntimeError {
+		t.Errorf("batch gave %+v, want it to stop at the crash", result.Results)
+	}
+
+	f.Batch = false
+	if _, err := f.ExecuteBatch(context.Background(), types.BatchExecutionRequest{Language: "python"}); !errors.Is(err, ErrBatchUnsupported) {
+		t.Errorf("ExecuteBatch without Batch gave %v, want ErrBatchUnsupported", err)
+	}
+}
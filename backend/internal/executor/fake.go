@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"backend/internal/types"
+)
+
+// Fake is an Executor for tests. It answers every run with Run and records
+// the requests it got, so handlers can be tested without any backend.
+type Fake struct {
+	// Run answers one run. Nil answers every run with success and no output
+	Run func(req types.ExecutionRequest) (*types.ExecutionResult, error)
+	// Batch is what SupportsBatch reports. Batches are answered test by test
+	// with Run, stopping at the first failure with StopOnFailure
+	Batch bool
+	// Signature is what SupportsSignature reports
+	Signature bool
+
+	mu       sync.Mutex
+	requests []types.ExecutionRequest
+}
+
+// Requests returns the runs the fake was asked for, in order. The tests of a
+// batch are recorded as one run each.
+func (f *Fake) Requests() []types.ExecutionRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]types.ExecutionRequest(nil), f.requests...)
+}
+
+func (f *Fake) Execute(ctx context.Context, req types.ExecutionRequest) (*types.ExecutionResult, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	if f.Run == nil {
+		return &types.ExecutionResult{Status: StatusSuccess}, nil
+	}
+	result, err := f.Run(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := normalize(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (f *Fake) ExecuteBatch(ctx context.Context, req types.BatchExecutionRequest) (*types.BatchExecutionResult, error) {
+	if !f.Batch {
+		return nil, fmt.Errorf("%w for %s", ErrBatchUnsupported, req.Language)
+	}
+	batch := &types.BatchExecutionResult{}
+	for _, test := range req.Tests {
+		result, err := f.Execute(ctx, types.ExecutionRequest{
+			Language:      req.Language,
+			Code:          req.Code,
+			Input:         test.Input,
+			TimeLimitMs:   test.TimeLimitMs,
+			MemoryLimitKB: test.MemoryLimitKB,
+			FunctionName:  req.FunctionName,
+			Parser:        req.Parser,
+			Signature:     req.Signature,
+		})
+		if err != nil {
+			return nil, err
+		}
+		batch.Results = append(batch.Results, *result)
+		if req.StopOnFailure && result.Status != StatusSuccess {
+			break
+		}
+	}
+	return batch, nil
+}
+
+func (f *Fake) SupportsBatch(language string) bool {
+	return f.Batch
+}
+
+func (f *Fake) SupportsSignature(language string) bool {
+	return f.Signature
+}
@@ -0,0 +1,167 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"backend/internal/languages"
+	"backend/internal/types"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/lambda"
+)
+
+// LambdaRequest is the structure sent to the Lambda function
+type LambdaRequest struct {
+	Code          string `json:"code"`
+	Input         string `json:"input"`
+	TimeLimitMs   int    `json:"time_limit_ms"`
+	MemoryLimitKB int    `json:"memory_limit_kb"`
+}
+
+// LambdaResponse is the structure received from the Lambda function
+type LambdaResponse struct {
+	Output          string `json:"output"`
+	ExecutionTimeMs int    `json:"execution_time_ms"` // CPU time
+	WallTimeMs      int    `json:"wall_time_ms"`
+	MemoryUsedKB    int    `json:"memory_used_kb"`
+	Status          string `json:"status"`
+}
+
+// Lambda runs Python programs on AWS Lambda, see
+// backend/lambda/python_executor_lambda.py, and hands everything else to
+// Fallback.
+type Lambda struct {
+	Region       string
+	FunctionName string
+	Fallback     Executor
+}
+
+// NewLambda returns the Executor of the Lambda function, with fallback
+// running what it cannot.
+func NewLambda(fallback Executor) *Lambda {
+	return &Lambda{
+		Region:       "ap-south-1",               // Use the region where your Lambda is deployed
+		FunctionName: "python-code-executor-zip", // Use the new ZIP-based Lambda function
+		Fallback:     fallback,
+	}
+}
+
+// runs reports whether req runs on Lambda. Interactive runs need the
+// interactor next to the solution, runs with a signature the generated
+// harness, and complete programs the structures library, which only the
+// executor containers have. Python runs on the containers too once a pool of
+// them is configured.
+func (l *Lambda) runs(req types.ExecutionRequest) bool {
+	lang, ok := languages.Lookup(req.Language)
+	return ok && lang.Name == "python" && !Pooled(lang.Name) &&
+		req.Interactor == nil && req.Signature == nil && !req.Complete
+}
+
+func (l *Lambda) Execute(ctx context.Context, req types.ExecutionRequest) (*types.ExecutionResult, error) {
+	if !l.runs(req) {
+		return l.Fallback.Execute(ctx, req)
+	}
+
+	// Create a new session in the specified region
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(l.Region),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	// Create a new Lambda service client
+	svc := lambda.New(sess)
+
+	// Convert the request to JSON
+	payload, err := json.Marshal(LambdaRequest{
+		Code:          req.Code,
+		Input:         req.Input,
+		TimeLimitMs:   req.TimeLimitMs,
+		MemoryLimitKB: req.MemoryLimitKB,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Lambda request: %w", err)
+	}
+
+	log.Printf("Invoking Lambda with payload: %s", string(payload))
+
+	// Set up the Lambda invocation input
+	invokeInput := &lambda.InvokeInput{
+		FunctionName:   aws.String(l.FunctionName),
+		Payload:        payload,
+		InvocationType: aws.String("RequestResponse"), // Synchronous invocation
+	}
+
+	// Invoke the Lambda function
+	resp, err := svc.InvokeWithContext(ctx, invokeInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke Lambda function: %w", err)
+	}
+
+	log.Printf("Lambda response status code: %d", aws.Int64Value(resp.StatusCode))
+
+	// Check if Lambda execution had an error
+	if resp.FunctionError != nil {
+		log.Printf("Lambda function error: %s", *resp.FunctionError)
+
+		// Try to parse the error payload for more details
+		var errorResp struct {
+			ErrorMessage string   `json:"errorMessage"`
+			ErrorType    string   `json:"errorType"`
+			StackTrace   []string `json:"stackTrace,omitempty"`
+		}
+
+		if err := json.Unmarshal(resp.Payload, &errorResp); err == nil && errorResp.ErrorMessage != "" {
+			log.Printf("Lambda error details: %s (%s)", errorResp.ErrorMessage, errorResp.ErrorType)
+			return nil, fmt.Errorf("Lambda function error (%s): %s", *resp.FunctionError, errorResp.ErrorMessage)
+		}
+
+		// Fallback to generic error
+		return nil, fmt.Errorf("Lambda function error: %s", *resp.FunctionError)
+	}
+
+	// Log the raw response payload for debugging
+	log.Printf("Lambda raw response: %s", string(resp.Payload))
+
+	// Parse the Lambda response
+	var lambdaResp LambdaResponse
+	if err := json.Unmarshal(resp.Payload, &lambdaResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Lambda response: %w", err)
+	}
+
+	result := &types.ExecutionResult{
+		Output:          lambdaResp.Output,
+		ExecutionTimeMs: lambdaResp.ExecutionTimeMs,
+		WallTimeMs:      lambdaResp.WallTimeMs,
+		MemoryUsedKB:    lambdaResp.MemoryUsedKB,
+		Status:          lambdaResp.Status,
+	}
+	if err := normalize(result); err != nil {
+		return nil, err
+	}
+	// Lambda reports which published version of the function ran, e.g. $LATEST
+	if resp.ExecutedVersion != nil {
+		result.ExecutorVersion = "lambda:" + l.FunctionName + ":" + *resp.ExecutedVersion
+	}
+
+	return result, nil
+}
+
+// ExecuteBatch hands batches to Fallback, as Lambda takes one input per
+// invocation.
+func (l *Lambda) ExecuteBatch(ctx context.Context, req types.BatchExecutionRequest) (*types.BatchExecutionResult, error) {
+	return l.Fallback.ExecuteBatch(ctx, req)
+}
+
+func (l *Lambda) SupportsBatch(language string) bool {
+	return l.Fallback.SupportsBatch(language)
+}
+
+func (l *Lambda) SupportsSignature(language string) bool {
+	return l.Fallback.SupportsSignature(language)
+}
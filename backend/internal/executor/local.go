@@ -0,0 +1,287 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"backend/internal/languages"
+	"backend/internal/types"
+)
+
+// Local runs code on the executors of docker/, built and started on this
+// machine as plain processes, so runs go through the same harnesses, limits
+// and verdicts as on the containers, with the toolchains installed here. It
+// is for development and tests: the executors run without their sandbox
+// unless EXECUTOR_SANDBOX is set, so only run code you trust with it.
+type Local struct {
+	// Dir is the docker directory of the repository, holding the sources of
+	// the executors
+	Dir string
+
+	docker  Docker
+	mu      sync.Mutex
+	binDir  string // Executors built so far, removed by Close
+	running map[string]*localExecutor
+}
+
+// localExecutor is an executor process started by Local.
+type localExecutor struct {
+	url  string
+	cmd  *exec.Cmd
+	done chan struct{} // Closed once the process has exited
+}
+
+// localToolchain is the executor of a language and the command it cannot
+// run without.
+type localToolchain struct {
+	dir     string
+	command string
+}
+
+var localToolchains = map[string]localToolchain{
+	"python":     {"python_executor", "python3"},
+	"javascript": {"js_executor", "node"},
+	"cpp":        {"cpp_executor", "g++"},
+	"java":       {"java_executor", "javac"},
+	"go":         {"go_executor", "go"},
+	"rust":       {"rust_executor", "rustc"},
+	"c":          {"c_executor", "gcc"},
+	"kotlin":     {"kotlin_executor", "kotlinc"},
+	"typescript": {"ts_executor", "tsc"},
+}
+
+// localStartTimeout bounds how long a started executor has to answer on /info.
+const localStartTimeout = 30 * time.Second
+
+// NewLocal returns the Executor running the executors in dir, see Local.
+func NewLocal(dir string) *Local {
+	l := &Local{Dir: dir, running: make(map[string]*localExecutor)}
+	l.docker.send = l.send
+	return l
+}
+
+// FindExecutorsDir returns the docker directory of the repository holding the
+// working directory, which NewFromEnv uses for Local unless EXECUTOR_LOCAL_DIR
+// is set.
+func FindExecutorsDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "docker", "runner", "go.mod")); err == nil {
+			return filepath.Join(dir, "docker"), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no docker/runner found above the working directory, set EXECUTOR_LOCAL_DIR")
+		}
+		dir = parent
+	}
+}
+
+// Execute starts the executor of the language on first use, so building it
+// does not count against the run's timeout.
+func (l *Local) Execute(ctx context.Context, req types.ExecutionRequest) (*types.ExecutionResult, error) {
+	if _, err := l.executor(req.Language); err != nil {
+		return nil, err
+	}
+	return l.docker.Execute(ctx, req)
+}
+
+func (l *Local) ExecuteBatch(ctx context.Context, req types.BatchExecutionRequest) (*types.BatchExecutionResult, error) {
+	if !l.SupportsBatch(req.Language) {
+		return nil, fmt.Errorf("%w for %s", ErrBatchUnsupported, req.Language)
+	}
+	if _, err := l.executor(req.Language); err != nil {
+		return nil, err
+	}
+	return l.docker.executeBatch(ctx, req)
+}
+
+// SupportsBatch is set for every language with an executor, Python included,
+// as there is no Lambda to send it to.
+func (l *Local) SupportsBatch(language string) bool {
+	lang, ok := languages.Lookup(language)
+	if !ok {
+		return false
+	}
+	_, ok = localToolchains[lang.Name]
+	return ok
+}
+
+func (l *Local) SupportsSignature(language string) bool {
+	lang, ok := languages.Lookup(language)
+	return ok && lang.Signature
+}
+
+// Available reports why code in language cannot be run locally, e.g. as its
+// toolchain or Go, which builds the executors, is not installed. It is nil
+// when it can.
+func (l *Local) Available(language string) error {
+	lang, ok := languages.Lookup(language)
+	if !ok {
+		return fmt.Errorf("unsupported language: %s", language)
+	}
+	toolchain, ok := localToolchains[lang.Name]
+	if !ok {
+		return fmt.Errorf("%s cannot be run locally", lang.DisplayName)
+	}
+	for _, command := range []string{"go", toolchain.command} {
+		if _, err := exec.LookPath(command); err != nil {
+			return fmt.Errorf("%s must be installed to run %s locally: %w", command, lang.DisplayName, err)
+		}
+	}
+	return nil
+}
+
+// Close stops the executors and removes their builds.
+func (l *Local) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for name, e := range l.running {
+		_ = e.cmd.Process.Kill()
+		<-e.done
+		delete(l.running, name)
+	}
+	if l.binDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(l.binDir)
+	l.binDir = ""
+	return err
+}
+
+// send posts payload to the executor of language.
+func (l *Local) send(ctx context.Context, language, path string, payload, result interface{}) (string, error) {
+	url, err := l.executor(language)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal execution request: %w", err)
+	}
+	version, _, err := post(ctx, url+path, body, result)
+	return version, err
+}
+
+// executor returns the address of the executor of language, starting it if
+// it is not running, e.g. on first use or after it crashed.
+func (l *Local) executor(language string) (string, error) {
+	lang, ok := languages.Lookup(language)
+	if !ok {
+		return "", fmt.Errorf("unsupported language: %s", language)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.running[lang.Name]; ok {
+		select {
+		case <-e.done:
+			delete(l.running, lang.Name)
+		default:
+			return e.url, nil
+		}
+	}
+	e, err := l.start(lang)
+	if err != nil {
+		return "", err
+	}
+	l.running[lang.Name] = e
+	return e.url, nil
+}
+
+// start builds the executor of lang, unless it already was, and starts it on
+// a free port. Its log goes next to its build.
+func (l *Local) start(lang languages.Language) (*localExecutor, error) {
+	if err := l.Available(lang.Name); err != nil {
+		return nil, err
+	}
+	toolchain := localToolchains[lang.Name]
+
+	if l.binDir == "" {
+		dir, err := os.MkdirTemp("", "local-executors-*")
+		if err != nil {
+			return nil, err
+		}
+		l.binDir = dir
+	}
+	binary := filepath.Join(l.binDir, toolchain.dir)
+	if _, err := os.Stat(binary); err != nil {
+		build := exec.Command("go", "build", "-o", binary, ".")
+		build.Dir = filepath.Join(l.Dir, toolchain.dir)
+		if out, err := build.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to build %s: %v\n%s", toolchain.dir, err, out)
+		}
+	}
+
+	addr, err := freeAddr()
+	if err != nil {
+		return nil, err
+	}
+	logFile, err := os.Create(binary + ".log")
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(binary)
+	cmd.Env = append(os.Environ(), "EXECUTOR_ADDR="+addr)
+	// The sandbox needs root, which development machines rarely run as
+	if os.Getenv("EXECUTOR_SANDBOX") == "" {
+		cmd.Env = append(cmd.Env, "EXECUTOR_SANDBOX=off")
+	}
+	if os.Getenv("EXECUTOR_VERSION") == "" {
+		cmd.Env = append(cmd.Env, "EXECUTOR_VERSION=local")
+	}
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	stopWithParent(cmd)
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", toolchain.dir, err)
+	}
+	e := &localExecutor{url: "http://" + addr, cmd: cmd, done: make(chan struct{})}
+	go func() {
+		_ = cmd.Wait()
+		logFile.Close()
+		close(e.done)
+	}()
+
+	deadline := time.Now().Add(localStartTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := checkInstance(ctx, e.url)
+		cancel()
+		if err == nil {
+			log.Printf("Started %s on %s, logging to %s", toolchain.dir, e.url, logFile.Name())
+			return e, nil
+		}
+		select {
+		case <-e.done:
+			return nil, fmt.Errorf("%s exited on start, see %s", toolchain.dir, logFile.Name())
+		case <-time.After(50 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			_ = cmd.Process.Kill()
+			<-e.done
+			return nil, fmt.Errorf("%s did not answer within %s: %w", toolchain.dir, localStartTimeout, err)
+		}
+	}
+}
+
+// freeAddr returns a loopback address with a port nothing listens on.
+func freeAddr() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	return listener.Addr().String(), nil
+}
@@ -0,0 +1,11 @@
+package executor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// stopWithParent has cmd killed when the backend exits, even without Close.
+func stopWithParent(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package executor
+
+import "os/exec"
+
+// stopWithParent does nothing where the kernel cannot kill a process with
+// its parent; executors left behind by a killed backend must be stopped by
+// hand there.
+func stopWithParent(cmd *exec.Cmd) {}
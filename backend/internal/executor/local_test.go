@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+	"backend/internal/types"
+)
+
+// newTestLocal returns a Local running the executors of this repository,
+// skipping the test unless language can be run locally.
+func newTestLocal(t *testing.T, language string) *Local {
+	dir, err := FindExecutorsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLocal(dir)
+	if err := l.Available(language); err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestLocalRunsPython(t *testing.T) {
+	l := newTestLocal(t, "python")
+	result, err := l.Execute(context.Background(), types.ExecutionRequest{
+		Language:    "python",
+		Code:        "print(int(input()) * 2)",
+		Input:       "21\n",
+		TimeLimitMs: 2000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusSuccess || result.Output != "42\n" {
+		t.Errorf("got %q (%s), want 42", result.Output, result.Status)
+	}
+	if result.ExecutorVersion != "local" {
+		t.Errorf("got executor version %q, want local", result.ExecutorVersion)
+	}
+
+	result, err = l.Execute(context.Background(), types.ExecutionRequest{
+		Language:    "python",
+		Code:        "while True:\n    pass\n",
+		TimeLimitMs: 200,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusTimeLimitExceeded {
+		t.Errorf("endless loop gave %s, want %s", result.Status, StatusTimeLimitExceeded)
+	}
+}
+
+// The executors get the backend's environment, so the limits configured for
+// the containers hold locally too.
+func TestLocalStopsAtOutputLimit(t *testing.T) {
+	t.Setenv("EXECUTOR_OUTPUT_LIMIT_KB", "64")
+	l := newTestLocal(t, "python")
+	result, err := l.Execute(context.Background(), types.ExecutionRequest{
+		Language:    "python",
+		Code:        "while True:\n    print('y' * 1000)\n",
+		TimeLimitMs: 5000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusOutputLimitExceeded {
+		t.Errorf("got %s, want %s", result.Status, StatusOutputLimitExceeded)
+	}
+}
+
+// The executor generates the harness for the signature, as it does on the
+// containers, and compiles it once for the whole batch.
+func TestLocalBatchCompilesOnce(t *testing.T) {
+	l := newTestLocal(t, "cpp")
+	square := &models.FunctionSignature{
+		FunctionName: "square",
+		Params:       []models.SignatureParam{{Name: "n", Type: "int"}},
+		ReturnType:   "long",
+	}
+	code := "class Solution {\npublic:\n    long long square(int n) {\n        if (n < 0) throw runtime_error(\"negative\");\n        return (long long)n * n;\n    }\n};\n"
+	result, err := l.ExecuteBatch(context.Background(), types.BatchExecutionRequest{
+		Language:  "cpp",
+		Code:      code,
+		Signature: square,
+		Tests: []types.BatchTestInput{
+			{Input: "3", TimeLimitMs: 1000},
+			{Input: "-1", TimeLimitMs: 1000},
+			{Input: "5", TimeLimitMs: 1000},
+		},
+		StopOnFailure: true,
+		Parallelism:   1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Results) != 2 {
+		t.Fatalf("got %d results, want the batch to stop at the failure", len(result.Results))
+	}
+	if got := strings.TrimSpace(result.Results[0].Output); result.Results[0].Status != StatusSuccess || got != "9" {
+		t.Errorf("first test gave %q (%s), want 9", got, result.Results[0].Status)
+	}
+	if result.Results[1].Status != StatusRuntimeError {
+		t.Errorf("second test gave %s, want %s", result.Results[1].Status, StatusRuntimeError)
+	}
+
+	result, err = l.ExecuteBatch(context.Background(), types.BatchExecutionRequest{
+		Language:  "cpp",
+		Code:      "class Solution {\npublic:\n    long long square(int n) { return }\n};\n",
+		Signature: square,
+		Tests:     []types.BatchTestInput{{Input: "1", TimeLimitMs: 1000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Results[0].Status != StatusCompileError {
+		t.Errorf("code that does not compile gave %s, want %s", result.Results[0].Status, StatusCompileError)
+	}
+}
+
+func TestLocalNeedsToolchain(t *testing.T) {
+	t.Setenv("PATH", "")
+	l := NewLocal(t.TempDir())
+	if _, err := l.Execute(context.Background(), types.ExecutionRequest{Language: "python", Code: "print(1)"}); err == nil {
+		t.Error("run without python3 installed did not fail")
+	}
+}
@@ -0,0 +1,378 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/languages"
+	"backend/internal/models"
+)
+
+// Settings of the pools' health checks and circuit breakers.
+var (
+	// HealthCheckInterval is how often StartHealthChecks asks every instance
+	// for GET /info
+	HealthCheckInterval = 10 * time.Second
+	// FailureThreshold is how many requests in a row an instance may fail
+	// before its circuit opens and it gets no more requests
+	FailureThreshold = 3
+	// OpenDuration is how long a circuit stays open before one trial request
+	// is let through to see whether the instance has recovered
+	OpenDuration = 30 * time.Second
+)
+
+// Pool spreads the requests for one language across the executor instances
+// running it. Each request goes to the healthy instance with the fewest
+// requests outstanding. An instance failing FailureThreshold requests in a
+// row is cut off for OpenDuration, and one failing its health check gets no
+// requests while another instance can take them.
+type Pool struct {
+	Language string
+
+	mu        sync.Mutex
+	instances []*instance
+	next      int // Where the search for the least loaded instance starts, so ties rotate
+}
+
+// instance is one executor of a pool. Its fields are guarded by the pool's mu.
+type instance struct {
+	url                 string
+	outstanding         int
+	healthy             bool
+	consecutiveFailures int
+	openedAt            time.Time // Zero while the circuit is closed
+	trial               bool      // The request let through a half-open circuit is outstanding
+	requests            int64
+	failures            int64
+	lastError           string
+	lastCheckedAt       *time.Time
+}
+
+// Circuit states reported in models.ExecutorInstanceStatus.
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half_open"
+)
+
+// circuit returns the state of in's circuit at now.
+func (in *instance) circuit(now time.Time) string {
+	switch {
+	case in.openedAt.IsZero():
+		return circuitClosed
+	case now.Before(in.openedAt.Add(OpenDuration)):
+		return circuitOpen
+	default:
+		return circuitHalfOpen
+	}
+}
+
+// available reports whether in can be sent a request at now, ignoring its
+// health checks.
+func (in *instance) available(now time.Time) bool {
+	switch in.circuit(now) {
+	case circuitClosed:
+		return true
+	case circuitHalfOpen:
+		return !in.trial
+	default:
+		return false
+	}
+}
+
+func newPool(language string, urls []string) *Pool {
+	p := &Pool{Language: language}
+	for _, url := range urls {
+		// Instances count as healthy until a health check says otherwise
+		p.instances = append(p.instances, &instance{url: strings.TrimRight(url, "/"), healthy: true})
+	}
+	return p
+}
+
+// acquire picks the instance the next request goes to and counts the request
+// as outstanding on it. Instances failing their health checks are only used
+// when no healthy one is available, as the checks lag behind. release must be
+// called with the outcome of the request.
+func (p *Pool) acquire() (*instance, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	best := p.leastLoaded(now, true)
+	if best == nil {
+		best = p.leastLoaded(now, false)
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no %s executor is available: all %d have their circuit open", p.Language, len(p.instances))
+	}
+	if best.circuit(now) == circuitHalfOpen {
+		best.trial = true
+	}
+	best.outstanding++
+	best.requests++
+	return best, nil
+}
+
+func (p *Pool) leastLoaded(now time.Time, healthyOnly bool) *instance {
+	var best *instance
+	for i := range p.instances {
+		in := p.instances[(p.next+i)%len(p.instances)]
+		if !in.available(now) || (healthyOnly && !in.healthy) {
+			continue
+		}
+		if best == nil || in.outstanding < best.outstanding {
+			best = in
+		}
+	}
+	if len(p.instances) > 0 {
+		p.next = (p.next + 1) % len(p.instances)
+	}
+	return best
+}
+
+// release ends a request to in. A non-nil err means in failed it, which
+// counts towards opening its circuit.
+func (p *Pool) release(in *instance, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	in.outstanding--
+	trial := in.trial
+	in.trial = false
+	if err == nil {
+		if !in.openedAt.IsZero() {
+			log.Printf("%s executor %s recovered, closing its circuit", p.Language, in.url)
+		}
+		in.consecutiveFailures = 0
+		in.openedAt = time.Time{}
+		return
+	}
+
+	in.failures++
+	in.consecutiveFailures++
+	in.lastError = err.Error()
+	if trial || (in.openedAt.IsZero() && in.consecutiveFailures >= FailureThreshold) {
+		log.Printf("Opening the circuit of %s executor %s after %d failures in a row: %v", p.Language, in.url, in.consecutiveFailures, err)
+		in.openedAt = time.Now()
+	}
+}
+
+// status reports the state of every instance of the pool.
+func (p *Pool) status() models.ExecutorPoolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	status := models.ExecutorPoolStatus{Language: p.Language, Configured: isConfigured(p.Language)}
+	for _, in := range p.instances {
+		status.Instances = append(status.Instances, models.ExecutorInstanceStatus{
+			URL:                 in.url,
+			Healthy:             in.healthy,
+			Circuit:             in.circuit(now),
+			Outstanding:         in.outstanding,
+			Requests:            in.requests,
+			Failures:            in.failures,
+			ConsecutiveFailures: in.consecutiveFailures,
+			LastError:           in.lastError,
+			LastCheckedAt:       in.lastCheckedAt,
+		})
+	}
+	return status
+}
+
+// healthyURL returns the address of an instance that passed its last health
+// check, or of the first instance if none has.
+func (p *Pool) healthyURL() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, in := range p.instances {
+		if in.healthy && in.lastCheckedAt != nil {
+			return in.url
+		}
+	}
+	return p.instances[0].url
+}
+
+var (
+	poolsMu sync.Mutex
+	// pools holds the pool of each language by canonical name. Languages
+	// missing from EXECUTOR_POOLS get a pool of their registry ExecutorURL
+	// when first used
+	pools = make(map[string]*Pool)
+	// configured holds the languages whose pools come from EXECUTOR_POOLS
+	configured = make(map[string]bool)
+)
+
+func init() {
+	// The language reports come from whichever instance is up
+	languages.ExecutorURLFor = func(lang languages.Language) string {
+		p, err := PoolFor(lang.Name)
+		if err != nil {
+			return lang.ExecutorURL
+		}
+		return p.healthyURL()
+	}
+}
+
+// LoadPools configures the pools from EXECUTOR_POOLS. The servers call it
+// once the environment is loaded.
+func LoadPools() error {
+	return ConfigurePools(os.Getenv("EXECUTOR_POOLS"))
+}
+
+// ConfigurePools sets the instances of the languages listed in config, which
+// is a list of language=url,url,... separated by semicolons, e.g.
+// "python=http://python-1:8080,http://python-2:8080;cpp=http://cpp-1:8080".
+// Languages it does not list keep the single executor of the registry.
+func ConfigurePools(config string) error {
+	parsed := make(map[string]*Pool)
+	for _, entry := range strings.Split(config, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid executor pool %q: want language=url,url,...", entry)
+		}
+		lang, ok := languages.Lookup(name)
+		if !ok {
+			return fmt.Errorf("invalid executor pool %q: unknown language %q", entry, name)
+		}
+		var urls []string
+		for _, url := range strings.Split(list, ",") {
+			if url = strings.TrimSpace(url); url != "" {
+				urls = append(urls, url)
+			}
+		}
+		if len(urls) == 0 {
+			return fmt.Errorf("invalid executor pool %q: no executor addresses", entry)
+		}
+		parsed[lang.Name] = newPool(lang.Name, urls)
+	}
+
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	pools = parsed
+	configured = make(map[string]bool)
+	for name, p := range parsed {
+		configured[name] = true
+		log.Printf("%s executor pool: %d instances", name, len(p.instances))
+	}
+	return nil
+}
+
+// PoolFor returns the pool of language, which may be an alias.
+func PoolFor(language string) (*Pool, error) {
+	lang, ok := languages.Lookup(language)
+	if !ok {
+		return nil, fmt.Errorf("unsupported language: %s", language)
+	}
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	p, ok := pools[lang.Name]
+	if !ok {
+		p = newPool(lang.Name, []string{lang.ExecutorURL})
+		pools[lang.Name] = p
+	}
+	return p, nil
+}
+
+// Pooled reports whether EXECUTOR_POOLS lists executors for language.
+func Pooled(language string) bool {
+	lang, ok := languages.Lookup(language)
+	return ok && isConfigured(lang.Name)
+}
+
+func isConfigured(name string) bool {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	return configured[name]
+}
+
+// Status reports the pools of every language, in the registry's order.
+func Status() []models.ExecutorPoolStatus {
+	statuses := []models.ExecutorPoolStatus{}
+	for _, name := range languages.Names() {
+		if p, err := PoolFor(name); err == nil {
+			statuses = append(statuses, p.status())
+		}
+	}
+	return statuses
+}
+
+var healthClient = &http.Client{Timeout: 5 * time.Second}
+
+// CheckHealth asks every instance of every language for GET /info, marking
+// those that do not answer unhealthy.
+func CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, name := range languages.Names() {
+		p, err := PoolFor(name)
+		if err != nil {
+			continue
+		}
+		p.mu.Lock()
+		instances := append([]*instance(nil), p.instances...)
+		p.mu.Unlock()
+		for _, in := range instances {
+			wg.Add(1)
+			go func(p *Pool, in *instance) {
+				defer wg.Done()
+				err := checkInstance(ctx, in.url)
+				now := time.Now()
+				p.mu.Lock()
+				defer p.mu.Unlock()
+				if err != nil {
+					if in.healthy && in.lastCheckedAt != nil {
+						log.Printf("%s executor %s failed its health check: %v", p.Language, in.url, err)
+					}
+					in.lastError = err.Error()
+				}
+				in.healthy = err == nil
+				in.lastCheckedAt = &now
+			}(p, in)
+		}
+	}
+	wg.Wait()
+}
+
+func checkInstance(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/info", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := healthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("executor returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// StartHealthChecks checks the instances in the background, now and then
+// every HealthCheckInterval until ctx is cancelled.
+func StartHealthChecks(ctx context.Context) {
+	go func() {
+		CheckHealth(ctx)
+		ticker := time.NewTicker(HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				CheckHealth(ctx)
+			}
+		}
+	}()
+}
@@ -0,0 +1,172 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeExecutor answers /execute with its name, or with status 500 while
+// failing is set, and /info while it is up.
+type fakeExecutor struct {
+	*httptest.Server
+	failing  atomic.Bool
+	down     atomic.Bool
+	requests atomic.Int64
+}
+
+func newFakeExecutor(t *testing.T, name string) *fakeExecutor {
+	f := &fakeExecutor{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/info" {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		f.requests.Add(1)
+		if f.failing.Load() {
+			http.Error(w, "broken", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"output": %q, "status": "success"}`, name)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func configure(t *testing.T, config string) {
+	t.Helper()
+	if err := ConfigurePools(config); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ConfigurePools("") })
+}
+
+func run(t *testing.T) (string, error) {
+	t.Helper()
+	var result struct {
+		Output string `json:"output"`
+	}
+	_, err := Post(context.Background(), "python", "/execute", struct{}{}, &result)
+	return result.Output, err
+}
+
+func TestConfigurePools(t *testing.T) {
+	for _, config := range []string{"python", "cobol=http://a:8080", "python=,"} {
+		if err := ConfigurePools(config); err == nil {
+			t.Errorf("ConfigurePools(%q) accepted an invalid configuration", config)
+		}
+	}
+
+	configure(t, "py=http://a:8080/, http://b:8080; cpp=http://c:8080")
+	if !Pooled("python") || !Pooled("c++") || Pooled("java") {
+		t.Errorf("Pooled: python %v, cpp %v, java %v", Pooled("python"), Pooled("c++"), Pooled("java"))
+	}
+	for _, pool := range Status() {
+		switch pool.Language {
+		case "python":
+			if len(pool.Instances) != 2 || pool.Instances[0].URL != "http://a:8080" || !pool.Configured {
+				t.Errorf("python pool = %+v", pool)
+			}
+		case "java":
+			if len(pool.Instances) != 1 || pool.Configured {
+				t.Errorf("java pool = %+v, want the registry's executor", pool)
+			}
+		}
+	}
+}
+
+func TestPoolPicksLeastOutstanding(t *testing.T) {
+	configure(t, "python=http://a:8080,http://b:8080,http://c:8080")
+	p, _ := PoolFor("python")
+
+	// Three requests in flight go to three different instances
+	seen := make(map[string]bool)
+	var held []*instance
+	for i := 0; i < 3; i++ {
+		in, err := p.acquire()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[in.url] = true
+		held = append(held, in)
+	}
+	if len(seen) != 3 {
+		t.Fatalf("requests went to %v, want one instance each", seen)
+	}
+
+	// Once b is the only idle instance, it gets the next request
+	p.release(held[1], nil)
+	in, _ := p.acquire()
+	if in != held[1] {
+		t.Errorf("request went to %s, want the idle %s", in.url, held[1].url)
+	}
+}
+
+func TestPoolOpensCircuitOfFailingInstance(t *testing.T) {
+	defer func(d time.Duration) { OpenDuration = d }(OpenDuration)
+	OpenDuration = 200 * time.Millisecond
+
+	good, bad := newFakeExecutor(t, "good"), newFakeExecutor(t, "bad")
+	bad.failing.Store(true)
+	configure(t, "python="+bad.URL+","+good.URL)
+
+	for i := 0; i < 20; i++ {
+		if _, err := run(t); err != nil && bad.requests.Load() > int64(FailureThreshold) {
+			t.Fatalf("request %d failed after the circuit should have opened: %v", i, err)
+		}
+	}
+	if n := bad.requests.Load(); n != int64(FailureThreshold) {
+		t.Errorf("failing instance got %d requests, want %d before its circuit opened", n, FailureThreshold)
+	}
+
+	// After OpenDuration one trial request goes through, closing the circuit
+	bad.failing.Store(false)
+	time.Sleep(OpenDuration)
+	outputs := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		output, err := run(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs[output]++
+	}
+	if outputs["bad"] == 0 {
+		t.Errorf("recovered instance got no requests: %v", outputs)
+	}
+	for _, pool := range Status() {
+		if pool.Language == "python" && pool.Instances[0].Circuit != circuitClosed {
+			t.Errorf("circuit of the recovered instance is %s", pool.Instances[0].Circuit)
+		}
+	}
+}
+
+func TestPoolSkipsUnhealthyInstances(t *testing.T) {
+	good, down := newFakeExecutor(t, "good"), newFakeExecutor(t, "down")
+	down.down.Store(true)
+	configure(t, "python="+down.URL+","+good.URL)
+
+	CheckHealth(context.Background())
+	for i := 0; i < 4; i++ {
+		if output, err := run(t); err != nil || output != "good" {
+			t.Fatalf("request %d went to %q (err %v), want the healthy instance", i, output, err)
+		}
+	}
+
+	// With no healthy instance left, requests still go to one whose circuit is
+	// closed, as health checks lag behind
+	good.down.Store(true)
+	CheckHealth(context.Background())
+	good.down.Store(false)
+	down.down.Store(false)
+	if _, err := run(t); err != nil {
+		t.Errorf("request with every instance unhealthy failed: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// VersionHeader carries the build of the executor that answered.
+const VersionHeader = "X-Executor-Version"
+
+// Post sends payload as JSON to path, e.g. "/execute", on the instance of
+// language's pool with the fewest requests outstanding, and decodes the
+// response into result. It returns the executor's version, empty for
+// executors too old to report one.
+func Post(ctx context.Context, language, path string, payload, result interface{}) (string, error) {
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal execution request: %w", err)
+	}
+
+	p, err := PoolFor(language)
+	if err != nil {
+		return "", err
+	}
+	in, err := p.acquire()
+	if err != nil {
+		return "", err
+	}
+	version, instanceErr, err := post(ctx, in.url+path, reqBody, result)
+	// Requests the caller gave up on say nothing about the instance
+	if errors.Is(ctx.Err(), context.Canceled) {
+		instanceErr = nil
+	}
+	p.release(in, instanceErr)
+	return version, err
+}
+
+// post sends one request to url. instanceErr is set along with err when the
+// executor rather than the request is at fault.
+func post(ctx context.Context, url string, body []byte, result interface{}) (version string, instanceErr, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to execute code: %w", err)
+		return "", err, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("executor service returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return "", err, err
+		}
+		return "", nil, err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		err = fmt.Errorf("failed to decode execution result: %w", err)
+		return "", err, err
+	}
+	return resp.Header.Get(VersionHeader), nil, nil
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/executor"
+	"backend/internal/judge"
+	"backend/internal/languages"
+	"backend/internal/models"
+	"backend/internal/types"
+	"backend/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// checkerTimeLimitMs is the CPU time a custom checker gets for one test.
+const checkerTimeLimitMs = 5000
+
+// outputChecker decides whether actual is an accepted answer for a test. The
+// message explains a rejection and may be empty. An error means the checker
+// itself could not run.
+type outputChecker func(input, expected, actual string) (passed bool, message string, err error)
+
+// newOutputChecker returns the checker configured on problem.
+func newOutputChecker(ctx context.Context, problem models.Problem) (outputChecker, error) {
+	if problem.Interactive {
+		// The interactor has already judged every run that succeeded
+		return func(input, expected, actual string) (bool, string, error) {
+			return true, "", nil
+		}, nil
+	}
+	if problem.Checker != models.CheckerCustom {
+		return func(input, expected, actual string) (bool, string, error) {
+			return judge.CompareOutput(problem.Checker, expected, actual, problem.CheckerAbsEps, problem.CheckerRelEps), "", nil
+		}, nil
+	}
+
+	checker, err := database.GetCheckerCode(ctx, problem.ProblemID)
+	if err != nil {
+		return nil, err
+	}
+	return func(input, expected, actual string) (bool, string, error) {
+		return runCustomChecker(checker, input, expected, actual)
+	}, nil
+}
+
+// runCustomChecker runs a problem's checker program. It reads a JSON object
+// with input, expected and actual from stdin and prints its verdict, see
+// judge.ParseCheckerVerdict.
+func runCustomChecker(checker *database.JudgeProgram, input, expected, actual string) (bool, string, error) {
+	stdin, err := json.Marshal(map[string]string{
+		"input":    input,
+		"expected": expected,
+		"actual":   actual,
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	result, err := executor.Default.Execute(context.Background(), types.ExecutionRequest{
+		Language:    checker.Language,
+		Code:        checker.Code,
+		Input:       string(stdin),
+		TimeLimitMs: checkerTimeLimitMs,
+		Complete:    true,
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("checker for problem %s failed to run: %w", checker.ProblemID, err)
+	}
+	if result.Status != "success" {
+		return false, "", fmt.Errorf("checker for problem %s ended with %s: %s", checker.ProblemID, result.Status, result.Output)
+	}
+
+	passed, message := judge.ParseCheckerVerdict(result.Output)
+	return passed, message, nil
+}
+
+// SetProblemCheckerHandler stores the custom checker program of a problem.
+// The problem still needs its checker set to "custom" for it to be used.
+func SetProblemCheckerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		utils.SendJSONError(w, "Method not allowed. Only PUT or POST is accepted.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload struct {
+		ProblemID string `json:"problem_id"`
+		Language  string `json:"language"`
+		Code      string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.SendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.ProblemID == "" || payload.Code == "" {
+		utils.SendJSONError(w, "Fields 'problem_id' and 'code' are required", http.StatusBadRequest)
+		return
+	}
+	lang, ok := languages.Lookup(payload.Language)
+	if !ok {
+		utils.SendJSONError(w, "Invalid language. Use one of: "+strings.Join(languages.Names(), ", ")+".", http.StatusBadRequest)
+		return
+	}
+	payload.Language = lang.Name
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	problemsCollection := database.GetCollection("OJ", "problems")
+	if err := problemsCollection.FindOne(ctx, bson.M{"problem_id": payload.ProblemID}).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.SendJSONError(w, "Problem not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error checking problem %s: %v", payload.ProblemID, err)
+		utils.SendJSONError(w, "Failed to verify problem", http.StatusInternalServerError)
+		return
+	}
+
+	if err := database.SaveCheckerCode(ctx, payload.ProblemID, payload.Language, payload.Code); err != nil {
+		log.Printf("Error saving checker: %v", err)
+		utils.SendJSONError(w, "Failed to save checker", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
+		"message": "Checker saved successfully",
+	})
+}
@@ -2,14 +2,13 @@ package handlers
 
 import (
 	"backend/internal/database"
+	"backend/internal/storage"
 	"backend/internal/types"
 	"backend/internal/utils"
 	"context"
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -93,11 +92,8 @@ func GetLastCodeHandler(w http.ResponseWriter, r *http.Request) {
 	sort.Slice(subs, func(i, j int) bool { return subs[i].Submitted.After(subs[j].Submitted) })
 	latest := subs[0]
 
-	// Read code file from submissions directory
-	submissionDir := filepath.Join("./submissions", latest.ID.Hex())
-	ext := utils.GetFileExtension(latest.Language)
-	codePath := filepath.Join(submissionDir, "code"+ext)
-	codeBytes, err := os.ReadFile(codePath)
+	// Read the code from the source store
+	codeBytes, err := Sources.Get(ctx, latest.ID, storage.CodeFileName(latest.Language))
 	if err != nil {
 		log.Printf("GetLastCode: cannot read code file: %v", err)
 		utils.SendJSONError(w, "Code file not found", http.StatusInternalServerError)
@@ -1,8 +1,10 @@
 package handlers
 
 import (
-	"backend/internal/ai"
 	"backend/internal/database"
+	"backend/internal/executor"
+	"backend/internal/languages"
+	"backend/internal/models"
 	"backend/internal/types"
 	"backend/internal/utils"
 	"context"
@@ -10,16 +12,128 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
 )
 
-func runCodeAgainstTestCases(ctx context.Context, language, problemID, userCode string, testCases []string) (*types.ExecuteCodeResult, error) {
+// defaultTimeLimitMs is used for problems that do not set a time limit.
+const defaultTimeLimitMs = 2000
+
+// TestParallelism is how many tests of one run execute at once, configured
+// through JUDGE_TEST_PARALLELISM.
+var TestParallelism = 4
+
+func init() {
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_TEST_PARALLELISM")); err == nil && v > 0 {
+		TestParallelism = v
+	}
+}
+
+// runLimits are the resource limits a test runs with.
+type runLimits struct {
+	TimeLimitMs   int // CPU time, after the language multiplier
+	MemoryLimitKB int // 0 means no memory limit
+}
+
+// problemRunLimits returns the limits for running a solution to problem in
+// language.
+func problemRunLimits(problem models.Problem, language string) runLimits {
+	timeLimitMs := problem.TimeLimitMs
+	if timeLimitMs <= 0 {
+		timeLimitMs = defaultTimeLimitMs
+	}
+	return runLimits{
+		TimeLimitMs:   utils.ScaleTimeLimit(language, timeLimitMs),
+		MemoryLimitKB: problem.MemoryLimitMB * 1024,
+	}
+}
+
+// testCaseRunLimits returns the limits for running a solution to problem in
+// language against tc, applying the test case's overrides.
+func testCaseRunLimits(problem models.Problem, tc models.TestCase, language string) runLimits {
+	if tc.TimeLimitMsOverride > 0 {
+		problem.TimeLimitMs = tc.TimeLimitMsOverride
+	}
+	if tc.MemoryLimitMBOverride > 0 {
+		problem.MemoryLimitMB = tc.MemoryLimitMBOverride
+	}
+	return problemRunLimits(problem, language)
+}
+
+// skipTestFunc decides, from the results of the tests before it, whether test
+// i need not run. Skipped tests get the status "skipped".
+type skipTestFunc func(i int, earlier []types.TestCaseResult) bool
+
+// runHooks let the caller steer a run and follow its progress. Any of them
+// may be nil.
+type runHooks struct {
+	Skip skipTestFunc
+	// NewRound reports whether test i, never the first, must start a new
+	// round rather than run along with the tests before it, see
+	// runTestInputs. It is only used along with Skip
+	NewRound func(i int) bool
+	// OnTestStart is called as test i starts. A round run as one batch only
+	// reports its first test.
+	OnTestStart func(i int)
+	// OnTestDone is called once test i has a result, including "skipped". It
+	// may be called from several goroutines at once.
+	OnTestDone func(i int, result types.TestCaseResult)
+}
+
+func (h runHooks) testStarted(i int) {
+	if h.OnTestStart != nil {
+		h.OnTestStart(i)
+	}
+}
+
+func (h runHooks) testDone(i int, result types.TestCaseResult) {
+	if h.OnTestDone != nil {
+		h.OnTestDone(i, result)
+	}
+}
+
+// runCodeAgainstTestCases runs userCode, wrapped with the problem's parsers,
+// once per test input. limits[i] are the limits for testCases[i]. With an
+// interactor, userCode is a complete program
+// that runs unwrapped and talks to the interactor, which gets the test input.
+// With the problem's signature, executors that support it wrap userCode
+// themselves and the test inputs are in the canonical format. In languages
+// taking complete programs, userCode is never wrapped and reads the test
+// input itself.
+func runCodeAgainstTestCases(ctx context.Context, language, problemID, userCode string, signature *models.FunctionSignature, interactor *types.Interactor, testCases []string, limits []runLimits, hooks runHooks) (*types.ExecuteCodeResult, error) {
+	if len(limits) != len(testCases) {
+		return nil, fmt.Errorf("got %d limits for %d test cases", len(limits), len(testCases))
+	}
+
+	fullCode := userCode
+	if interactor != nil || !executor.Default.SupportsSignature(language) {
+		signature = nil
+	}
+	lang, _ := languages.Lookup(language)
+	if interactor == nil && signature == nil && !lang.CompletePrograms {
+		var err error
+		fullCode, err = wrapUserCode(ctx, language, problemID, userCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return runTestInputs(ctx, language, fullCode, signature, interactor, testCases, limits, hooks), nil
+}
+
+// wrapUserCode surrounds userCode with the input and output parsers generated
+// for the problem, making a program that reads a test from stdin and prints
+// the answer.
+func wrapUserCode(ctx context.Context, language, problemID, userCode string) (string, error) {
 	// Fetch the parser and solution code from the database
 	artifacts, err := database.GetGeneratedCode(ctx, problemID, language)
 	if err != nil {
 		log.Printf("Failed to get generated code for problem '%s': %v", problemID, err)
-		return nil, fmt.Errorf("could not find solution artifacts for this problem")
+		return "", fmt.Errorf("could not find solution artifacts for this problem")
 	}
 
 	var fullCode string
@@ -100,50 +214,62 @@ except Exception:
 			artifacts.OutputParserCode,
 		)
 	}
+	return fullCode, nil
+}
 
+// runTestInputs runs code once per test input, see runCodeAgainstTestCases.
+//
+// Tests run in rounds of up to TestParallelism at a time. Without hooks.Skip,
+// one round runs them all. With it, skip sees the results of each round before
+// the tests after it start, so it can judge their output: a wrong answer is
+// only known once the backend checks it. Within a round, tests stop being
+// started once one fails to run cleanly. hooks.NewRound can end a round early.
+func runTestInputs(ctx context.Context, language, code string, signature *models.FunctionSignature, interactor *types.Interactor, testCases []string, limits []runLimits, hooks runHooks) *types.ExecuteCodeResult {
 	result := &types.ExecuteCodeResult{
 		Status:  "processing",
 		Results: make([]types.TestCaseResult, len(testCases)),
 	}
 
-	var maxExecutionTime int64
-	hasError := false
-
-	for i, testInput := range testCases {
-		execResult, err := ai.ExecuteCode(language, fullCode, testInput)
-
-		// Initialize the result with default values
-		result.Results[i] = types.TestCaseResult{
-			Status: "error",
-		}
-
-		if err != nil {
-			result.Results[i].Error = err.Error()
-			hasError = true
+	for next := 0; next < len(testCases); {
+		if hooks.Skip != nil && hooks.Skip(next, result.Results[:next]) {
+			result.Results[next] = skippedTestResult(limits[next])
+			hooks.testDone(next, result.Results[next])
+			next++
 			continue
 		}
-
-		// Check if execResult is nil before accessing its fields
-		if execResult == nil {
-			result.Results[i].Error = "Execution result is nil"
-			hasError = true
-			continue
+		first := next
+		roundHooks := runHooks{
+			OnTestStart: func(i int) { hooks.testStarted(first + i) },
+			OnTestDone:  func(i int, r types.TestCaseResult) { hooks.testDone(first+i, r) },
+		}
+		end := len(testCases)
+		if hooks.Skip != nil {
+			end = min(next+max(TestParallelism, 1), end)
+			for j := next + 1; j < end; j++ {
+				if hooks.NewRound != nil && hooks.NewRound(j) {
+					end = j
+				}
+			}
 		}
+		next += runTestRound(ctx, language, code, signature, interactor, testCases[next:end], limits[next:end], hooks.Skip != nil, result.Results[next:end], roundHooks)
 
-		// Now it's safe to access execResult fields
-		result.Results[i].ExecutionTimeMs = int64(execResult.ExecutionTimeMs)
-		result.Results[i].MemoryUsedKB = execResult.MemoryUsedKB
-		result.Results[i].Status = execResult.Status
+		// Code that does not compile fails every test the same way
+		if compileFailed(result.Results[first:next]) != nil {
+			for ; next < len(testCases); next++ {
+				result.Results[next] = skippedTestResult(limits[next])
+				hooks.testDone(next, result.Results[next])
+			}
+		}
+	}
 
-		if execResult.Status == "success" {
-			result.Results[i].Stdout = execResult.Output
-		} else {
-			result.Results[i].Stderr = execResult.Output
+	var maxExecutionTime int64
+	hasError := false
+	for _, r := range result.Results {
+		if r.Status != "success" && r.Status != "skipped" {
 			hasError = true
 		}
-
-		if result.Results[i].ExecutionTimeMs > maxExecutionTime {
-			maxExecutionTime = result.Results[i].ExecutionTimeMs
+		if r.ExecutionTimeMs > maxExecutionTime {
+			maxExecutionTime = r.ExecutionTimeMs
 		}
 	}
 
@@ -158,8 +284,182 @@ except Exception:
 		result.Stdout = result.Results[0].Stdout
 		result.Stderr = result.Results[0].Stderr
 	}
+	if failed := compileFailed(result.Results); failed != nil {
+		result.Status = "compile_error"
+		result.Stderr = failed.Stderr
+		result.Diagnostics = failed.Diagnostics
+	}
 
-	return result, nil
+	return result
+}
+
+// skippedTestResult is the result of a test that was not run.
+func skippedTestResult(limits runLimits) types.TestCaseResult {
+	return types.TestCaseResult{
+		Status:        "skipped",
+		TimeLimitMs:   limits.TimeLimitMs,
+		MemoryLimitKB: limits.MemoryLimitKB,
+	}
+}
+
+// compileFailed returns the first of results whose code did not compile, or
+// nil if there is none.
+func compileFailed(results []types.TestCaseResult) *types.TestCaseResult {
+	for i := range results {
+		if results[i].Status == "compile_error" {
+			return &results[i]
+		}
+	}
+	return nil
+}
+
+// runTestRound runs code against testCases, storing the results in results,
+// and returns how many tests ran. Those are always the first ones, and at
+// least one. With stopOnFailure, no test is started after one has failed.
+// Languages whose executor takes batches run the whole round in one request.
+// hooks are called with indices into testCases; their Skip is not used.
+func runTestRound(ctx context.Context, language, code string, signature *models.FunctionSignature, interactor *types.Interactor, testCases []string, limits []runLimits, stopOnFailure bool, results []types.TestCaseResult, hooks runHooks) int {
+	if interactor == nil && executor.Default.SupportsBatch(language) {
+		batchReq := types.BatchExecutionRequest{
+			Language:      language,
+			Code:          code,
+			Signature:     signature,
+			Tests:         make([]types.BatchTestInput, len(testCases)),
+			StopOnFailure: stopOnFailure,
+			Parallelism:   TestParallelism,
+		}
+		for i, testInput := range testCases {
+			batchReq.Tests[i] = types.BatchTestInput{
+				Input:         testInput,
+				TimeLimitMs:   limits[i].TimeLimitMs,
+				MemoryLimitKB: limits[i].MemoryLimitKB,
+			}
+		}
+
+		hooks.testStarted(0)
+		batch, err := executor.Default.ExecuteBatch(ctx, batchReq)
+		if err == nil && len(batch.Results) == 0 && len(testCases) > 0 {
+			err = fmt.Errorf("executor returned no results")
+		}
+		if err != nil {
+			// Without results nothing can be told apart, so the whole round failed
+			for i := range testCases {
+				results[i] = testCaseResult(nil, err, limits[i])
+				hooks.testDone(i, results[i])
+			}
+			return len(testCases)
+		}
+		ran := min(len(batch.Results), len(testCases))
+		for i := 0; i < ran; i++ {
+			results[i] = testCaseResult(&batch.Results[i], nil, limits[i])
+			hooks.testDone(i, results[i])
+		}
+		return ran
+	}
+
+	return forEachTest(len(testCases), TestParallelism, stopOnFailure, func(i int) bool {
+		hooks.testStarted(i)
+		execResult, err := executor.Default.Execute(ctx, types.ExecutionRequest{
+			Language:      language,
+			Code:          code,
+			Signature:     signature,
+			Input:         testCases[i],
+			TimeLimitMs:   limits[i].TimeLimitMs,
+			MemoryLimitKB: limits[i].MemoryLimitKB,
+			Interactor:    interactor,
+		})
+		results[i] = testCaseResult(execResult, err, limits[i])
+		hooks.testDone(i, results[i])
+		return results[i].Status != "success"
+	})
+}
+
+// forEachTest calls run for the tests 0 to n-1, starting them in order with at
+// most parallelism running at once. run reports whether its test failed. With
+// stopOnFailure, no test is started once one has failed; tests already running
+// are still waited for. It returns how many tests ran, which are always the
+// first ones.
+func forEachTest(n, parallelism int, stopOnFailure bool, run func(i int) (failed bool)) int {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		stopped bool
+		wg      sync.WaitGroup
+	)
+	slots := make(chan struct{}, parallelism)
+	started := 0
+	for ; started < n; started++ {
+		slots <- struct{}{}
+		mu.Lock()
+		stop := stopped
+		mu.Unlock()
+		if stop {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if run(i) && stopOnFailure {
+				mu.Lock()
+				stopped = true
+				mu.Unlock()
+			}
+		}(started)
+	}
+	wg.Wait()
+	return started
+}
+
+// statusExecutorError is the status of a test the executor could not run,
+// e.g. as it was unreachable. It says nothing about the code, see
+// executor.Executor.
+const statusExecutorError = "error"
+
+// testCaseResult converts the outcome of running one test with limits.
+func testCaseResult(execResult *types.ExecutionResult, err error, limits runLimits) types.TestCaseResult {
+	// Initialize the result with default values
+	result := types.TestCaseResult{
+		Status:        statusExecutorError,
+		TimeLimitMs:   limits.TimeLimitMs,
+		MemoryLimitKB: limits.MemoryLimitKB,
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// Check if execResult is nil before accessing its fields
+	if execResult == nil {
+		result.Error = "Execution result is nil"
+		return result
+	}
+
+	// Now it's safe to access execResult fields
+	result.ExecutionTimeMs = int64(execResult.ExecutionTimeMs)
+	result.WallTimeMs = int64(execResult.WallTimeMs)
+	result.MemoryUsedKB = execResult.MemoryUsedKB
+	result.Status = execResult.Status
+	result.Transcript = execResult.Transcript
+	result.InteractorMessage = execResult.InteractorMessage
+	result.ExecutorVersion = execResult.ExecutorVersion
+	result.Diagnostics = execResult.Diagnostics
+
+	switch execResult.Status {
+	case "success":
+		result.Stdout = execResult.Output
+	case "wrong_answer", "query_limit_exceeded":
+		// Verdicts of an interactive run, the solution itself ran fine
+	default:
+		result.Stderr = execResult.Output
+	}
+	return result
 }
 
 func ExecuteCodeHandler(w http.ResponseWriter, r *http.Request) {
@@ -188,11 +488,39 @@ func ExecuteCodeHandler(w http.ResponseWriter, r *http.Request) {
 		testCases = []string{payload.Stdin}
 	}
 
-	result, err := runCodeAgainstTestCases(ctx, payload.Language, payload.ProblemId, payload.Code, testCases)
+	// Runs use the problem's limits so they behave like submissions
+	var problem models.Problem
+	problemsCollection := database.GetCollection("OJ", "problems")
+	if err := problemsCollection.FindOne(ctx, bson.M{"problem_id": payload.ProblemId}).Decode(&problem); err != nil {
+		log.Printf("Could not load limits for problem '%s': %v", payload.ProblemId, err)
+	}
+
+	// Custom inputs are not tied to a test case, so they all get the problem's limits
+	limits := make([]runLimits, len(testCases))
+	for i := range limits {
+		limits[i] = problemRunLimits(problem, payload.Language)
+	}
+
+	var interactor *types.Interactor
+	if problem.Interactive {
+		var err error
+		interactor, err = loadInteractor(ctx, problem)
+		if err != nil {
+			log.Printf("Could not load interactor for problem '%s': %v", payload.ProblemId, err)
+			utils.SendJSONError(w, "Interactor for this problem is not available", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	result, err := runCodeAgainstTestCases(ctx, payload.Language, payload.ProblemId, payload.Code, problem.Signature, interactor, testCases, limits, runHooks{})
 	if err != nil {
 		utils.SendJSONError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	for i := range result.Results {
+		result.Results[i].Stdout = truncateOutput(result.Results[i].Stdout)
+		result.Results[i].Stderr = truncateOutput(result.Results[i].Stderr)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/types"
+	"backend/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// interactorTimeLimitMs is the CPU time an interactor gets for one test.
+const interactorTimeLimitMs = 10000
+
+// loadInteractor returns the interactor of an interactive problem, ready to
+// send along with each run of a solution.
+func loadInteractor(ctx context.Context, problem models.Problem) (*types.Interactor, error) {
+	program, err := database.GetInteractorCode(ctx, problem.ProblemID)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Interactor{
+		Code:        program.Code,
+		Language:    program.Language,
+		TimeLimitMs: interactorTimeLimitMs,
+		QueryLimit:  problem.QueryLimit,
+	}, nil
+}
+
+// SetProblemInteractorHandler stores the interactor program of a problem.
+// The problem still needs interactive set for it to be used.
+func SetProblemInteractorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		utils.SendJSONError(w, "Method not allowed. Only PUT or POST is accepted.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload struct {
+		ProblemID string `json:"problem_id"`
+		Language  string `json:"language"`
+		Code      string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.SendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.ProblemID == "" || payload.Code == "" {
+		utils.SendJSONError(w, "Fields 'problem_id' and 'code' are required", http.StatusBadRequest)
+		return
+	}
+	// Every executor has python3 to run interactors with
+	if payload.Language != "python" {
+		utils.SendJSONError(w, "Invalid language. Interactors must be written in python.", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	problemsCollection := database.GetCollection("OJ", "problems")
+	if err := problemsCollection.FindOne(ctx, bson.M{"problem_id": payload.ProblemID}).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.SendJSONError(w, "Problem not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error checking problem %s: %v", payload.ProblemID, err)
+		utils.SendJSONError(w, "Failed to verify problem", http.StatusInternalServerError)
+		return
+	}
+
+	if err := database.SaveInteractorCode(ctx, payload.ProblemID, payload.Language, payload.Code); err != nil {
+		log.Printf("Error saving interactor: %v", err)
+		utils.SendJSONError(w, "Failed to save interactor", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
+		"message": "Interactor saved successfully",
+	})
+}
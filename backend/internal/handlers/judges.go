@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"backend/internal/queue"
+	"backend/internal/utils"
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// GetJudgeWorkersHandler lists the judge processes that have reported
+// heartbeats, with their load and whether they are still alive
+func GetJudgeWorkersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.SendJSONError(w, "Method not allowed. Only GET is accepted.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	workers, err := queue.ListWorkers(ctx)
+	if err != nil {
+		log.Printf("Error fetching judge workers: %v", err)
+		utils.SendJSONError(w, "Failed to retrieve judge workers", http.StatusInternalServerError)
+		return
+	}
+
+	alive := 0
+	for _, worker := range workers {
+		if worker.Alive {
+			alive++
+		}
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"workers": workers,
+		"alive":   alive,
+		"total":   len(workers),
+	})
+}
+
+// judgeExecutorPools is the state of one judge's executor pools at its last
+// heartbeat.
+type judgeExecutorPools struct {
+	WorkerID string                      `json:"worker_id"`
+	Alive    bool                        `json:"alive"`
+	Pools    []models.ExecutorPoolStatus `json:"pools"`
+}
+
+// GetExecutorPoolsHandler shows the executor instances of every language:
+// the state of this server's pools, which run code from the editor, and of
+// each judge's, which run submissions
+func GetExecutorPoolsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.SendJSONError(w, "Method not allowed. Only GET is accepted.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	workers, err := queue.ListWorkers(ctx)
+	if err != nil {
+		log.Printf("Error fetching judge workers: %v", err)
+		utils.SendJSONError(w, "Failed to retrieve judge workers", http.StatusInternalServerError)
+		return
+	}
+
+	judges := []judgeExecutorPools{}
+	for _, worker := range workers {
+		if worker.StoppedAt != nil {
+			continue
+		}
+		judges = append(judges, judgeExecutorPools{WorkerID: worker.WorkerID, Alive: worker.Alive, Pools: worker.ExecutorPools})
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"server": executor.Status(),
+		"judges": judges,
+	})
+}
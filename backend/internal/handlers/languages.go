@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"backend/internal/languages"
+	"backend/internal/utils"
+	"net/http"
+)
+
+// GetLanguagesHandler lists the languages submissions can be written in, with
+// what their executors last reported about their toolchains
+func GetLanguagesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.SendJSONError(w, "Method not allowed. Only GET is accepted.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"languages": languages.All(),
+	})
+}
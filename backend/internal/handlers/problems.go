@@ -3,7 +3,9 @@ package handlers
 import (
 	"backend/internal/ai"
 	"backend/internal/database"
+	"backend/internal/judge"
 	"backend/internal/models"
+	"backend/internal/signature"
 	"backend/internal/types"
 	"backend/internal/utils"
 	"context"
@@ -286,6 +288,24 @@ func CreateProblemHandler(w http.ResponseWriter, r *http.Request) {
 		utils.SendJSONError(w, "Title, statement, and difficulty are required fields.", http.StatusBadRequest)
 		return
 	}
+	if !judge.ValidScoringMode(problem.ScoringMode) {
+		utils.SendJSONError(w, "Invalid scoring_mode. Use all_or_nothing, sum, or groups.", http.StatusBadRequest)
+		return
+	}
+	if !judge.ValidCheckerMode(problem.Checker) {
+		utils.SendJSONError(w, "Invalid checker. Use exact, tokens, unordered_lines, float, or custom.", http.StatusBadRequest)
+		return
+	}
+	if problem.CheckerAbsEps < 0 || problem.CheckerRelEps < 0 {
+		utils.SendJSONError(w, "Checker epsilons cannot be negative.", http.StatusBadRequest)
+		return
+	}
+	if problem.Signature != nil {
+		if err := signature.Validate(*problem.Signature); err != nil {
+			utils.SendJSONError(w, "Invalid signature: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Set creation and update timestamps
 	now := time.Now()
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/queue"
+	"backend/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxRejudgeSubmissions caps how many submissions one rejudge may requeue, so
+// a mistaken request cannot flood the judges.
+const maxRejudgeSubmissions = 10000
+
+// rejudgeRequest selects the submissions to rejudge: one submission, every
+// submission to a problem or every submission by a user, optionally only
+// accepted ones and only those submitted in [From, To).
+type rejudgeRequest struct {
+	SubmissionID string     `json:"submission_id"`
+	ProblemID    string     `json:"problem_id"`
+	UserID       string     `json:"user_id"`
+	OnlyAccepted bool       `json:"only_accepted"`
+	From         *time.Time `json:"from"`
+	To           *time.Time `json:"to"`
+}
+
+// newRejudge checks req and returns the rejudge it asks for along with the
+// filter matching its submissions. Submissions still waiting for a verdict
+// are never matched.
+func newRejudge(req rejudgeRequest) (models.Rejudge, bson.M, error) {
+	var rejudge models.Rejudge
+	filter := bson.M{}
+
+	scopes := 0
+	if req.SubmissionID != "" {
+		id, err := primitive.ObjectIDFromHex(req.SubmissionID)
+		if err != nil {
+			return rejudge, nil, fmt.Errorf("invalid submission_id")
+		}
+		rejudge.SubmissionID = &id
+		filter["_id"] = id
+		scopes++
+	}
+	if req.ProblemID != "" {
+		rejudge.ProblemID = req.ProblemID
+		filter["problem_id"] = req.ProblemID
+		scopes++
+	}
+	if req.UserID != "" {
+		id, err := primitive.ObjectIDFromHex(req.UserID)
+		if err != nil {
+			return rejudge, nil, fmt.Errorf("invalid user_id")
+		}
+		rejudge.UserID = &id
+		filter["user_id"] = id
+		scopes++
+	}
+	if scopes != 1 {
+		return rejudge, nil, fmt.Errorf("exactly one of submission_id, problem_id and user_id is required")
+	}
+
+	if req.OnlyAccepted {
+		rejudge.OnlyAccepted = true
+		filter["status"] = models.StatusAccepted
+	} else {
+		filter["status"] = bson.M{"$ne": models.StatusPending}
+	}
+
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return rejudge, nil, fmt.Errorf("from must be before to")
+	}
+	submittedAt := bson.M{}
+	if req.From != nil {
+		rejudge.From = req.From
+		submittedAt["$gte"] = *req.From
+	}
+	if req.To != nil {
+		rejudge.To = req.To
+		submittedAt["$lt"] = *req.To
+	}
+	if len(submittedAt) > 0 {
+		filter["submitted_at"] = submittedAt
+	}
+	return rejudge, filter, nil
+}
+
+// RejudgeHandler lets admins judge submissions again. POST starts a rejudge
+// from a rejudgeRequest: each submission is queued like a new one, and its
+// new verdict joins the earlier ones in its verdict history. GET
+// ?id=<rejudge id> reports how many submissions are still waiting.
+func RejudgeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		startRejudge(w, r)
+	case http.MethodGet:
+		getRejudge(w, r)
+	default:
+		utils.SendJSONError(w, "Method not allowed. Only GET or POST is accepted.", http.StatusMethodNotAllowed)
+	}
+}
+
+func startRejudge(w http.ResponseWriter, r *http.Request) {
+	var req rejudgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	rejudge, filter, err := newRejudge(req)
+	if err != nil {
+		utils.SendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rejudge.ID = primitive.NewObjectID()
+	rejudge.RequestedBy, _ = r.Context().Value(middleware.UserIDKey).(primitive.ObjectID)
+	rejudge.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	submissionsCollection := database.GetCollection("OJ", "submissions")
+	cursor, err := submissionsCollection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "submitted_at", Value: 1}}).
+		SetLimit(maxRejudgeSubmissions+1).
+		SetProjection(bson.M{"verdict_history": bson.M{"$slice": -1}}))
+	if err != nil {
+		log.Printf("Failed to find submissions to rejudge: %v", err)
+		utils.SendJSONError(w, "Failed to find submissions", http.StatusInternalServerError)
+		return
+	}
+	var submissions []models.Submission
+	if err := cursor.All(ctx, &submissions); err != nil {
+		log.Printf("Failed to decode submissions to rejudge: %v", err)
+		utils.SendJSONError(w, "Failed to find submissions", http.StatusInternalServerError)
+		return
+	}
+	if len(submissions) == 0 {
+		utils.SendJSONError(w, "No judged submissions match", http.StatusNotFound)
+		return
+	}
+	if len(submissions) > maxRejudgeSubmissions {
+		utils.SendJSONError(w, fmt.Sprintf("More than %d submissions match, narrow the request", maxRejudgeSubmissions), http.StatusBadRequest)
+		return
+	}
+
+	// The rejudge is saved before any submission is reset, so every
+	// submission pointing at it can be traced back to it and the stats it
+	// touches are recomputed once it finishes
+	rejudge.Total = len(submissions)
+	rejudge.UserIDs, rejudge.ProblemIDs = rejudgeScope(submissions)
+	rejudgesCollection := database.GetCollection("OJ", "rejudges")
+	if _, err := rejudgesCollection.InsertOne(ctx, rejudge); err != nil {
+		log.Printf("Failed to save rejudge %s: %v", rejudge.ID.Hex(), err)
+		utils.SendJSONError(w, "Failed to start rejudge", http.StatusInternalServerError)
+		return
+	}
+
+	// Every submission is marked PENDING before any is queued, so the rejudge
+	// cannot look finished while it is still being set up. A submission left
+	// without a judge state is requeued by the judges' startup sweep.
+	var requeued []models.Submission
+	for _, submission := range submissions {
+		update := bson.M{
+			"$set": bson.M{"status": models.StatusPending, "rejudge_id": rejudge.ID},
+			"$unset": bson.M{
+				"judge_state":      "",
+				"attempts":         "",
+				"last_judge_error": "",
+			},
+		}
+		// Verdicts given before the history was kept are saved before the
+		// new one joins them
+		if len(submission.VerdictHistory) == 0 {
+			update["$push"] = bson.M{"verdict_history": models.VerdictRecord{
+				Status:          submission.Status,
+				TestCasesPassed: submission.TestCasesPassed,
+				TestCasesTotal:  submission.TestCasesTotal,
+				Score:           submission.Score,
+				MaxScore:        submission.MaxScore,
+				ExecutionTimeMs: submission.ExecutionTimeMs,
+				MemoryUsedKB:    submission.MemoryUsedKB,
+			}}
+		}
+		result, err := submissionsCollection.UpdateOne(ctx,
+			bson.M{"_id": submission.ID, "status": submission.Status},
+			update,
+		)
+		if err != nil {
+			log.Printf("Failed to reset submission %s for rejudge: %v", submission.ID.Hex(), err)
+			continue
+		}
+		if result.ModifiedCount == 0 {
+			continue // Its verdict changed in the meantime
+		}
+		requeued = append(requeued, submission)
+	}
+
+	rejudge.Remaining = len(requeued)
+	if len(requeued) < rejudge.Total {
+		rejudge.Total = len(requeued)
+		rejudge.UserIDs, rejudge.ProblemIDs = rejudgeScope(requeued)
+		_, err := rejudgesCollection.UpdateOne(ctx, bson.M{"_id": rejudge.ID}, bson.M{"$set": bson.M{
+			"total":       rejudge.Total,
+			"user_ids":    rejudge.UserIDs,
+			"problem_ids": rejudge.ProblemIDs,
+		}})
+		if err != nil {
+			// The stats of a few more users and problems are recomputed
+			log.Printf("Failed to update rejudge %s: %v", rejudge.ID.Hex(), err)
+		}
+	}
+
+	for _, submission := range requeued {
+		if err := queue.Enqueue(ctx, submission.ID); err != nil {
+			log.Printf("Failed to enqueue rejudged submission %s: %v", submission.ID.Hex(), err)
+			continue
+		}
+		publishSubmissionEvent(submission.ID, models.EventQueued, map[string]interface{}{
+			"rejudge_id": rejudge.ID.Hex(),
+		})
+	}
+
+	log.Printf("Rejudge %s requeued %d submissions", rejudge.ID.Hex(), rejudge.Total)
+	utils.SendJSONResponse(w, http.StatusAccepted, rejudge)
+}
+
+// rejudgeScope returns the users and problems of submissions, whose stats a
+// rejudge of them changes.
+func rejudgeScope(submissions []models.Submission) ([]primitive.ObjectID, []string) {
+	users := make(map[primitive.ObjectID]bool)
+	problems := make(map[string]bool)
+	var userIDs []primitive.ObjectID
+	var problemIDs []string
+	for _, submission := range submissions {
+		if !users[submission.UserID] {
+			users[submission.UserID] = true
+			userIDs = append(userIDs, submission.UserID)
+		}
+		if !problems[submission.ProblemID] {
+			problems[submission.ProblemID] = true
+			problemIDs = append(problemIDs, submission.ProblemID)
+		}
+	}
+	return userIDs, problemIDs
+}
+
+func getRejudge(w http.ResponseWriter, r *http.Request) {
+	rejudgeID, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.SendJSONError(w, "Invalid rejudge ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var rejudge models.Rejudge
+	err = database.GetCollection("OJ", "rejudges").FindOne(ctx, bson.M{"_id": rejudgeID}).Decode(&rejudge)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.SendJSONError(w, "Rejudge not found", http.StatusNotFound)
+		} else {
+			log.Printf("Failed to retrieve rejudge %s: %v", rejudgeID.Hex(), err)
+			utils.SendJSONError(w, "Failed to retrieve rejudge", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	remaining, err := pendingRejudgeSubmissions(ctx, rejudgeID)
+	if err != nil {
+		log.Printf("Failed to count submissions of rejudge %s: %v", rejudgeID.Hex(), err)
+		utils.SendJSONError(w, "Failed to retrieve rejudge", http.StatusInternalServerError)
+		return
+	}
+	rejudge.Remaining = int(remaining)
+
+	// Dead-lettered submissions never reach the judges' finishing step, so
+	// a rejudge ending with one is finished here
+	if remaining == 0 && rejudge.FinishedAt == nil {
+		go finishRejudge(rejudgeID)
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, rejudge)
+}
+
+// pendingRejudgeSubmissions counts the submissions of a rejudge still waiting
+// for their new verdict.
+func pendingRejudgeSubmissions(ctx context.Context, rejudgeID primitive.ObjectID) (int64, error) {
+	return database.GetCollection("OJ", "submissions").CountDocuments(ctx, bson.M{
+		"rejudge_id": rejudgeID,
+		"status":     models.StatusPending,
+	})
+}
+
+// finishRejudge recomputes the stats of every user and problem a rejudge
+// touched, complexity statistics included, once all of its submissions have
+// new verdicts. It is called after
+// each rejudged submission is judged and does nothing until the last one is.
+func finishRejudge(rejudgeID primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	remaining, err := pendingRejudgeSubmissions(ctx, rejudgeID)
+	if err != nil {
+		log.Printf("Failed to count submissions of rejudge %s: %v", rejudgeID.Hex(), err)
+		return
+	}
+	if remaining > 0 {
+		return
+	}
+
+	// Only the caller that marks the rejudge finished does the recomputing
+	var rejudge models.Rejudge
+	err = database.GetCollection("OJ", "rejudges").FindOneAndUpdate(ctx,
+		bson.M{"_id": rejudgeID, "finished_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"finished_at": time.Now()}},
+	).Decode(&rejudge)
+	if err == mongo.ErrNoDocuments {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to finish rejudge %s: %v", rejudgeID.Hex(), err)
+		return
+	}
+
+	for _, userID := range rejudge.UserIDs {
+		if err := UpdateUserStats(userID); err != nil {
+			log.Printf("Failed to update stats of user %s after rejudge %s: %v", userID.Hex(), rejudgeID.Hex(), err)
+		}
+		if err := RecomputeUserSkills(ctx, userID); err != nil {
+			log.Printf("Failed to recompute skills of user %s after rejudge %s: %v", userID.Hex(), rejudgeID.Hex(), err)
+		}
+	}
+	for _, problemID := range rejudge.ProblemIDs {
+		updateProblemAcceptanceRate(ctx, problemID)
+		if err := rebuildProblemStats(ctx, problemID); err != nil {
+			log.Printf("Failed to rebuild stats of problem %s after rejudge %s: %v", problemID, rejudgeID.Hex(), err)
+		}
+	}
+	log.Printf("Rejudge %s finished, recomputed stats of %d users and %d problems", rejudgeID.Hex(), len(rejudge.UserIDs), len(rejudge.ProblemIDs))
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/signature"
+	"backend/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// SetProblemSignatureHandler sets the function signature of a problem, or
+// removes it when signature is null. Its test cases must then be in the
+// canonical format: one JSON value per parameter, one per line.
+func SetProblemSignatureHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		utils.SendJSONError(w, "Method not allowed. Only PUT or POST is accepted.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload struct {
+		ProblemID string                    `json:"problem_id"`
+		Signature *models.FunctionSignature `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.SendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.ProblemID == "" {
+		utils.SendJSONError(w, "Field 'problem_id' is required", http.StatusBadRequest)
+		return
+	}
+	update := bson.M{"$unset": bson.M{"signature": ""}}
+	if payload.Signature != nil {
+		if err := signature.Validate(*payload.Signature); err != nil {
+			utils.SendJSONError(w, "Invalid signature: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		update = bson.M{"$set": bson.M{"signature": payload.Signature, "updated_at": time.Now()}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := database.GetCollection("OJ", "problems").UpdateOne(ctx, bson.M{"problem_id": payload.ProblemID}, update)
+	if err != nil {
+		log.Printf("Error saving signature of problem %s: %v", payload.ProblemID, err)
+		utils.SendJSONError(w, "Failed to save signature", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		utils.SendJSONError(w, "Problem not found", http.StatusNotFound)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
+		"message": "Signature saved successfully",
+	})
+}
@@ -3,7 +3,11 @@ package handlers
 import (
 	"backend/internal/ai"
 	"backend/internal/database"
+	"backend/internal/judge"
+	"backend/internal/languages"
 	"backend/internal/models"
+	"backend/internal/queue"
+	"backend/internal/storage"
 	"backend/internal/types"
 	"backend/internal/utils"
 	"context"
@@ -12,10 +16,11 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson"
@@ -24,19 +29,26 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Submission queue for processing submissions
-var (
-	submissionQueue     = make(chan primitive.ObjectID, 100) // Buffer for 100 submissions
-	submissionQueueLock sync.Mutex
-	isProcessing        = false
-)
+// FailFast stops judging a submission at its first failed test, marking the
+// rest skipped, when its problem is scored all-or-nothing. Configured through
+// JUDGE_FAIL_FAST.
+var FailFast = false
+
+// Sources keeps the code and test logs of submissions. The servers replace it
+// with the store configured through SOURCE_STORE once the database is up.
+var Sources storage.SourceStore = storage.NewLocalStore(storage.DefaultLocalDir)
+
+// MaxStoredOutputBytes caps the output and error of a test kept with its
+// result, beyond which they are truncated with a marker. Configured through
+// JUDGE_MAX_STORED_OUTPUT_KB.
+var MaxStoredOutputBytes = 64 * 1024
 
-// Initialize the submissions directory
 func init() {
-	// Create the submissions directory if it doesn't exist
-	submissionsDir := "./submissions"
-	if err := os.MkdirAll(submissionsDir, 0755); err != nil {
-		log.Printf("Failed to create submissions directory: %v", err)
+	if v, err := strconv.ParseBool(os.Getenv("JUDGE_FAIL_FAST")); err == nil {
+		FailFast = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_MAX_STORED_OUTPUT_KB")); err == nil && v > 0 {
+		MaxStoredOutputBytes = v * 1024
 	}
 }
 
@@ -47,9 +59,6 @@ func SubmitSolutionHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Start the submission processing queue if it's not already running
-	go startSubmissionProcessor()
-
 	// Get user ID from JWT token
 	cookie, err := r.Cookie("authToken")
 	if err != nil {
@@ -87,6 +96,12 @@ func SubmitSolutionHandler(w http.ResponseWriter, r *http.Request) {
 		utils.SendJSONError(w, "Problem ID, language, and code are required", http.StatusBadRequest)
 		return
 	}
+	if lang, ok := languages.Lookup(submissionData.Language); ok {
+		submissionData.Language = lang.Name
+	} else if submissionData.Language != "pseudocode" {
+		utils.SendJSONError(w, "Unsupported language: "+submissionData.Language, http.StatusBadRequest)
+		return
+	}
 
 	// Create submission record
 	submission := models.Submission{
@@ -128,52 +143,37 @@ func SubmitSolutionHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		// We'll run the Python code, but save the original pseudocode for reference
-		// The python code will be saved in a separate file in the submission directory
-		// Let's create the submission directory first.
-		submissionDir := filepath.Join("./submissions", submissionID.Hex())
-		if err := os.MkdirAll(submissionDir, 0755); err != nil {
-			log.Printf("Failed to create submission directory: %v", err)
-			utils.SendJSONError(w, "Server error during submission", http.StatusInternalServerError)
-			return
-		}
-
-		// Save the original pseudocode
-		pseudocodePath := filepath.Join(submissionDir, "code.pseudo")
-		if err := os.WriteFile(pseudocodePath, []byte(submissionData.Code), 0644); err != nil {
-			log.Printf("Failed to write pseudocode file: %v", err)
+		if err := putSource(submissionID, storage.CodeFileName("pseudocode"), []byte(submissionData.Code)); err != nil {
+			log.Printf("Failed to store pseudocode: %v", err)
 			utils.SendJSONError(w, "Server error during submission", http.StatusInternalServerError)
 			return
 		}
 
 		// Save the converted Python code
-		pythonCodePath := filepath.Join(submissionDir, "code.py")
-		if err := os.WriteFile(pythonCodePath, []byte(pythonCode), 0644); err != nil {
-			log.Printf("Failed to write python code file: %v", err)
+		if err := putSource(submissionID, storage.CodeFileName("python"), []byte(pythonCode)); err != nil {
+			log.Printf("Failed to store python code: %v", err)
 			utils.SendJSONError(w, "Server error during submission", http.StatusInternalServerError)
 			return
 		}
-		// Queue the submission for processing
-		submissionQueue <- submissionID
 	} else {
 		// For other languages, save the code directly
-		submissionDir := filepath.Join("./submissions", submissionID.Hex())
-		if err := os.MkdirAll(submissionDir, 0755); err != nil {
-			log.Printf("Failed to create submission directory: %v", err)
-			utils.SendJSONError(w, "Server error during submission", http.StatusInternalServerError)
-			return
-		}
-
-		fileExtension := utils.GetFileExtension(submission.Language)
-		codeFilePath := filepath.Join(submissionDir, "code"+fileExtension)
-		if err := os.WriteFile(codeFilePath, []byte(submissionData.Code), 0644); err != nil {
-			log.Printf("Failed to write code file: %v", err)
+		if err := putSource(submissionID, storage.CodeFileName(submission.Language), []byte(submissionData.Code)); err != nil {
+			log.Printf("Failed to store code: %v", err)
 			utils.SendJSONError(w, "Server error during submission", http.StatusInternalServerError)
 			return
 		}
+	}
 
-		// Queue the submission for processing
-		submissionQueue <- submissionID
+	// Queue the submission for the judge workers. If this fails the submission
+	// stays PENDING and is picked up by the startup sweep.
+	enqueueCtx, cancelEnqueue := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelEnqueue()
+	if err := queue.Enqueue(enqueueCtx, submissionID); err != nil {
+		log.Printf("Failed to enqueue submission %s: %v", submissionID.Hex(), err)
+		utils.SendJSONError(w, "Failed to queue submission for judging", http.StatusInternalServerError)
+		return
 	}
+	publishSubmissionEvent(submissionID, models.EventQueued, nil)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
@@ -183,37 +183,22 @@ func SubmitSolutionHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Start the submission processor if it's not already running
-func startSubmissionProcessor() {
-	submissionQueueLock.Lock()
-	if !isProcessing {
-		isProcessing = true
-		go processSubmissionQueue()
-		log.Println("Submission processor started.")
-	}
-	submissionQueueLock.Unlock()
-}
-
-// Process submissions in the queue
-func processSubmissionQueue() {
-	for submissionID := range submissionQueue {
-		// Process the submission
-		processSubmission(submissionID)
-
-		// Check if queue is empty
-		submissionQueueLock.Lock()
-		if len(submissionQueue) == 0 {
-			isProcessing = false
-			submissionQueueLock.Unlock()
-			break
-		}
-		submissionQueueLock.Unlock()
-	}
+// putSource stores a file of submissionID in Sources with a deadline of its
+// own, as a pseudocode conversion or a test run before it may have outlasted
+// the caller's.
+func putSource(submissionID primitive.ObjectID, name string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return Sources.Put(ctx, submissionID, name, data)
 }
 
-// Process a single submission
-func processSubmission(submissionID primitive.ObjectID) {
+// ProcessSubmission judges a single submission and records its verdict. It is
+// the work function run by the judge queue workers. A returned error means the
+// submission could not be judged (database or executor failure) and should be
+// retried; verdicts themselves are recorded on the submission.
+func ProcessSubmission(submissionID primitive.ObjectID) error {
 	log.Printf("Processing submission: %s", submissionID.Hex())
+	startedAt := time.Now()
 
 	// Get submission details from database
 	submissionsCollection := database.GetCollection("OJ", "submissions")
@@ -223,9 +208,7 @@ func processSubmission(submissionID primitive.ObjectID) {
 	var submission models.Submission
 	err := submissionsCollection.FindOne(ctx, bson.M{"_id": submissionID}).Decode(&submission)
 	if err != nil {
-		log.Printf("Failed to retrieve submission %s: %v", submissionID.Hex(), err)
-		updateSubmissionStatus(submissionID, models.StatusRuntimeError, 0, 0, 0, 0, "", "", nil)
-		return
+		return fmt.Errorf("failed to retrieve submission %s: %w", submissionID.Hex(), err)
 	}
 
 	// Get problem details to know test cases and time limits
@@ -233,9 +216,7 @@ func processSubmission(submissionID primitive.ObjectID) {
 	var problem models.Problem
 	err = problemsCollection.FindOne(ctx, bson.M{"problem_id": submission.ProblemID}).Decode(&problem)
 	if err != nil {
-		log.Printf("Failed to retrieve problem %s: %v", submission.ProblemID, err)
-		updateSubmissionStatus(submissionID, models.StatusRuntimeError, 0, 0, 0, 0, "", "", nil)
-		return
+		return fmt.Errorf("failed to retrieve problem %s: %w", submission.ProblemID, err)
 	}
 
 	// Get test cases for the problem
@@ -243,49 +224,135 @@ func processSubmission(submissionID primitive.ObjectID) {
 	findOptions := options.Find().SetSort(bson.D{{Key: "sequence_number", Value: 1}})
 	cursor, err := testCasesCollection.Find(ctx, bson.M{"problem_db_id": problem.ID}, findOptions)
 	if err != nil {
-		log.Printf("Failed to find test cases for problem %s: %v", submission.ProblemID, err)
-		updateSubmissionStatus(submissionID, models.StatusRuntimeError, 0, 0, 0, 0, "", "", nil)
-		return
+		return fmt.Errorf("failed to find test cases for problem %s: %w", submission.ProblemID, err)
 	}
 	defer cursor.Close(ctx)
 
 	var testCases []models.TestCase
 	if err = cursor.All(ctx, &testCases); err != nil {
-		log.Printf("Failed to decode test cases for problem %s: %v", submission.ProblemID, err)
-		updateSubmissionStatus(submissionID, models.StatusRuntimeError, 0, 0, 0, 0, "", "", nil)
-		return
+		return fmt.Errorf("failed to decode test cases for problem %s: %w", submission.ProblemID, err)
 	}
 
 	if len(testCases) == 0 {
 		log.Printf("No test cases found for problem %s", submission.ProblemID)
 		// If there are no test cases, we can consider the submission accepted by default.
-		updateSubmissionStatus(submissionID, models.StatusAccepted, 0, 0, 0, 0, "", "", nil)
-		return
+		verdict := newVerdictRecord(submission, startedAt)
+		verdict.Status = models.StatusAccepted
+		if err := updateSubmissionStatus(submissionID, verdict, nil, "", "", nil); err != nil {
+			return err
+		}
+		if submission.RejudgeID != nil {
+			go finishRejudge(*submission.RejudgeID)
+		}
+		return nil
 	}
 
 	// Read code file
-	submissionDir := filepath.Join("./submissions", submissionID.Hex())
-	fileExtension := utils.GetFileExtension(submission.Language)
-	codeFilePath := filepath.Join(submissionDir, "code"+fileExtension)
-	codeBytes, err := os.ReadFile(codeFilePath)
+	codeBytes, err := Sources.Get(ctx, submissionID, storage.CodeFileName(submission.Language))
 	if err != nil {
-		log.Printf("Failed to read code file for submission %s: %v", submissionID.Hex(), err)
-		updateSubmissionStatus(submissionID, models.StatusRuntimeError, 0, 0, 0, 0, "", "", nil)
-		return
+		return fmt.Errorf("failed to read code file for submission %s: %w", submissionID.Hex(), err)
 	}
 	code := string(codeBytes)
 
 	// Execute code against each test case using the centralized function
 	var testCaseInputs []string
+	var testCaseLimits []runLimits
 	for _, tc := range testCases {
 		testCaseInputs = append(testCaseInputs, tc.Input)
+		testCaseLimits = append(testCaseLimits, testCaseRunLimits(problem, tc, submission.Language))
 	}
 
-	executionResult, err := runCodeAgainstTestCases(context.Background(), submission.Language, submission.ProblemID, code, testCaseInputs)
+	check, err := newOutputChecker(ctx, problem)
 	if err != nil {
-		log.Printf("runCodeAgainstTestCases failed for submission %s: %v", submissionID.Hex(), err)
-		updateSubmissionStatus(submissionID, models.StatusRuntimeError, 0, 0, 0, 0, "", "", nil)
-		return
+		return fmt.Errorf("failed to load checker for problem %s: %w", submission.ProblemID, err)
+	}
+
+	var interactor *types.Interactor
+	if problem.Interactive {
+		interactor, err = loadInteractor(ctx, problem)
+		if err != nil {
+			return fmt.Errorf("failed to load interactor for problem %s: %w", submission.ProblemID, err)
+		}
+	}
+
+	publishSubmissionEvent(submissionID, models.EventCompiling, map[string]interface{}{
+		"language": submission.Language,
+	})
+
+	// Each test's verdict is decided once, as soon as its result is in, so it
+	// can be streamed to the user and a custom checker never runs twice for
+	// one test. Tests finish concurrently, hence the locking
+	verdicts := make([]testVerdict, len(testCases))
+	judged := make([]sync.Once, len(testCases))
+	var checkMu sync.Mutex
+	var checkErr error
+	failedCheck := func() error {
+		checkMu.Lock()
+		defer checkMu.Unlock()
+		return checkErr
+	}
+	judgeResult := func(i int, result types.TestCaseResult) {
+		judged[i].Do(func() {
+			verdict, err := judgeTestResult(result, testCases[i], check)
+			if err != nil {
+				checkMu.Lock()
+				if checkErr == nil {
+					checkErr = err
+				}
+				checkMu.Unlock()
+				return
+			}
+			verdicts[i] = verdict
+			publishSubmissionEvent(submissionID, models.EventTestResult, map[string]interface{}{
+				"test":              i + 1,
+				"total":             len(testCases),
+				"status":            verdict.Status,
+				"execution_time_ms": result.ExecutionTimeMs,
+				"memory_used_kb":    result.MemoryUsedKB,
+			})
+		})
+	}
+
+	// Once a subtask group has failed, its remaining tests cannot change the
+	// score, so they can be skipped to save executor time. Likewise for every
+	// remaining test in fail-fast mode, as long as any failure scores zero
+	subtasks := judge.Subtasks(testCases)
+	skipGroups := problem.SkipFailedGroup && len(subtasks) > 0
+	failFast := FailFast && judge.FailFastAllowed(problem.ScoringMode)
+	hooks := runHooks{
+		OnTestStart: func(i int) {
+			publishSubmissionEvent(submissionID, models.EventRunning, map[string]interface{}{
+				"test":  i + 1,
+				"total": len(testCases),
+			})
+		},
+		OnTestDone: judgeResult,
+	}
+	if skipGroups || failFast {
+		hooks.Skip = skipAfterFailures(testCases, subtasks, failFast, skipGroups, func(j int, result types.TestCaseResult) (models.TestResultStatus, error) {
+			judgeResult(j, result)
+			if err := failedCheck(); err != nil {
+				return "", err
+			}
+			return verdicts[j].Status, nil
+		})
+	}
+	if skipGroups {
+		// A group's failure is then known before the tests of the groups
+		// depending on it start
+		hooks.NewRound = startsGroup(testCases)
+	}
+
+	executionResult, err := runCodeAgainstTestCases(context.Background(), submission.Language, submission.ProblemID, code, problem.Signature, interactor, testCaseInputs, testCaseLimits, hooks)
+	if err != nil {
+		return fmt.Errorf("runCodeAgainstTestCases failed for submission %s: %w", submissionID.Hex(), err)
+	}
+
+	for i, result := range executionResult.Results {
+		judgeResult(i, result)
+	}
+	if err := failedCheck(); err != nil {
+		return fmt.Errorf("failed to judge results of submission %s: %w", submissionID.Hex(), err)
 	}
 
 	// Process the results
@@ -293,9 +360,20 @@ func processSubmission(submissionID primitive.ObjectID) {
 	var finalStatus models.SubmissionStatus = models.StatusAccepted
 	var firstFailedResult *models.SubmissionResult
 	var testResultsLog strings.Builder
+	var outcomes []judge.TestOutcome
 
 	submissionResultsCollection := database.GetCollection("OJ", "submission_results")
 
+	// Clear results left behind by an earlier attempt that failed part-way. The
+	// run may have outlasted ctx, so each write from here on gets its own
+	// deadline
+	clearCtx, cancelClear := context.WithTimeout(context.Background(), 10*time.Second)
+	_, err = submissionResultsCollection.DeleteMany(clearCtx, bson.M{"submission_id": submissionID})
+	cancelClear()
+	if err != nil {
+		return fmt.Errorf("failed to clear previous results for submission %s: %w", submissionID.Hex(), err)
+	}
+
 	for i, result := range executionResult.Results {
 		tc := testCases[i]
 		submissionResult := models.SubmissionResult{
@@ -304,41 +382,69 @@ func processSubmission(submissionID primitive.ObjectID) {
 			SequenceNumber:  tc.SequenceNumber,
 			Input:           tc.Input,
 			ExpectedOutput:  tc.ExpectedOutput,
-			ActualOutput:    result.Stdout,
+			ActualOutput:    truncateOutput(result.Stdout),
 			ExecutionTimeMs: int(result.ExecutionTimeMs),
+			WallTimeMs:      int(result.WallTimeMs),
+			TimeLimitMs:     result.TimeLimitMs,
 			MemoryUsedKB:    result.MemoryUsedKB,
-			Error:           result.Stderr,
+			MemoryLimitKB:   result.MemoryLimitKB,
+			Error:           truncateOutput(result.Stderr),
+			Status:          verdicts[i].Status,
+			CheckerMessage:  verdicts[i].Message,
+			Transcript:      result.Transcript,
+			Diagnostics:     result.Diagnostics,
 		}
 
-		if result.Status != "success" {
-			if result.Status == "timeout" {
-				submissionResult.Status = models.TestResultStatusTimeLimitExceeded
-				if finalStatus == models.StatusAccepted {
-					finalStatus = models.StatusTimeLimitExceeded
-				}
-			} else {
-				submissionResult.Status = models.TestResultStatusRuntimeError
-				if finalStatus == models.StatusAccepted {
-					finalStatus = models.StatusRuntimeError
-				}
+		switch submissionResult.Status {
+		case models.TestResultStatusPassed:
+			testCasesPassed++
+		case models.TestResultStatusSkipped:
+			// Only happens after an earlier test failed, which set the verdict
+		case models.TestResultStatusTimeLimitExceeded:
+			if finalStatus == models.StatusAccepted {
+				finalStatus = models.StatusTimeLimitExceeded
+			}
+		case models.TestResultStatusMemoryLimitExceeded:
+			if finalStatus == models.StatusAccepted {
+				finalStatus = models.StatusMemoryLimitExceeded
+			}
+		case models.TestResultStatusRuntimeError:
+			if finalStatus == models.StatusAccepted {
+				finalStatus = models.StatusRuntimeError
+			}
+		case models.TestResultStatusQueryLimitExceeded:
+			if finalStatus == models.StatusAccepted {
+				finalStatus = models.StatusQueryLimitExceeded
+			}
+		case models.TestResultStatusCompilationError:
+			if finalStatus == models.StatusAccepted {
+				finalStatus = models.StatusCompilationError
+			}
+		case models.TestResultStatusSecurityViolation:
+			if finalStatus == models.StatusAccepted {
+				finalStatus = models.StatusSecurityViolation
+			}
+		case models.TestResultStatusOutputLimitExceeded:
+			if finalStatus == models.StatusAccepted {
+				finalStatus = models.StatusOutputLimitExceeded
 			}
-		} else if strings.TrimSpace(result.Stdout) != strings.TrimSpace(tc.ExpectedOutput) {
-			submissionResult.Status = models.TestResultStatusWrongAnswer
+		default:
 			if finalStatus == models.StatusAccepted {
 				finalStatus = models.StatusWrongAnswer
 			}
-		} else {
-			submissionResult.Status = models.TestResultStatusPassed
-			testCasesPassed++
 		}
 
 		totalExecutionTimeMs += int(result.ExecutionTimeMs)
 		totalMemoryUsedKB += result.MemoryUsedKB
+		outcomes = append(outcomes, testOutcome(tc, submissionResult.Status))
 
-		// Save the result of this test case
-		_, err = submissionResultsCollection.InsertOne(ctx, submissionResult)
+		// Save the result of this test case. Without it the verdict would be
+		// missing its details, so the submission is judged again
+		insertCtx, cancelInsert := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err = submissionResultsCollection.InsertOne(insertCtx, submissionResult)
+		cancelInsert()
 		if err != nil {
-			log.Printf("Failed to save submission result for submission %s, test case %d: %v", submissionID.Hex(), i+1, err)
+			return fmt.Errorf("failed to save result of test case %d for submission %s: %w", i+1, submissionID.Hex(), err)
 		}
 
 		// Append to the log string
@@ -347,25 +453,34 @@ func processSubmission(submissionID primitive.ObjectID) {
 		testResultsLog.WriteString(fmt.Sprintf("Expected Output: %s\n", tc.ExpectedOutput))
 		testResultsLog.WriteString(fmt.Sprintf("Actual Output: %s\n", submissionResult.ActualOutput))
 		testResultsLog.WriteString(fmt.Sprintf("Status: %s\n", submissionResult.Status))
+		if submissionResult.CheckerMessage != "" {
+			testResultsLog.WriteString(fmt.Sprintf("Checker: %s\n", submissionResult.CheckerMessage))
+		}
 		if submissionResult.Error != "" {
 			testResultsLog.WriteString(fmt.Sprintf("Error: %s\n", submissionResult.Error))
 		}
+		if submissionResult.Transcript != "" {
+			testResultsLog.WriteString(fmt.Sprintf("Transcript:\n%s", submissionResult.Transcript))
+		}
 		testResultsLog.WriteString("\n")
 
-		if submissionResult.Status != models.TestResultStatusPassed && firstFailedResult == nil {
+		if submissionResult.Status != models.TestResultStatusPassed && submissionResult.Status != models.TestResultStatusSkipped && firstFailedResult == nil {
 			firstFailedResult = &submissionResult
 		}
 	}
 
-	// Write the test results log to a file
-	logFilePath := filepath.Join(submissionDir, "test_results.log")
-	if err := os.WriteFile(logFilePath, []byte(testResultsLog.String()), 0644); err != nil {
+	// Store the test results log next to the code
+	if err := putSource(submissionID, storage.TestResultsLogName, []byte(testResultsLog.String())); err != nil {
 		log.Printf("Failed to write test results log for submission %s: %v", submissionID.Hex(), err)
 	}
 
 	// Update overall submission status
 	var timeComplexity, memoryComplexity string
-	if finalStatus == models.StatusAccepted {
+	if finalStatus == models.StatusAccepted && submission.RejudgeID != nil {
+		// The code has not changed, so a rejudge keeps the earlier analysis
+		// rather than asking the AI again for each submission it touches
+		timeComplexity, memoryComplexity = submission.TimeComplexity, submission.MemoryComplexity
+	} else if finalStatus == models.StatusAccepted {
 		// Perform complexity analysis only if all test cases pass
 		analysisCtx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
@@ -388,11 +503,37 @@ func processSubmission(submissionID primitive.ObjectID) {
 		averageMemoryUsage = totalMemoryUsedKB / len(testCases)
 	}
 
-	updateSubmissionStatus(submissionID, finalStatus, averageExecutionTime, averageMemoryUsage, testCasesPassed, len(testCases), timeComplexity, memoryComplexity, firstFailedResult)
+	score, maxScore := judge.Score(problem.ScoringMode, subtasks, outcomes)
+	groups := judge.GroupResults(subtasks, outcomes)
+
+	verdict := newVerdictRecord(submission, startedAt)
+	verdict.Status = finalStatus
+	verdict.TestCasesPassed = testCasesPassed
+	verdict.TestCasesTotal = len(testCases)
+	verdict.Score = score
+	verdict.MaxScore = maxScore
+	verdict.ExecutionTimeMs = averageExecutionTime
+	verdict.MemoryUsedKB = averageMemoryUsage
+	verdict.ExecutorVersion = executorVersions(executionResult.Results)
+	if err := updateSubmissionStatus(submissionID, verdict, groups, timeComplexity, memoryComplexity, firstFailedResult); err != nil {
+		return err
+	}
 
 	// After processing, check if the submission was accepted and trigger updates.
 	// We run this in a goroutine so it doesn't block the submission processing flow.
 	go func() {
+		// A rejudge recomputes the stats of everyone it touched once it is
+		// done, and is not the user practicing, so no check-in is recorded
+		if submission.RejudgeID != nil {
+			finishRejudge(*submission.RejudgeID)
+			return
+		}
+
+		// Refresh solved counts and partial-credit totals for the user
+		if err := UpdateUserStats(submission.UserID); err != nil {
+			log.Printf("Failed to update user stats for submission %s: %v", submissionID.Hex(), err)
+		}
+
 		if finalStatus == models.StatusAccepted {
 			// Find the associated problem to get its ObjectID
 			var problem models.Problem
@@ -421,13 +562,146 @@ func processSubmission(submissionID primitive.ObjectID) {
 			}
 		}
 	}()
+
+	return nil
+}
+
+// skipAfterFailures returns the Skip hook of a run of testCases: test i is
+// skipped once any earlier test failed with failFast, or once its group or one
+// it depends on failed with skipGroups. verdict returns the verdict of earlier
+// test j, so wrong answers count as failures too; when a test cannot be
+// judged, every test after it is skipped, as the submission is retried.
+func skipAfterFailures(testCases []models.TestCase, subtasks []judge.Subtask, failFast, skipGroups bool, verdict func(j int, result types.TestCaseResult) (models.TestResultStatus, error)) skipTestFunc {
+	return func(i int, earlier []types.TestCaseResult) bool {
+		outcomes := make([]judge.TestOutcome, len(earlier))
+		for j, result := range earlier {
+			status, err := verdict(j, result)
+			if err != nil {
+				return true
+			}
+			outcomes[j] = testOutcome(testCases[j], status)
+		}
+		if failFast && judge.AnyFailed(outcomes) {
+			return true
+		}
+		return skipGroups && judge.ShouldSkip(subtasks, testCases[i].Group, outcomes)
+	}
+}
+
+// startsGroup returns the NewRound hook ending rounds where the group of
+// testCases changes.
+func startsGroup(testCases []models.TestCase) func(i int) bool {
+	return func(i int) bool {
+		return testCases[i].Group != testCases[i-1].Group
+	}
 }
 
-// Update submission status in database
-func updateSubmissionStatus(submissionID primitive.ObjectID, status models.SubmissionStatus,
-	executionTimeMs, memoryUsedKB, testCasesPassed, testCasesTotal int,
-	timeComplexity, memoryComplexity string, firstFailedResult *models.SubmissionResult,
-) {
+// testVerdict is the judged outcome of one test case.
+type testVerdict struct {
+	Status  models.TestResultStatus
+	Message string // From a custom checker or interactor, if any
+}
+
+// judgeTestResult classifies the result of running tc, using check to decide
+// whether the output of a successful run is accepted. Interactive runs come
+// back already judged by the interactor.
+func judgeTestResult(result types.TestCaseResult, tc models.TestCase, check outputChecker) (testVerdict, error) {
+	switch result.Status {
+	case "success":
+		passed, message, err := check(tc.Input, tc.ExpectedOutput, result.Stdout)
+		if err != nil {
+			return testVerdict{}, err
+		}
+		if message == "" {
+			message = result.InteractorMessage
+		}
+		if !passed {
+			return testVerdict{Status: models.TestResultStatusWrongAnswer, Message: message}, nil
+		}
+		return testVerdict{Status: models.TestResultStatusPassed, Message: message}, nil
+	case "wrong_answer":
+		return testVerdict{Status: models.TestResultStatusWrongAnswer, Message: result.InteractorMessage}, nil
+	case "query_limit_exceeded":
+		return testVerdict{Status: models.TestResultStatusQueryLimitExceeded, Message: result.InteractorMessage}, nil
+	case "interactor_error":
+		// Not the solution's fault, so the submission is retried
+		return testVerdict{}, fmt.Errorf("interactor failed on test %d: %s", tc.SequenceNumber, result.Stderr)
+	case statusExecutorError:
+		// The test could not be run at all, so the submission is retried
+		return testVerdict{}, fmt.Errorf("executor failed on test %d: %s", tc.SequenceNumber, result.Error)
+	case "skipped":
+		return testVerdict{Status: models.TestResultStatusSkipped}, nil
+	case "compile_error", "compilation_error":
+		// compilation_error is what executors sent before diagnostics
+		return testVerdict{Status: models.TestResultStatusCompilationError}, nil
+	case "time_limit_exceeded", "timeout":
+		return testVerdict{Status: models.TestResultStatusTimeLimitExceeded}, nil
+	case "memory_limit_exceeded":
+		return testVerdict{Status: models.TestResultStatusMemoryLimitExceeded}, nil
+	case "security_violation":
+		return testVerdict{Status: models.TestResultStatusSecurityViolation}, nil
+	case "output_limit_exceeded":
+		return testVerdict{Status: models.TestResultStatusOutputLimitExceeded}, nil
+	default:
+		return testVerdict{Status: models.TestResultStatusRuntimeError}, nil
+	}
+}
+
+// truncateOutput cuts output down to MaxStoredOutputBytes for storing, on a
+// UTF-8 character boundary, and marks how much was left out.
+func truncateOutput(output string) string {
+	if len(output) <= MaxStoredOutputBytes {
+		return output
+	}
+	cut := MaxStoredOutputBytes
+	for cut > 0 && !utf8.RuneStart(output[cut]) {
+		cut--
+	}
+	return output[:cut] + fmt.Sprintf("\n... [output truncated, %d more bytes]", len(output)-cut)
+}
+
+// testOutcome is what the scoring rules need to know about a judged test.
+func testOutcome(tc models.TestCase, status models.TestResultStatus) judge.TestOutcome {
+	return judge.TestOutcome{
+		Group:  tc.Group,
+		Points: tc.Points,
+		Passed: status == models.TestResultStatusPassed,
+		Status: status,
+	}
+}
+
+// newVerdictRecord starts the record of the verdict a judging of submission
+// that began at startedAt is about to give.
+func newVerdictRecord(submission models.Submission, startedAt time.Time) models.VerdictRecord {
+	return models.VerdictRecord{
+		WorkerID:  submission.ClaimedBy,
+		Attempt:   submission.Attempts,
+		StartedAt: &startedAt,
+		RejudgeID: submission.RejudgeID,
+	}
+}
+
+// executorVersions lists the executor builds that produced results, in the
+// order first seen. It is usually just one.
+func executorVersions(results []types.TestCaseResult) string {
+	var versions []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if r.ExecutorVersion != "" && !seen[r.ExecutorVersion] {
+			seen[r.ExecutorVersion] = true
+			versions = append(versions, r.ExecutorVersion)
+		}
+	}
+	return strings.Join(versions, ", ")
+}
+
+// updateSubmissionStatus records verdict as the submission's current verdict
+// and appends it to its verdict history. It has its own deadline, as judging
+// may have used up the caller's. An error means the verdict was not recorded,
+// so the submission must be judged again.
+func updateSubmissionStatus(submissionID primitive.ObjectID, verdict models.VerdictRecord,
+	groups []models.GroupResult, timeComplexity, memoryComplexity string, firstFailedResult *models.SubmissionResult,
+) error {
 	submissionsCollection := database.GetCollection("OJ", "submissions")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -436,21 +710,28 @@ func updateSubmissionStatus(submissionID primitive.ObjectID, status models.Submi
 	var submission models.Submission
 	err := submissionsCollection.FindOne(ctx, bson.M{"_id": submissionID}).Decode(&submission)
 	if err != nil {
-		log.Printf("Failed to get submission %s: %v", submissionID.Hex(), err)
-		return
+		return fmt.Errorf("failed to get submission %s: %w", submissionID.Hex(), err)
 	}
 
+	judgedAt := time.Now()
+	verdict.JudgedAt = &judgedAt
+	status := verdict.Status
+
 	// Update the submission status
 	update := bson.M{
 		"$set": bson.M{
 			"status":            status,
-			"execution_time_ms": executionTimeMs,
-			"memory_used_kb":    memoryUsedKB,
-			"test_cases_passed": testCasesPassed,
-			"test_cases_total":  testCasesTotal,
+			"execution_time_ms": verdict.ExecutionTimeMs,
+			"memory_used_kb":    verdict.MemoryUsedKB,
+			"test_cases_passed": verdict.TestCasesPassed,
+			"test_cases_total":  verdict.TestCasesTotal,
+			"score":             verdict.Score,
+			"max_score":         verdict.MaxScore,
+			"groups":            groups,
 			"time_complexity":   timeComplexity,
 			"memory_complexity": memoryComplexity,
 		},
+		"$push": bson.M{"verdict_history": verdict},
 	}
 
 	if firstFailedResult != nil {
@@ -459,27 +740,50 @@ func updateSubmissionStatus(submissionID primitive.ObjectID, status models.Submi
 			"expected_output": firstFailedResult.ExpectedOutput,
 			"actual_output":   firstFailedResult.ActualOutput,
 			"error":           firstFailedResult.Error,
+			"checker_message": firstFailedResult.CheckerMessage,
 		}
 	}
+	// A rejudge may replace a compile error with a verdict that has none
+	if firstFailedResult != nil && len(firstFailedResult.Diagnostics) > 0 {
+		update["$set"].(bson.M)["compile_diagnostics"] = firstFailedResult.Diagnostics
+	} else {
+		update["$unset"] = bson.M{"compile_diagnostics": ""}
+	}
 
 	_, err = submissionsCollection.UpdateOne(ctx, bson.M{"_id": submissionID}, update)
 	if err != nil {
-		log.Printf("Failed to update submission status for %s: %v", submissionID.Hex(), err)
-		return
+		return fmt.Errorf("failed to update submission status for %s: %w", submissionID.Hex(), err)
 	}
 
 	log.Printf("Successfully updated submission %s with status: %s", submissionID.Hex(), status)
 
+	verdictData := map[string]interface{}{
+		"status":            status,
+		"test_cases_passed": verdict.TestCasesPassed,
+		"test_cases_total":  verdict.TestCasesTotal,
+		"score":             verdict.Score,
+		"max_score":         verdict.MaxScore,
+		"execution_time_ms": verdict.ExecutionTimeMs,
+		"memory_used_kb":    verdict.MemoryUsedKB,
+	}
+	if firstFailedResult != nil && len(firstFailedResult.Diagnostics) > 0 {
+		verdictData["diagnostics"] = firstFailedResult.Diagnostics
+	}
+	publishSubmissionEvent(submissionID, models.EventVerdict, verdictData)
+
 	// After updating the submission, also update problem-wide statistics in a separate goroutine
 	go func() {
 		// Update problem acceptance rate
 		updateProblemAcceptanceRate(context.Background(), submission.ProblemID)
 
-		// If the solution was accepted, update complexity stats
-		if status == models.StatusAccepted && timeComplexity != "" && memoryComplexity != "" {
+		// If the solution was accepted, update complexity stats. A rejudged
+		// submission was counted before, and finishRejudge rebuilds the stats
+		// of the problems a rejudge touched
+		if status == models.StatusAccepted && timeComplexity != "" && memoryComplexity != "" && submission.RejudgeID == nil {
 			updateProblemStats(context.Background(), submission.ProblemID, timeComplexity, memoryComplexity)
 		}
 	}()
+	return nil
 }
 
 // updateProblemAcceptanceRate recalculates and updates the acceptance rate for a problem
@@ -532,25 +836,44 @@ func updateProblemStats(ctx context.Context, problemID, timeComplexity, memoryCo
 	}
 }
 
-// Execute code with given input
-func executeCode(ctx context.Context, language, code, input string, timeLimitMs int) (*types.ExecutionResult, error) {
-	// For now, we're assuming the execution service can handle a raw string of code.
-	// In a real implementation, you might need to write this to a temporary file.
-
-	result, err := ai.ExecuteCode(language, code, input)
+// rebuildProblemStats recomputes the complexity statistics of a problem from
+// its accepted submissions, e.g. once a rejudge has changed their verdicts.
+func rebuildProblemStats(ctx context.Context, problemID string) error {
+	cursor, err := database.GetCollection("OJ", "submissions").Find(ctx,
+		bson.M{
+			"problem_id":        problemID,
+			"status":            models.StatusAccepted,
+			"time_complexity":   bson.M{"$nin": bson.A{nil, ""}},
+			"memory_complexity": bson.M{"$nin": bson.A{nil, ""}},
+		},
+		options.Find().SetProjection(bson.M{"time_complexity": 1, "memory_complexity": 1}),
+	)
 	if err != nil {
-		return nil, fmt.Errorf("execution failed: %w", err)
+		return err
 	}
-
-	// Convert ai.ExecuteCodeResult to types.ExecutionResult
-	executionResult := &types.ExecutionResult{
-		Status:          result.Status,
-		Output:          result.Output,
-		ExecutionTimeMs: result.ExecutionTimeMs,
-		MemoryUsedKB:    result.MemoryUsedKB,
+	var submissions []models.Submission
+	if err := cursor.All(ctx, &submissions); err != nil {
+		return err
 	}
 
-	return executionResult, nil
+	timeDistribution := make(map[string]int)
+	memoryDistribution := make(map[string]int)
+	for _, submission := range submissions {
+		timeDistribution[submission.TimeComplexity]++
+		memoryDistribution[submission.MemoryComplexity]++
+	}
+	_, err = database.GetCollection("OJ", "problem_stats").UpdateOne(ctx,
+		bson.M{"problem_id": problemID},
+		bson.M{"$set": bson.M{
+			"problem_id":                     problemID,
+			"total_accepted_submissions":     len(submissions),
+			"time_complexity_distribution":   timeDistribution,
+			"memory_complexity_distribution": memoryDistribution,
+			"last_updated_at":                time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
 }
 
 // GetSubmissionsHandler retrieves a list of submissions
@@ -719,6 +1042,8 @@ func GetSubmissionsHandler(w http.ResponseWriter, r *http.Request) {
 			Language:        sub.Language,
 			Status:          sub.Status,
 			ExecutionTimeMs: sub.ExecutionTimeMs,
+			Score:           sub.Score,
+			MaxScore:        sub.MaxScore,
 			SubmittedAt:     sub.SubmittedAt,
 		}
 
@@ -790,23 +1115,7 @@ func GetSubmissionDetailsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user ID from JWT token
-	var userID primitive.ObjectID
-	var isAdmin bool
-
-	cookie, err := r.Cookie("authToken")
-	if err == nil {
-		// Token exists, parse it
-		tokenStr := cookie.Value
-		claims := &types.Claims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
-
-		if err == nil && token.Valid {
-			userID, _ = primitive.ObjectIDFromHex(claims.UserID)
-			isAdmin = claims.IsAdmin
-		}
-	}
+	userID, isAdmin := requestViewer(r)
 
 	// Query database
 	submissionsCollection := database.GetCollection("OJ", "submissions")
@@ -831,6 +1140,13 @@ func GetSubmissionDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Which judge machine gave each verdict is only of interest to admins
+	if !isAdmin {
+		for i := range submission.VerdictHistory {
+			submission.VerdictHistory[i].WorkerID = ""
+		}
+	}
+
 	// Fetch associated user and problem details
 	var user models.User
 	var problem models.Problem
@@ -842,15 +1158,11 @@ func GetSubmissionDetailsHandler(w http.ResponseWriter, r *http.Request) {
 	_ = problemsCollection.FindOne(ctx, bson.M{"problem_id": submission.ProblemID}).Decode(&problem)
 
 	// Read code file
-	submissionDir := filepath.Join("./submissions", submissionID.Hex())
-	var codeFilePath string
-	if submission.Language == "pseudocode" {
-		codeFilePath = filepath.Join(submissionDir, "code.py")
-	} else {
-		fileExtension := utils.GetFileExtension(submission.Language)
-		codeFilePath = filepath.Join(submissionDir, "code"+fileExtension)
+	codeLanguage := submission.Language
+	if codeLanguage == "pseudocode" {
+		codeLanguage = "python"
 	}
-	code, err := os.ReadFile(codeFilePath)
+	code, err := Sources.Get(ctx, submissionID, storage.CodeFileName(codeLanguage))
 	if err != nil {
 		log.Printf("Failed to read code file: %v", err)
 		code = []byte("// Code file not found")
@@ -878,7 +1190,7 @@ func GetSubmissionDetailsHandler(w http.ResponseWriter, r *http.Request) {
 			ctx,
 			bson.M{
 				"submission_id": submissionID,
-				"status":        bson.M{"$ne": models.TestResultStatusPassed},
+				"status":        bson.M{"$nin": []models.TestResultStatus{models.TestResultStatusPassed, models.TestResultStatusSkipped}},
 			},
 			options.FindOne().SetSort(bson.D{{Key: "sequence_number", Value: 1}}),
 		).Decode(&failedResult)
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/events"
+	"backend/internal/models"
+	"backend/internal/types"
+	"backend/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// eventPollInterval is how often a stream looks for new events.
+	eventPollInterval = time.Second
+	// eventHeartbeatInterval is how long a stream may stay silent before a
+	// comment is sent to keep proxies from closing it.
+	eventHeartbeatInterval = 15 * time.Second
+	// eventStreamTimeout ends streams that outlive it; EventSource clients
+	// reconnect and resume from Last-Event-ID.
+	eventStreamTimeout = 10 * time.Minute
+)
+
+// publishSubmissionEvent records a judging event for the submission's event
+// stream. Events only inform watching clients, so failures are logged rather
+// than failing the judging.
+func publishSubmissionEvent(submissionID primitive.ObjectID, eventType models.SubmissionEventType, data map[string]interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := events.Publish(ctx, submissionID, eventType, data); err != nil {
+		log.Printf("Failed to publish event: %v", err)
+	}
+}
+
+// requestViewer returns the user the request's authToken cookie belongs to, if
+// any, and whether they are an admin. A missing or invalid token gives the
+// zero ID.
+func requestViewer(r *http.Request) (userID primitive.ObjectID, isAdmin bool) {
+	cookie, err := r.Cookie("authToken")
+	if err != nil {
+		return userID, false
+	}
+	claims := &types.Claims{}
+	token, err := jwt.ParseWithClaims(cookie.Value, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtKey, nil
+	})
+	if err != nil || !token.Valid {
+		return userID, false
+	}
+	userID, _ = primitive.ObjectIDFromHex(claims.UserID)
+	return userID, claims.IsAdmin
+}
+
+// SubmissionEventsHandler streams the judging of a submission as Server-Sent
+// Events: "queued", "compiling", "running" (test N of M), "test_result" for
+// each judged test and finally "verdict". Each event's ID is its sequence
+// number, so a client reconnecting with Last-Event-ID (or ?last_event_id=)
+// only gets what it missed. The stream ends after a verdict; a client that
+// reconnects after seeing the verdict gets 204 No Content, which tells
+// EventSource to stop.
+func SubmissionEventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.SendJSONError(w, "Method not allowed. Only GET is accepted.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The path is /api/submissions/{id}/events
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 {
+		utils.SendJSONError(w, "Invalid URL format", http.StatusBadRequest)
+		return
+	}
+	submissionID, err := primitive.ObjectIDFromHex(parts[len(parts)-2])
+	if err != nil {
+		utils.SendJSONError(w, "Invalid submission ID format", http.StatusBadRequest)
+		return
+	}
+
+	userID, isAdmin := requestViewer(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), eventStreamTimeout)
+	defer cancel()
+
+	var submission models.Submission
+	err = database.GetCollection("OJ", "submissions").FindOne(ctx, bson.M{"_id": submissionID}).Decode(&submission)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.SendJSONError(w, "Submission not found", http.StatusNotFound)
+		} else {
+			log.Printf("Failed to retrieve submission: %v", err)
+			utils.SendJSONError(w, "Failed to retrieve submission", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !isAdmin && submission.UserID != userID {
+		utils.SendJSONError(w, "You don't have permission to view this submission", http.StatusForbidden)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.SendJSONError(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastSeq := events.ParseLastEventID(lastEventID)
+
+	// A client that already saw the verdict has nothing left to wait for
+	if lastSeq > 0 && submission.Status != models.StatusPending {
+		seen, err := events.Since(ctx, submissionID, lastSeq-1)
+		if err == nil && len(seen) == 1 && seen[0].Type == models.EventVerdict {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Submissions judged before events were recorded only have their verdict
+	if submission.EventSeq == 0 && submission.Status != models.StatusPending {
+		events.WriteSSE(w, models.SubmissionEvent{
+			Type: models.EventVerdict,
+			Data: map[string]interface{}{
+				"status":            submission.Status,
+				"test_cases_passed": submission.TestCasesPassed,
+				"test_cases_total":  submission.TestCasesTotal,
+				"score":             submission.Score,
+				"max_score":         submission.MaxScore,
+				"execution_time_ms": submission.ExecutionTimeMs,
+				"memory_used_kb":    submission.MemoryUsedKB,
+			},
+		})
+		flusher.Flush()
+		return
+	}
+
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+	for {
+		newEvents, err := events.Since(ctx, submissionID, lastSeq)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to stream events: %v", err)
+			}
+			return
+		}
+
+		for _, event := range newEvents {
+			if err := events.WriteSSE(w, event); err != nil {
+				return
+			}
+			lastSeq = event.Seq
+		}
+		if len(newEvents) > 0 {
+			flusher.Flush()
+			lastWrite = time.Now()
+			if newEvents[len(newEvents)-1].Type == models.EventVerdict {
+				return
+			}
+		} else if time.Since(lastWrite) >= eventHeartbeatInterval {
+			if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
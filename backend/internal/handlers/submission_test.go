@@ -2,17 +2,22 @@ package handlers
 
 import (
 	"backend/internal/database"
+	"backend/internal/executor"
+	"backend/internal/judge"
 	"backend/internal/models"
+	"backend/internal/storage"
 	"backend/internal/types"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -279,7 +284,9 @@ func TestSubmissionQueueProcessing(t *testing.T) {
 	}
 
 	// Process the submission
-	processSubmission(submission.ID)
+	if err := ProcessSubmission(submission.ID); err != nil {
+		t.Fatalf("Failed to process submission: %v", err)
+	}
 
 	// Check if submission status was updated
 	var updatedSubmission models.Submission
@@ -420,3 +427,418 @@ func TestGetSubmissionDetailsHandler(t *testing.T) {
 	// Clean up test data
 	cleanupTestData(t, submission.ID, problemID)
 }
+
+// TestTestCaseRunLimits checks that per-test overrides replace the problem's
+// limits and still get the language multiplier
+func TestTestCaseRunLimits(t *testing.T) {
+	problem := models.Problem{TimeLimitMs: 1000, MemoryLimitMB: 128}
+
+	limits := testCaseRunLimits(problem, models.TestCase{}, "cpp")
+	if limits.TimeLimitMs != 1000 || limits.MemoryLimitKB != 128*1024 {
+		t.Errorf("Expected problem limits 1000ms/%dKB, got %dms/%dKB", 128*1024, limits.TimeLimitMs, limits.MemoryLimitKB)
+	}
+
+	stressTest := models.TestCase{TimeLimitMsOverride: 5000, MemoryLimitMBOverride: 512}
+	limits = testCaseRunLimits(problem, stressTest, "python")
+	if limits.TimeLimitMs != 15000 || limits.MemoryLimitKB != 512*1024 {
+		t.Errorf("Expected override limits 15000ms/%dKB, got %dms/%dKB", 512*1024, limits.TimeLimitMs, limits.MemoryLimitKB)
+	}
+}
+
+// TestRunTestInputsWithFakeExecutor runs tests against executor.Fake, with
+// the second failing so the rest of the batch is not started
+func TestRunTestInputsWithFakeExecutor(t *testing.T) {
+	defer func(e executor.Executor) { executor.Default = e }(executor.Default)
+	fake := &executor.Fake{
+		Batch: true,
+		Run: func(req types.ExecutionRequest) (*types.ExecutionResult, error) {
+			if req.Input == "crash" {
+				return &types.ExecutionResult{Status: "runtime_error", Output: "boom"}, nil
+			}
+			return &types.ExecutionResult{Status: "success", Output: req.Input + "\n", ExecutionTimeMs: 5}, nil
+		},
+	}
+	executor.Default = fake
+
+	limits := []runLimits{{TimeLimitMs: 1000}, {TimeLimitMs: 2000}, {TimeLimitMs: 3000}}
+	skipAfterFailure := runHooks{Skip: func(i int, previous []types.TestCaseResult) bool {
+		return len(previous) > 0 && previous[len(previous)-1].Status != "success"
+	}}
+	result := runTestInputs(context.Background(), "cpp", "code", nil, nil, []string{"1", "crash", "3"}, limits, skipAfterFailure)
+
+	statuses := []string{result.Results[0].Status, result.Results[1].Status, result.Results[2].Status}
+	if statuses[0] != "success" || statuses[1] != "runtime_error" || statuses[2] != "skipped" {
+		t.Fatalf("Expected success, runtime_error, skipped, got %v", statuses)
+	}
+	if result.Results[0].Stdout != "1\n" || result.Results[1].Stderr != "boom" {
+		t.Errorf("Unexpected outputs %q and %q", result.Results[0].Stdout, result.Results[1].Stderr)
+	}
+	requests := fake.Requests()
+	if len(requests) != 2 || requests[1].TimeLimitMs != 2000 {
+		t.Errorf("Expected two runs with their own limits, got %+v", requests)
+	}
+}
+
+// TestRunTestInputsStopsOnWrongAnswer checks that a wrong answer, which only
+// the backend can tell, stops the tests after its round in fail-fast judging,
+// even for languages run in batches
+func TestRunTestInputsStopsOnWrongAnswer(t *testing.T) {
+	defer func(e executor.Executor) { executor.Default = e }(executor.Default)
+	defer func(n int) { TestParallelism = n }(TestParallelism)
+	TestParallelism = 2
+	fake := &executor.Fake{
+		Batch: true,
+		Run: func(req types.ExecutionRequest) (*types.ExecutionResult, error) {
+			if req.Input == "1" {
+				return &types.ExecutionResult{Status: "success", Output: "wrong\n"}, nil
+			}
+			return &types.ExecutionResult{Status: "success", Output: req.Input + "\n"}, nil
+		},
+	}
+	executor.Default = fake
+
+	check, err := newOutputChecker(context.Background(), models.Problem{})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	inputs := []string{"1", "2", "3", "4", "5"}
+	limits := make([]runLimits, len(inputs))
+	failFast := runHooks{Skip: func(i int, earlier []types.TestCaseResult) bool {
+		for j, result := range earlier {
+			verdict, err := judgeTestResult(result, models.TestCase{Input: inputs[j], ExpectedOutput: inputs[j]}, check)
+			if err != nil || verdict.Status != models.TestResultStatusPassed {
+				return true
+			}
+		}
+		return false
+	}}
+	result := runTestInputs(context.Background(), "cpp", "code", nil, nil, inputs, limits, failFast)
+
+	if got := len(fake.Requests()); got != 2 {
+		t.Errorf("Expected only the first round of 2 tests to run, got %d runs", got)
+	}
+	for i := 2; i < len(inputs); i++ {
+		if result.Results[i].Status != "skipped" {
+			t.Errorf("Expected test %d to be skipped, got %s", i+1, result.Results[i].Status)
+		}
+	}
+}
+
+// TestSkipAfterFailuresDependentGroup checks that a wrong answer in a group
+// skips the rest of it and the groups depending on it, without their tests
+// being sent along with it
+func TestSkipAfterFailuresDependentGroup(t *testing.T) {
+	defer func(e executor.Executor) { executor.Default = e }(executor.Default)
+	defer func(n int) { TestParallelism = n }(TestParallelism)
+	TestParallelism = 4
+	fake := &executor.Fake{
+		Batch: true,
+		Run: func(req types.ExecutionRequest) (*types.ExecutionResult, error) {
+			if req.Input == "2" {
+				return &types.ExecutionResult{Status: "success", Output: "wrong\n"}, nil
+			}
+			return &types.ExecutionResult{Status: "success", Output: req.Input + "\n"}, nil
+		},
+	}
+	executor.Default = fake
+
+	testCases := []models.TestCase{
+		{Input: "1", ExpectedOutput: "1", Group: "small"},
+		{Input: "2", ExpectedOutput: "2", Group: "small"},
+		{Input: "3", ExpectedOutput: "3", Group: "small"},
+		{Input: "4", ExpectedOutput: "4", Group: "large", GroupDependsOn: []string{"small"}},
+		{Input: "5", ExpectedOutput: "5", Group: "large"},
+		{Input: "6", ExpectedOutput: "6", Group: "other"},
+	}
+	check, err := newOutputChecker(context.Background(), models.Problem{})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	inputs := make([]string, len(testCases))
+	for i, tc := range testCases {
+		inputs[i] = tc.Input
+	}
+	hooks := runHooks{
+		Skip: skipAfterFailures(testCases, judge.Subtasks(testCases), false, true, func(j int, result types.TestCaseResult) (models.TestResultStatus, error) {
+			verdict, err := judgeTestResult(result, testCases[j], check)
+			return verdict.Status, err
+		}),
+		NewRound: startsGroup(testCases),
+	}
+	result := runTestInputs(context.Background(), "cpp", "code", nil, nil, inputs, make([]runLimits, len(inputs)), hooks)
+
+	want := []string{"success", "success", "success", "skipped", "skipped", "success"}
+	for i, status := range want {
+		if result.Results[i].Status != status {
+			t.Errorf("Expected test %d to be %s, got %s", i+1, status, result.Results[i].Status)
+		}
+	}
+	if got := len(fake.Requests()); got != 4 {
+		t.Errorf("Expected the small group and the independent test to run, got %d runs", got)
+	}
+}
+
+// TestRunTestInputsLocally runs real Python code through the executor the
+// containers run, started on this machine
+func TestRunTestInputsLocally(t *testing.T) {
+	dir, err := executor.FindExecutorsDir()
+	if err != nil {
+		t.Fatalf("Failed to find the executors: %v", err)
+	}
+	local := executor.NewLocal(dir)
+	if err := local.Available("python"); err != nil {
+		t.Skip(err)
+	}
+	defer local.Close()
+	defer func(e executor.Executor) { executor.Default = e }(executor.Default)
+	executor.Default = local
+
+	code := "n = int(input())\nprint(n * n)\n"
+	limits := []runLimits{{TimeLimitMs: 2000}, {TimeLimitMs: 2000}, {TimeLimitMs: 2000}}
+	result := runTestInputs(context.Background(), "python", code, nil, nil, []string{"2", "3", "x"}, limits, runHooks{})
+
+	if result.Results[0].Stdout != "4\n" || result.Results[1].Stdout != "9\n" {
+		t.Errorf("Expected 4 and 9, got %q and %q", result.Results[0].Stdout, result.Results[1].Stdout)
+	}
+	if result.Results[2].Status != "runtime_error" || !strings.Contains(result.Results[2].Stderr, "ValueError") {
+		t.Errorf("Expected a runtime error with a ValueError, got %+v", result.Results[2])
+	}
+	if result.Status != "error" {
+		t.Errorf("Expected status error, got %s", result.Status)
+	}
+}
+
+// TestJudgeTestResultInteractive checks that verdicts of interactive runs are
+// taken from the interactor and that a failing interactor is not blamed on
+// the solution
+func TestJudgeTestResultInteractive(t *testing.T) {
+	check, err := newOutputChecker(context.Background(), models.Problem{Interactive: true})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	tc := models.TestCase{SequenceNumber: 1, Input: "42"}
+
+	verdict, err := judgeTestResult(types.TestCaseResult{Status: "success", InteractorMessage: "found in 6 guesses"}, tc, check)
+	if err != nil || verdict.Status != models.TestResultStatusPassed || verdict.Message != "found in 6 guesses" {
+		t.Errorf("Expected PASSED with interactor message, got %+v (err %v)", verdict, err)
+	}
+
+	verdict, err = judgeTestResult(types.TestCaseResult{Status: "wrong_answer", InteractorMessage: "guessed 41"}, tc, check)
+	if err != nil || verdict.Status != models.TestResultStatusWrongAnswer || verdict.Message != "guessed 41" {
+		t.Errorf("Expected WRONG_ANSWER with interactor message, got %+v (err %v)", verdict, err)
+	}
+
+	verdict, err = judgeTestResult(types.TestCaseResult{Status: "query_limit_exceeded"}, tc, check)
+	if err != nil || verdict.Status != models.TestResultStatusQueryLimitExceeded {
+		t.Errorf("Expected QUERY_LIMIT_EXCEEDED, got %+v (err %v)", verdict, err)
+	}
+
+	if _, err := judgeTestResult(types.TestCaseResult{Status: "interactor_error"}, tc, check); err == nil {
+		t.Error("Expected an error when the interactor fails")
+	}
+}
+
+// TestJudgeTestResultCompileError checks that code which does not compile is
+// given a compilation error rather than a runtime error
+func TestJudgeTestResultCompileError(t *testing.T) {
+	check, err := newOutputChecker(context.Background(), models.Problem{})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	tc := models.TestCase{SequenceNumber: 1, Input: "1", ExpectedOutput: "1"}
+
+	for _, status := range []string{"compile_error", "compilation_error"} {
+		verdict, err := judgeTestResult(types.TestCaseResult{Status: status}, tc, check)
+		if err != nil || verdict.Status != models.TestResultStatusCompilationError {
+			t.Errorf("Expected COMPILATION_ERROR for %s, got %+v (err %v)", status, verdict, err)
+		}
+	}
+
+	results := []types.TestCaseResult{
+		{Status: "success"},
+		{Status: "compile_error", Diagnostics: []models.CompileDiagnostic{{File: "solution.cpp", Line: 3}}},
+	}
+	if failed := compileFailed(results); failed == nil || failed.Diagnostics[0].Line != 3 {
+		t.Errorf("Expected the second result to be found, got %+v", failed)
+	}
+	if failed := compileFailed(results[:1]); failed != nil {
+		t.Errorf("Expected no compile failure, got %+v", failed)
+	}
+}
+
+// TestJudgeTestResultExecutorError checks that a test the executor could not
+// run is not blamed on the code: judging it fails, so the submission is
+// retried
+func TestJudgeTestResultExecutorError(t *testing.T) {
+	check, err := newOutputChecker(context.Background(), models.Problem{})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	tc := models.TestCase{SequenceNumber: 1, Input: "1", ExpectedOutput: "1"}
+
+	defer func(e executor.Executor) { executor.Default = e }(executor.Default)
+	executor.Default = &executor.Fake{Run: func(req types.ExecutionRequest) (*types.ExecutionResult, error) {
+		return nil, errors.New("circuit open")
+	}}
+	result := runTestInputs(context.Background(), "go", "code", nil, nil, []string{"1"}, []runLimits{{TimeLimitMs: 1000}}, runHooks{})
+
+	if verdict, err := judgeTestResult(result.Results[0], tc, check); err == nil {
+		t.Errorf("Expected an error to retry the submission, got verdict %+v", verdict)
+	}
+}
+
+// TestProcessSubmissionRetriesExecutorFailure checks that a submission whose
+// tests could not be run is left PENDING for the queue to retry rather than
+// given a verdict
+func TestProcessSubmissionRetriesExecutorFailure(t *testing.T) {
+	defer func(e executor.Executor) { executor.Default = e }(executor.Default)
+	fake := &executor.Fake{Run: func(req types.ExecutionRequest) (*types.ExecutionResult, error) {
+		return nil, errors.New("executor unreachable")
+	}}
+	executor.Default = fake
+
+	problemID := createTestProblem(t)
+	submission := models.Submission{
+		ID:          primitive.NewObjectID(),
+		UserID:      primitive.NewObjectID(),
+		ProblemID:   problemID,
+		Language:    "go",
+		Status:      models.StatusPending,
+		SubmittedAt: time.Now(),
+	}
+	defer cleanupTestData(t, submission.ID, problemID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	submissionsCollection := database.GetCollection("OJ", "submissions")
+	if _, err := submissionsCollection.InsertOne(ctx, submission); err != nil {
+		t.Fatalf("Failed to create test submission: %v", err)
+	}
+	code := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tvar a, b int\n\tfmt.Scan(&a, &b)\n\tfmt.Println(a + b)\n}\n"
+	if err := Sources.Put(ctx, submission.ID, storage.CodeFileName("go"), []byte(code)); err != nil {
+		t.Fatalf("Failed to store code: %v", err)
+	}
+
+	if err := ProcessSubmission(submission.ID); err == nil {
+		t.Fatal("Expected an error so the queue retries the submission")
+	}
+	if len(fake.Requests()) == 0 {
+		t.Error("Expected the executor to be asked to run the code")
+	}
+
+	var stored models.Submission
+	if err := submissionsCollection.FindOne(ctx, bson.M{"_id": submission.ID}).Decode(&stored); err != nil {
+		t.Fatalf("Failed to retrieve submission: %v", err)
+	}
+	if stored.Status != models.StatusPending {
+		t.Errorf("Expected the submission to stay PENDING, got %s", stored.Status)
+	}
+	count, err := database.GetCollection("OJ", "submission_results").CountDocuments(ctx, bson.M{"submission_id": submission.ID})
+	if err != nil || count != 0 {
+		t.Errorf("Expected no stored results, got %d (err %v)", count, err)
+	}
+}
+
+// TestJudgeTestResultSecurityViolation checks that a run killed by the sandbox
+// gets its own verdict rather than a runtime error
+func TestJudgeTestResultSecurityViolation(t *testing.T) {
+	check, err := newOutputChecker(context.Background(), models.Problem{})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	tc := models.TestCase{SequenceNumber: 1, Input: "1", ExpectedOutput: "1"}
+
+	verdict, err := judgeTestResult(types.TestCaseResult{Status: "security_violation"}, tc, check)
+	if err != nil || verdict.Status != models.TestResultStatusSecurityViolation {
+		t.Errorf("Expected SECURITY_VIOLATION, got %+v (err %v)", verdict, err)
+	}
+}
+
+// TestJudgeTestResultOutputLimitExceeded checks that a run killed for writing
+// too much gets its own verdict rather than a runtime error
+func TestJudgeTestResultOutputLimitExceeded(t *testing.T) {
+	check, err := newOutputChecker(context.Background(), models.Problem{})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	tc := models.TestCase{SequenceNumber: 1, Input: "1", ExpectedOutput: "1"}
+
+	verdict, err := judgeTestResult(types.TestCaseResult{Status: "output_limit_exceeded"}, tc, check)
+	if err != nil || verdict.Status != models.TestResultStatusOutputLimitExceeded {
+		t.Errorf("Expected OUTPUT_LIMIT_EXCEEDED, got %+v (err %v)", verdict, err)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	defer func(max int) { MaxStoredOutputBytes = max }(MaxStoredOutputBytes)
+	MaxStoredOutputBytes = 8
+
+	if got := truncateOutput("12345678"); got != "12345678" {
+		t.Errorf("Output within the limit was changed to %q", got)
+	}
+	if got, want := truncateOutput("1234567890"), "12345678\n... [output truncated, 2 more bytes]"; got != want {
+		t.Errorf("truncateOutput = %q, want %q", got, want)
+	}
+	// "é" is two bytes, and the cut must not split it
+	if got, want := truncateOutput("1234567é"), "1234567\n... [output truncated, 2 more bytes]"; got != want {
+		t.Errorf("truncateOutput = %q, want %q", got, want)
+	}
+}
+
+func TestNewRejudge(t *testing.T) {
+	if _, _, err := newRejudge(rejudgeRequest{}); err == nil {
+		t.Error("rejudge without a scope was accepted")
+	}
+	if _, _, err := newRejudge(rejudgeRequest{ProblemID: "two-sum", UserID: primitive.NewObjectID().Hex()}); err == nil {
+		t.Error("rejudge with two scopes was accepted")
+	}
+	if _, _, err := newRejudge(rejudgeRequest{SubmissionID: "not-an-id"}); err == nil {
+		t.Error("rejudge with an invalid submission ID was accepted")
+	}
+
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	if _, _, err := newRejudge(rejudgeRequest{ProblemID: "two-sum", From: &to, To: &from}); err == nil {
+		t.Error("rejudge with from after to was accepted")
+	}
+
+	rejudge, filter, err := newRejudge(rejudgeRequest{ProblemID: "two-sum", OnlyAccepted: true, From: &from, To: &to})
+	if err != nil {
+		t.Fatalf("newRejudge failed: %v", err)
+	}
+	if rejudge.ProblemID != "two-sum" || !rejudge.OnlyAccepted {
+		t.Errorf("rejudge = %+v, want problem two-sum with only accepted", rejudge)
+	}
+	want := bson.M{
+		"problem_id":   "two-sum",
+		"status":       models.StatusAccepted,
+		"submitted_at": bson.M{"$gte": from, "$lt": to},
+	}
+	if fmt.Sprint(filter) != fmt.Sprint(want) {
+		t.Errorf("filter = %v, want %v", filter, want)
+	}
+
+	// Submissions still being judged are left alone
+	_, filter, err = newRejudge(rejudgeRequest{UserID: primitive.NewObjectID().Hex()})
+	if err != nil {
+		t.Fatalf("newRejudge failed: %v", err)
+	}
+	if fmt.Sprint(filter["status"]) != fmt.Sprint(bson.M{"$ne": models.StatusPending}) {
+		t.Errorf("status filter = %v, want everything but PENDING", filter["status"])
+	}
+}
+
+func TestExecutorVersions(t *testing.T) {
+	results := []types.TestCaseResult{
+		{ExecutorVersion: "1.4.0"},
+		{},
+		{ExecutorVersion: "1.4.0"},
+		{ExecutorVersion: "1.5.0"},
+	}
+	if got := executorVersions(results); got != "1.4.0, 1.5.0" {
+		t.Errorf("executorVersions = %q, want %q", got, "1.4.0, 1.5.0")
+	}
+	if got := executorVersions(nil); got != "" {
+		t.Errorf("executorVersions(nil) = %q, want empty", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"backend/internal/ai"
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/internal/signature"
 	"backend/internal/utils"
 
 	"context"
@@ -11,6 +12,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -37,6 +39,14 @@ func AddTestCaseHandler(w http.ResponseWriter, r *http.Request) { // Only allowe
 		return
 	}
 	// You might want to add validation for payload.Points and payload.SequenceNumber (e.g., >= 0)
+	if payload.TimeLimitMsOverride < 0 || payload.MemoryLimitMBOverride < 0 {
+		utils.SendJSONError(w, "Time and memory limit overrides cannot be negative.", http.StatusBadRequest)
+		return
+	}
+	if err := validateGroupMetadata(payload.Group, payload.GroupName, payload.GroupPoints, payload.GroupDependsOn); err != nil {
+		utils.SendJSONError(w, fmt.Sprintf("Invalid subtask group: %v", err), http.StatusBadRequest)
+		return
+	}
 
 	problemObjectID, err := primitive.ObjectIDFromHex(payload.ProblemDBID)
 	if err != nil {
@@ -59,15 +69,27 @@ func AddTestCaseHandler(w http.ResponseWriter, r *http.Request) { // Only allowe
 		return
 	}
 
+	input, expectedOutput, err := canonicalTestCase(existingProblem.Signature, payload.Input, payload.ExpectedOutput)
+	if err != nil {
+		utils.SendJSONError(w, fmt.Sprintf("Test case does not match the problem's signature: %v", err), http.StatusBadRequest)
+		return
+	}
+
 	newTestCase := models.TestCase{
-		ProblemDBID:    problemObjectID,
-		Input:          payload.Input,
-		ExpectedOutput: payload.ExpectedOutput,
-		IsSample:       payload.IsSample,
-		Points:         payload.Points,
-		Notes:          payload.Notes,
-		SequenceNumber: payload.SequenceNumber,
-		CreatedAt:      time.Now(),
+		ProblemDBID:           problemObjectID,
+		Input:                 input,
+		ExpectedOutput:        expectedOutput,
+		IsSample:              payload.IsSample,
+		Points:                payload.Points,
+		Notes:                 payload.Notes,
+		SequenceNumber:        payload.SequenceNumber,
+		CreatedAt:             time.Now(),
+		Group:                 payload.Group,
+		GroupName:             payload.GroupName,
+		GroupPoints:           payload.GroupPoints,
+		GroupDependsOn:        payload.GroupDependsOn,
+		TimeLimitMsOverride:   payload.TimeLimitMsOverride,
+		MemoryLimitMBOverride: payload.MemoryLimitMBOverride,
 	}
 
 	testCasesCollection := database.GetCollection("OJ", "test_cases")
@@ -209,12 +231,10 @@ func BulkAddTestCasesHandler(w http.ResponseWriter, r *http.Request) {
 				// Keep the original input if evaluation fails
 				continue
 			}
-			req.TestCases[testName] = map[string]string{
-				"input":  evaluatedInput,
-				"python": "false", // Mark as no longer needing Python evaluation
-				"output": testData["output"],
-			}
 			log.Printf("Evaluated Python expression for test case %s: %s -> %s", testName, ai.TruncateForLogging(testData["input"], 100), ai.TruncateForLogging(evaluatedInput, 100))
+			// Update in place so other fields such as limit overrides are kept
+			testData["input"] = evaluatedInput
+			testData["python"] = "false" // Mark as no longer needing Python evaluation
 		}
 	}
 
@@ -228,21 +248,66 @@ func BulkAddTestCasesHandler(w http.ResponseWriter, r *http.Request) {
 
 	for testName, testData := range req.TestCases {
 		// The actual input is in the "input" key of the inner map.
-		actualInput := testData["input"]
-		expectedOutput := testData["output"]
+		actualInput, expectedOutput, err := canonicalTestCase(existingProblem.Signature, testData["input"], testData["output"])
+		if err != nil {
+			utils.SendJSONError(w, fmt.Sprintf("Test case %s does not match the problem's signature: %v", testName, err), http.StatusBadRequest)
+			return
+		}
 
 		isSample := sequenceNumber <= req.SampleCount
 		notes := testName
 
+		// Optional per-test limits arrive as strings like every other field
+		timeLimitOverride, err := parseLimitOverride(testData["time_limit_ms_override"])
+		if err != nil {
+			utils.SendJSONError(w, fmt.Sprintf("Invalid time_limit_ms_override for test case %s.", testName), http.StatusBadRequest)
+			return
+		}
+		memoryLimitOverride, err := parseLimitOverride(testData["memory_limit_mb_override"])
+		if err != nil {
+			utils.SendJSONError(w, fmt.Sprintf("Invalid memory_limit_mb_override for test case %s.", testName), http.StatusBadRequest)
+			return
+		}
+
+		points := 1 // Default points value
+		if testData["points"] != "" {
+			points, err = utils.ParseInt(testData["points"])
+			if err != nil || points < 0 {
+				utils.SendJSONError(w, fmt.Sprintf("Invalid points for test case %s.", testName), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Subtask metadata; group_depends_on is a comma-separated list of group IDs
+		groupPoints := 0
+		if testData["group_points"] != "" {
+			groupPoints, err = utils.ParseInt(testData["group_points"])
+			if err != nil {
+				utils.SendJSONError(w, fmt.Sprintf("Invalid group_points for test case %s.", testName), http.StatusBadRequest)
+				return
+			}
+		}
+		groupDependsOn := parseGroupList(testData["group_depends_on"])
+		if err := validateGroupMetadata(testData["group"], testData["group_name"], groupPoints, groupDependsOn); err != nil {
+			utils.SendJSONError(w, fmt.Sprintf("Invalid subtask group for test case %s: %v", testName, err), http.StatusBadRequest)
+			return
+		}
+
 		testCases = append(testCases, models.TestCase{
-			ProblemDBID:    problemObjectID,
-			Input:          actualInput,
-			ExpectedOutput: expectedOutput,
-			IsSample:       isSample,
-			Points:         1, // Default points value
-			Notes:          notes,
-			SequenceNumber: sequenceNumber,
-			CreatedAt:      time.Now(),
+			ProblemDBID:           problemObjectID,
+			Input:                 actualInput,
+			ExpectedOutput:        expectedOutput,
+			IsSample:              isSample,
+			Points:                points,
+			Notes:                 notes,
+			SequenceNumber:        sequenceNumber,
+			CreatedAt:             time.Now(),
+			Group:                 testData["group"],
+			GroupName:             testData["group_name"],
+			GroupPoints:           groupPoints,
+			GroupDependsOn:        groupDependsOn,
+			TimeLimitMsOverride:   timeLimitOverride,
+			MemoryLimitMBOverride: memoryLimitOverride,
 		})
 
 		sequenceNumber++
@@ -270,3 +335,61 @@ func BulkAddTestCasesHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 	log.Printf("Added %d test cases for problem %s\n", len(testCases), req.ProblemDBID)
 }
+
+// canonicalTestCase converts a test of a problem with a signature to the
+// canonical format, see signature.ConvertTestCase. Tests of other problems
+// are kept as they are.
+func canonicalTestCase(sig *models.FunctionSignature, input, expectedOutput string) (string, string, error) {
+	if sig == nil {
+		return input, expectedOutput, nil
+	}
+	return signature.ConvertTestCase(*sig, input, expectedOutput)
+}
+
+// parseLimitOverride parses an optional limit override from a bulk upload.
+// An empty value means no override.
+func parseLimitOverride(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := utils.ParseInt(value)
+	if err != nil {
+		return 0, err
+	}
+	if limit < 0 {
+		return 0, fmt.Errorf("limit override cannot be negative: %d", limit)
+	}
+	return limit, nil
+}
+
+// validateGroupMetadata checks the subtask fields of a test case. Metadata
+// only makes sense on a grouped test, and a group cannot depend on itself.
+func validateGroupMetadata(group, name string, points int, dependsOn []string) error {
+	if group == "" {
+		if name != "" || points != 0 || len(dependsOn) > 0 {
+			return fmt.Errorf("group_name, group_points and group_depends_on require a group")
+		}
+		return nil
+	}
+	if points < 0 {
+		return fmt.Errorf("group_points cannot be negative: %d", points)
+	}
+	for _, dep := range dependsOn {
+		if dep == "" || dep == group {
+			return fmt.Errorf("group %q cannot depend on %q", group, dep)
+		}
+	}
+	return nil
+}
+
+// parseGroupList splits a comma-separated list of group IDs from a bulk
+// upload, ignoring surrounding spaces.
+func parseGroupList(value string) []string {
+	var groups []string
+	for _, group := range strings.Split(value, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
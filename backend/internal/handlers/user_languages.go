@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"backend/internal/database"
+	"backend/internal/languages"
 	"backend/internal/models"
 	"backend/internal/utils"
 
@@ -67,6 +68,11 @@ func GetUserLanguages(w http.ResponseWriter, r *http.Request) {
 func UpdateUserLanguageStats(userID primitive.ObjectID, language string, status models.SubmissionStatus) error {
 	ctx := context.Background()
 
+	// Count aliases such as "c++" with their language
+	if lang, ok := languages.Lookup(language); ok {
+		language = lang.Name
+	}
+
 	// Get the user
 	userCollection := database.GetCollection("OJ", "users")
 	var user models.User
@@ -185,32 +191,33 @@ func AdminGenerateLanguageStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Define test language data
-	languages := []struct {
-		name         string
+	// Define test language data for the first languages of the registry,
+	// stored under their canonical names like real submissions
+	registry := languages.All()
+	samples := []struct {
 		submissions  int
 		acceptedRate float64
 	}{
-		{"C++", 97, 0.85},
-		{"Java", 9, 0.67},
-		{"Python", 2, 1.0},
-		{"JavaScript", 39, 0.72},
+		{2, 1.0},
+		{39, 0.72},
+		{97, 0.85},
+		{9, 0.67},
 	}
 
 	// Create language stats object
 	totalSubmissions := 0
 	var userLanguages []models.UserLanguage
 
-	for _, lang := range languages {
-		totalSubmissions += lang.submissions
-		accepted := int(float64(lang.submissions) * lang.acceptedRate)
+	for i, sample := range samples {
+		totalSubmissions += sample.submissions
+		accepted := int(float64(sample.submissions) * sample.acceptedRate)
 
 		userLanguages = append(userLanguages, models.UserLanguage{
 			ID:                primitive.NewObjectID(),
 			UserID:            targetUser.ID,
 			Username:          targetUser.Username,
-			Language:          lang.name,
-			SubmissionCount:   lang.submissions,
+			Language:          registry[i].Name,
+			SubmissionCount:   sample.submissions,
 			AcceptedCount:     accepted,
 			PercentageOfTotal: 0,                                                    // Will be calculated below
 			LastUsed:          time.Now().AddDate(0, 0, -int(time.Now().Weekday())), // Set to beginning of week
@@ -249,7 +256,7 @@ func AdminGenerateLanguageStats(w http.ResponseWriter, r *http.Request) {
 	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
 		"message":          "Test language stats generated successfully",
 		"username":         targetUser.Username,
-		"languageCount":    len(languages),
+		"languageCount":    len(samples),
 		"totalSubmissions": totalSubmissions,
 	})
 }
@@ -215,6 +215,97 @@ func UpdateUserSkill(userID primitive.ObjectID, problemID string, tags []string,
 	return err
 }
 
+// RecomputeUserSkills rebuilds a user's skill profile from every problem they
+// have an accepted submission for. Unlike UpdateUserSkill it also takes back
+// skills for problems that are no longer solved, e.g. after a rejudge.
+func RecomputeUserSkills(ctx context.Context, userID primitive.ObjectID) error {
+	userCollection := database.GetCollection("OJ", "users")
+	var user models.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return err
+	}
+
+	submissionCollection := database.GetCollection("OJ", "submissions")
+	solved, err := submissionCollection.Distinct(ctx, "problem_id", bson.M{
+		"user_id": userID,
+		"status":  models.StatusAccepted,
+	})
+	if err != nil {
+		return err
+	}
+
+	var problems []models.Problem
+	if len(solved) > 0 {
+		cursor, err := database.GetCollection("OJ", "problems").Find(ctx, bson.M{"problem_id": bson.M{"$in": solved}})
+		if err != nil {
+			return err
+		}
+		if err := cursor.All(ctx, &problems); err != nil {
+			return err
+		}
+	}
+
+	skillsCollection := database.GetCollection("OJ", "user_skills")
+	var skillsProfile models.UserSkillsProfile
+	err = skillsCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&skillsProfile)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	// Keep the IDs and practice times of skills the user still has
+	existing := make(map[string]models.UserSkill)
+	for _, skill := range skillsProfile.Skills {
+		existing[skill.SkillName] = skill
+	}
+
+	skills := []models.UserSkill{}
+	index := make(map[string]int)
+	for _, problem := range problems {
+		for _, tag := range problem.Tags {
+			i, ok := index[tag]
+			if !ok {
+				skill, had := existing[tag]
+				if !had {
+					skill = models.UserSkill{ID: primitive.NewObjectID(), LastPracticed: time.Now()}
+				}
+				skill.UserID = userID
+				skill.Username = user.Username
+				skill.SkillName = tag
+				skill.ProblemsSolved, skill.EasyCount, skill.MediumCount, skill.HardCount = 0, 0, 0, 0
+				i = len(skills)
+				index[tag] = i
+				skills = append(skills, skill)
+			}
+
+			skills[i].ProblemsSolved++
+			switch problem.Difficulty {
+			case "Easy":
+				skills[i].EasyCount++
+			case "Medium":
+				skills[i].MediumCount++
+			case "Hard":
+				skills[i].HardCount++
+			}
+		}
+	}
+	for i := range skills {
+		skills[i].Level = calculateSkillLevel(skills[i].ProblemsSolved, skills[i].EasyCount, skills[i].MediumCount, skills[i].HardCount)
+	}
+
+	_, err = skillsCollection.UpdateOne(
+		ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": models.UserSkillsProfile{
+			UserID:        userID,
+			Username:      user.Username,
+			Skills:        skills,
+			LastUpdatedAt: time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
 // AdminGenerateSkillStats creates test skill statistics for development/testing
 func AdminGenerateSkillStats(w http.ResponseWriter, r *http.Request) {
 	// Verify admin access
@@ -98,8 +98,11 @@ func UpdateUserStats(userID primitive.ObjectID) error {
 	submissionCollection := database.GetCollection("OJ", "submissions")
 	problemCollection := database.GetCollection("OJ", "problems")
 
-	// Count total submissions
-	totalSubmissions, err := submissionCollection.CountDocuments(ctx, bson.M{"user_id": userID})
+	// Count total submissions, leaving out those the judge failed on
+	totalSubmissions, err := submissionCollection.CountDocuments(ctx, bson.M{
+		"user_id": userID,
+		"status":  bson.M{"$ne": models.StatusJudgeError},
+	})
 	if err != nil {
 		return err
 	}
@@ -196,9 +199,31 @@ func UpdateUserStats(userID primitive.ObjectID) error {
 		hardSolved = int(hardCount)
 	}
 
+	// Sum the best score on each problem so partial credit counts
+	pipeline = mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "max_score": bson.M{"$gt": 0}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$problem_id", "best": bson.M{"$max": "$score"}}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$best"}}}},
+	}
+
+	cursor, err = submissionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	totalScore := 0
+	if cursor.Next(ctx) {
+		var result struct {
+			Total int `bson:"total"`
+		}
+		if err := cursor.Decode(&result); err == nil {
+			totalScore = result.Total
+		}
+	}
+
 	// Log calculated stats before updating
-	log.Printf("Updating stats for user %s: TotalSolved=%d, Easy=%d, Medium=%d, Hard=%d, TotalSubmissions=%d, AcceptanceRate=%.2f",
-		user.Username, totalSolved, easySolved, mediumSolved, hardSolved, totalSubmissions, acceptanceRate)
+	log.Printf("Updating stats for user %s: TotalSolved=%d, Easy=%d, Medium=%d, Hard=%d, TotalScore=%d, TotalSubmissions=%d, AcceptanceRate=%.2f",
+		user.Username, totalSolved, easySolved, mediumSolved, hardSolved, totalScore, totalSubmissions, acceptanceRate)
 
 	// Calculate ranking (for simplicity, this is a very basic ranking system)
 	// Get all users with their total solved count for ranking
@@ -215,6 +240,7 @@ func UpdateUserStats(userID primitive.ObjectID) error {
 			"easy_solved":       easySolved,
 			"medium_solved":     mediumSolved,
 			"hard_solved":       hardSolved,
+			"total_score":       totalScore,
 			"total_submissions": totalSubmissions,
 			"acceptance_rate":   acceptanceRate,
 			"total_users":       int(totalUsers),
@@ -280,7 +306,8 @@ func UpdateAllRankings(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetRankingsHandler returns the top users ranked by problems solved
+// GetRankingsHandler returns the top users ranked by problems solved, or by
+// total score when called with sort=score
 func GetRankingsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		utils.SendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -312,10 +339,17 @@ func GetRankingsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get user stats sorted by ranking
+	// sort=score orders by partial-credit total instead of problems solved
+	sortByScore := r.URL.Query().Get("sort") == "score"
+	sort := bson.D{{Key: "ranking", Value: 1}} // Sort by ranking (ascending)
+	if sortByScore {
+		sort = bson.D{{Key: "total_score", Value: -1}, {Key: "total_solved", Value: -1}}
+	}
+
+	// Get user stats in the requested order
 	userStatsCollection := database.GetCollection("OJ", "user_stats")
 	opts := options.Find().
-		SetSort(bson.D{{"ranking", 1}}). // Sort by ranking (ascending)
+		SetSort(sort).
 		SetLimit(int64(limit)).
 		SetSkip(int64(skip))
 
@@ -334,6 +368,7 @@ func GetRankingsHandler(w http.ResponseWriter, r *http.Request) {
 		EasySolved   int    `json:"easy_solved"`
 		MediumSolved int    `json:"medium_solved"`
 		HardSolved   int    `json:"hard_solved"`
+		TotalScore   int    `json:"total_score"`
 	}
 
 	for cursor.Next(context.TODO()) {
@@ -349,6 +384,7 @@ func GetRankingsHandler(w http.ResponseWriter, r *http.Request) {
 			EasySolved   int    `json:"easy_solved"`
 			MediumSolved int    `json:"medium_solved"`
 			HardSolved   int    `json:"hard_solved"`
+			TotalScore   int    `json:"total_score"`
 		}{
 			Username:     stats.Username,
 			Ranking:      stats.Ranking,
@@ -356,6 +392,13 @@ func GetRankingsHandler(w http.ResponseWriter, r *http.Request) {
 			EasySolved:   stats.EasySolved,
 			MediumSolved: stats.MediumSolved,
 			HardSolved:   stats.HardSolved,
+			TotalScore:   stats.TotalScore,
+		}
+
+		// Score-ordered lists are numbered by position rather than the
+		// solved-count ranking stored on the stats document
+		if sortByScore {
+			ranking.Ranking = skip + len(rankings) + 1
 		}
 
 		rankings = append(rankings, ranking)
@@ -375,6 +418,7 @@ func GetRankingsHandler(w http.ResponseWriter, r *http.Request) {
 			EasySolved   int    `json:"easy_solved"`
 			MediumSolved int    `json:"medium_solved"`
 			HardSolved   int    `json:"hard_solved"`
+			TotalScore   int    `json:"total_score"`
 		}{}
 	}
 
@@ -0,0 +1,120 @@
+package judge
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend/internal/models"
+)
+
+// DefaultFloatEpsilon is the absolute tolerance of the float checker when a
+// problem sets neither epsilon.
+const DefaultFloatEpsilon = 1e-6
+
+// ValidCheckerMode reports whether mode is a checker the judge knows. The
+// empty mode is valid and means exact.
+func ValidCheckerMode(mode models.CheckerMode) bool {
+	switch mode {
+	case "", models.CheckerExact, models.CheckerTokens, models.CheckerUnorderedLines,
+		models.CheckerFloat, models.CheckerCustom:
+		return true
+	default:
+		return false
+	}
+}
+
+// CompareOutput reports whether actual matches expected under one of the
+// built-in checkers. The custom checker runs a program, so it cannot be
+// decided here and always compares as exact.
+func CompareOutput(mode models.CheckerMode, expected, actual string, absEps, relEps float64) bool {
+	switch mode {
+	case models.CheckerTokens:
+		return equalStrings(strings.Fields(expected), strings.Fields(actual))
+	case models.CheckerUnorderedLines:
+		return equalStrings(sortedLines(expected), sortedLines(actual))
+	case models.CheckerFloat:
+		return equalFloats(strings.Fields(expected), strings.Fields(actual), absEps, relEps)
+	default:
+		return strings.TrimSpace(actual) == strings.TrimSpace(expected)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedLines returns the non-blank lines of s, without trailing whitespace,
+// in sorted order.
+func sortedLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// equalFloats compares token by token. Tokens that are both numbers match if
+// they are within absEps or within relEps of the expected value, or, for NaN
+// and infinities, if they are the same value; any other token must match
+// exactly.
+func equalFloats(expected, actual []string, absEps, relEps float64) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	if absEps == 0 && relEps == 0 {
+		absEps = DefaultFloatEpsilon
+	}
+	for i := range expected {
+		want, errWant := strconv.ParseFloat(expected[i], 64)
+		got, errGot := strconv.ParseFloat(actual[i], 64)
+		if errWant != nil || errGot != nil {
+			if expected[i] != actual[i] {
+				return false
+			}
+			continue
+		}
+		if isNonFinite(want) || isNonFinite(got) {
+			// No tolerance applies, so only the same value matches
+			if !(math.IsNaN(want) && math.IsNaN(got)) && want != got {
+				return false
+			}
+			continue
+		}
+		diff := math.Abs(got - want)
+		if !(diff <= absEps || diff <= relEps*math.Abs(want)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNonFinite reports whether f is NaN or an infinity, which ParseFloat
+// accepts as "nan" and "inf".
+func isNonFinite(f float64) bool {
+	return math.IsNaN(f) || math.IsInf(f, 0)
+}
+
+// ParseCheckerVerdict reads the output of a custom checker: the first line is
+// OK (or AC) when the answer is accepted and anything else when it is not,
+// and the remaining lines are a message for the user.
+func ParseCheckerVerdict(output string) (passed bool, message string) {
+	verdict, message, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	switch strings.ToUpper(strings.TrimSpace(verdict)) {
+	case "OK", "AC":
+		passed = true
+	}
+	return passed, strings.TrimSpace(message)
+}
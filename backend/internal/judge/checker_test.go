@@ -0,0 +1,65 @@
+package judge
+
+import (
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestCompareOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     models.CheckerMode
+		expected string
+		actual   string
+		absEps   float64
+		relEps   float64
+		want     bool
+	}{
+		{"exact ignores surrounding whitespace", models.CheckerExact, "1 2\n", "\n1 2", 0, 0, true},
+		{"exact keeps inner whitespace", models.CheckerExact, "1 2", "1  2", 0, 0, false},
+		{"default is exact", "", "yes", "yes\n", 0, 0, true},
+		{"tokens", models.CheckerTokens, "1 2\n3", "1\n2   3\n", 0, 0, true},
+		{"tokens differ", models.CheckerTokens, "1 2 3", "1 3 2", 0, 0, false},
+		{"unordered lines", models.CheckerUnorderedLines, "a b\nc d\n", "c d  \n\na b", 0, 0, true},
+		{"unordered lines differ", models.CheckerUnorderedLines, "a\nb", "a\na", 0, 0, false},
+		{"float default epsilon", models.CheckerFloat, "0.3333333", "0.33333333", 0, 0, true},
+		{"float outside absolute epsilon", models.CheckerFloat, "1.0", "1.1", 0.01, 0, false},
+		{"float within relative epsilon", models.CheckerFloat, "1000000", "1000001", 0, 1e-5, true},
+		{"float compares words exactly", models.CheckerFloat, "area 2.0", "Area 2.0", 0, 0, false},
+		{"float token count", models.CheckerFloat, "1 2", "1", 0, 0, false},
+		{"float rejects nan", models.CheckerFloat, "1.5", "nan", 0, 0, false},
+		{"float rejects infinity", models.CheckerFloat, "1e300", "inf", 0, 1e-5, false},
+		{"float rejects infinity of the other sign", models.CheckerFloat, "inf", "-inf", 0, 0, false},
+		{"float matches nan", models.CheckerFloat, "NaN", "nan", 0, 0, true},
+		{"float matches infinity", models.CheckerFloat, "inf", "Infinity", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareOutput(tt.mode, tt.expected, tt.actual, tt.absEps, tt.relEps); got != tt.want {
+				t.Errorf("CompareOutput(%q, %q) = %v, want %v", tt.expected, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCheckerVerdict(t *testing.T) {
+	tests := []struct {
+		output      string
+		wantPassed  bool
+		wantMessage string
+	}{
+		{"OK\n", true, ""},
+		{"ac\nvalid pair", true, "valid pair"},
+		{"WA\nindices 1 and 2 do not sum to 9\n", false, "indices 1 and 2 do not sum to 9"},
+		{"", false, ""},
+	}
+
+	for _, tt := range tests {
+		passed, message := ParseCheckerVerdict(tt.output)
+		if passed != tt.wantPassed || message != tt.wantMessage {
+			t.Errorf("ParseCheckerVerdict(%q) = %v, %q, want %v, %q", tt.output, passed, message, tt.wantPassed, tt.wantMessage)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package judge
+
+import "backend/internal/models"
+
+// Subtask is a test case group with its metadata collected from its tests.
+type Subtask struct {
+	ID        string
+	Name      string
+	Points    int // Replaces the sum of the tests' points when set
+	DependsOn []string
+}
+
+// Subtasks collects the groups of testCases in order of first appearance.
+// Metadata is repeated on every test of a group, so the first test that sets
+// a field wins.
+func Subtasks(testCases []models.TestCase) []Subtask {
+	var subtasks []Subtask
+	index := make(map[string]int)
+	for _, tc := range testCases {
+		if tc.Group == "" {
+			continue
+		}
+		i, ok := index[tc.Group]
+		if !ok {
+			i = len(subtasks)
+			index[tc.Group] = i
+			subtasks = append(subtasks, Subtask{ID: tc.Group})
+		}
+		s := &subtasks[i]
+		if s.Name == "" {
+			s.Name = tc.GroupName
+		}
+		if s.Points == 0 {
+			s.Points = tc.GroupPoints
+		}
+		if len(s.DependsOn) == 0 {
+			s.DependsOn = tc.GroupDependsOn
+		}
+	}
+	return subtasks
+}
+
+// failedGroups returns the groups with at least one test that did not pass.
+func failedGroups(outcomes []TestOutcome) map[string]bool {
+	failed := make(map[string]bool)
+	for _, o := range outcomes {
+		if o.Group != "" && !o.Passed {
+			failed[o.Group] = true
+		}
+	}
+	return failed
+}
+
+// dependencyFailed reports whether any group that group depends on, directly
+// or through other groups, has failed. Cycles are ignored.
+func dependencyFailed(subtasks []Subtask, group string, failed map[string]bool) bool {
+	deps := make(map[string][]string, len(subtasks))
+	for _, s := range subtasks {
+		deps[s.ID] = s.DependsOn
+	}
+
+	seen := map[string]bool{group: true}
+	pending := append([]string(nil), deps[group]...)
+	for len(pending) > 0 {
+		dep := pending[0]
+		pending = pending[1:]
+		if seen[dep] {
+			continue
+		}
+		seen[dep] = true
+		if failed[dep] {
+			return true
+		}
+		pending = append(pending, deps[dep]...)
+	}
+	return false
+}
+
+// ShouldSkip reports whether the next test, in group, can be skipped given the
+// outcomes of the tests run before it: its group or a group it depends on has
+// already failed, so it cannot change the group's result. Ungrouped tests are
+// never skipped.
+func ShouldSkip(subtasks []Subtask, group string, earlier []TestOutcome) bool {
+	if group == "" {
+		return false
+	}
+	failed := failedGroups(earlier)
+	return failed[group] || dependencyFailed(subtasks, group, failed)
+}
+
+// GroupResults returns a verdict for each subtask, in the order of subtasks,
+// followed by any group that only appears in outcomes. A group earns its
+// points only if every test in it passed and every group it depends on passed.
+func GroupResults(subtasks []Subtask, outcomes []TestOutcome) []models.GroupResult {
+	known := make(map[string]bool, len(subtasks))
+	for _, s := range subtasks {
+		known[s.ID] = true
+	}
+	for _, o := range outcomes {
+		if o.Group != "" && !known[o.Group] {
+			known[o.Group] = true
+			subtasks = append(subtasks, Subtask{ID: o.Group})
+		}
+	}
+	if len(subtasks) == 0 {
+		return nil
+	}
+
+	failed := failedGroups(outcomes)
+	results := make([]models.GroupResult, len(subtasks))
+	index := make(map[string]int, len(subtasks))
+	for i, s := range subtasks {
+		index[s.ID] = i
+		results[i] = models.GroupResult{Group: s.ID, Name: s.Name, Status: models.TestResultStatusPassed}
+	}
+
+	for _, o := range outcomes {
+		i, ok := index[o.Group]
+		if !ok {
+			continue
+		}
+		r := &results[i]
+		r.TestsTotal++
+		r.MaxScore += weight(o.Points)
+		switch {
+		case o.Passed:
+			r.TestsPassed++
+		case o.Status == models.TestResultStatusSkipped:
+			r.TestsSkipped++
+		case r.Status == models.TestResultStatusPassed || r.Status == models.TestResultStatusSkipped:
+			// Report the first test that actually failed
+			r.Status = o.Status
+		}
+	}
+
+	for i, s := range subtasks {
+		r := &results[i]
+		if s.Points > 0 {
+			r.MaxScore = s.Points
+		}
+		if r.TestsSkipped > 0 && r.Status == models.TestResultStatusPassed {
+			r.Status = models.TestResultStatusSkipped
+		}
+		r.DependencyFailed = dependencyFailed(subtasks, s.ID, failed)
+		if r.DependencyFailed && r.Status == models.TestResultStatusPassed {
+			r.Status = models.TestResultStatusSkipped
+		}
+		if r.TestsPassed == r.TestsTotal && !r.DependencyFailed {
+			r.Score = r.MaxScore
+		}
+	}
+	return results
+}
@@ -0,0 +1,83 @@
+package judge
+
+import (
+	"reflect"
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestSubtasks(t *testing.T) {
+	testCases := []models.TestCase{
+		{Group: "1"},
+		{Group: "1", GroupName: "n <= 10", GroupPoints: 20},
+		{},
+		{Group: "2", GroupName: "n <= 1000", GroupPoints: 80, GroupDependsOn: []string{"1"}},
+	}
+
+	want := []Subtask{
+		{ID: "1", Name: "n <= 10", Points: 20},
+		{ID: "2", Name: "n <= 1000", Points: 80, DependsOn: []string{"1"}},
+	}
+	if got := Subtasks(testCases); !reflect.DeepEqual(got, want) {
+		t.Errorf("Subtasks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestShouldSkip(t *testing.T) {
+	subtasks := []Subtask{
+		{ID: "1"},
+		{ID: "2", DependsOn: []string{"1"}},
+		{ID: "3", DependsOn: []string{"2"}},
+		{ID: "4"},
+	}
+	earlier := []TestOutcome{
+		{Group: "1", Passed: false},
+		{Group: "4", Passed: true},
+	}
+
+	tests := []struct {
+		group string
+		want  bool
+	}{
+		{"1", true},  // Its own group already failed
+		{"2", true},  // Depends on a failed group
+		{"3", true},  // Depends on a failed group through "2"
+		{"4", false}, // Still passing
+		{"", false},  // Ungrouped tests always run
+	}
+	for _, tt := range tests {
+		if got := ShouldSkip(subtasks, tt.group, earlier); got != tt.want {
+			t.Errorf("ShouldSkip(%q) = %v, want %v", tt.group, got, tt.want)
+		}
+	}
+}
+
+func TestGroupResults(t *testing.T) {
+	subtasks := []Subtask{
+		{ID: "1", Name: "small", Points: 30},
+		{ID: "2", Name: "large", Points: 70, DependsOn: []string{"1"}},
+		{ID: "3"},
+	}
+	outcomes := []TestOutcome{
+		{Group: "1", Passed: true, Status: models.TestResultStatusPassed},
+		{Group: "1", Passed: false, Status: models.TestResultStatusWrongAnswer},
+		{Group: "1", Passed: false, Status: models.TestResultStatusSkipped},
+		{Group: "2", Passed: true, Status: models.TestResultStatusPassed},
+		{Group: "3", Points: 5, Passed: true, Status: models.TestResultStatusPassed},
+	}
+
+	want := []models.GroupResult{
+		{Group: "1", Name: "small", Status: models.TestResultStatusWrongAnswer, MaxScore: 30, TestsPassed: 1, TestsSkipped: 1, TestsTotal: 3},
+		{Group: "2", Name: "large", Status: models.TestResultStatusSkipped, MaxScore: 70, TestsPassed: 1, TestsTotal: 1, DependencyFailed: true},
+		{Group: "3", Status: models.TestResultStatusPassed, Score: 5, MaxScore: 5, TestsPassed: 1, TestsTotal: 1},
+	}
+	if got := GroupResults(subtasks, outcomes); !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupResults() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	score, max := Score(models.ScoringGroups, subtasks, outcomes)
+	if score != 5 || max != 105 {
+		t.Errorf("Score() = %d/%d, want 5/105", score, max)
+	}
+}
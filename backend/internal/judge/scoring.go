@@ -0,0 +1,96 @@
+// Package judge holds the rules for turning program output into verdicts and
+// test results into a score. It does no I/O, so the rules can be tested
+// without a database or executors.
+package judge
+
+import "backend/internal/models"
+
+// TestOutcome is the result of one test case as far as scoring is concerned.
+type TestOutcome struct {
+	Group  string // Subtask group, empty for ungrouped tests
+	Points int
+	Passed bool
+	Status models.TestResultStatus // Reported in group verdicts
+}
+
+// ValidScoringMode reports whether mode is a scoring mode the judge knows.
+// The empty mode is valid and means all-or-nothing.
+func ValidScoringMode(mode models.ScoringMode) bool {
+	switch mode {
+	case "", models.ScoringAllOrNothing, models.ScoringSum, models.ScoringGroups:
+		return true
+	default:
+		return false
+	}
+}
+
+// FailFastAllowed reports whether a submission scored with mode can stop at
+// its first failed test without changing its score. Only all-or-nothing
+// scoring can: any failure already means no points.
+func FailFastAllowed(mode models.ScoringMode) bool {
+	return mode == "" || mode == models.ScoringAllOrNothing
+}
+
+// AnyFailed reports whether any test in outcomes did not pass.
+func AnyFailed(outcomes []TestOutcome) bool {
+	for _, o := range outcomes {
+		if !o.Passed {
+			return true
+		}
+	}
+	return false
+}
+
+// weight returns the points a test is worth. Tests without points count as
+// one, so problems whose tests were never given points still score sensibly.
+func weight(points int) int {
+	if points <= 0 {
+		return 1
+	}
+	return points
+}
+
+// Score computes the points earned and the points available for a submission.
+//
+//   - all_or_nothing: every point if all tests pass, nothing otherwise
+//   - sum: the points of every passed test
+//   - groups: a group's points only if every test in it and every group it
+//     depends on passes; ungrouped tests are scored on their own
+//
+// subtasks is only used in groups mode; see Subtasks.
+func Score(mode models.ScoringMode, subtasks []Subtask, outcomes []TestOutcome) (score, maxScore int) {
+	switch mode {
+	case models.ScoringSum:
+		for _, o := range outcomes {
+			maxScore += weight(o.Points)
+			if o.Passed {
+				score += weight(o.Points)
+			}
+		}
+
+	case models.ScoringGroups:
+		for _, g := range GroupResults(subtasks, outcomes) {
+			score += g.Score
+			maxScore += g.MaxScore
+		}
+		for _, o := range outcomes {
+			if o.Group == "" {
+				maxScore += weight(o.Points)
+				if o.Passed {
+					score += weight(o.Points)
+				}
+			}
+		}
+
+	default:
+		allPassed := true
+		for _, o := range outcomes {
+			maxScore += weight(o.Points)
+			allPassed = allPassed && o.Passed
+		}
+		if allPassed {
+			score = maxScore
+		}
+	}
+	return score, maxScore
+}
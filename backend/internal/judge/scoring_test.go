@@ -0,0 +1,78 @@
+package judge
+
+import (
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestScore(t *testing.T) {
+	outcomes := []TestOutcome{
+		{Group: "small", Points: 10, Passed: true},
+		{Group: "small", Points: 10, Passed: true},
+		{Group: "large", Points: 40, Passed: true},
+		{Group: "large", Points: 40, Passed: false},
+	}
+
+	tests := []struct {
+		name      string
+		mode      models.ScoringMode
+		outcomes  []TestOutcome
+		wantScore int
+		wantMax   int
+	}{
+		{"default is all or nothing", "", outcomes, 0, 100},
+		{"all or nothing, all passed", models.ScoringAllOrNothing, outcomes[:3], 60, 60},
+		{"sum", models.ScoringSum, outcomes, 60, 100},
+		{"groups", models.ScoringGroups, outcomes, 20, 100},
+		{"ungrouped tests score on their own", models.ScoringGroups, []TestOutcome{
+			{Points: 5, Passed: true},
+			{Points: 5, Passed: false},
+		}, 5, 10},
+		{"tests without points count as one", models.ScoringSum, []TestOutcome{
+			{Passed: true},
+			{Passed: false},
+			{Passed: true},
+		}, 2, 3},
+		{"no tests", models.ScoringSum, nil, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, max := Score(tt.mode, nil, tt.outcomes)
+			if score != tt.wantScore || max != tt.wantMax {
+				t.Errorf("Score() = %d/%d, want %d/%d", score, max, tt.wantScore, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestValidScoringMode(t *testing.T) {
+	for _, mode := range []models.ScoringMode{"", models.ScoringAllOrNothing, models.ScoringSum, models.ScoringGroups} {
+		if !ValidScoringMode(mode) {
+			t.Errorf("ValidScoringMode(%q) = false, want true", mode)
+		}
+	}
+	if ValidScoringMode("best_of") {
+		t.Errorf("ValidScoringMode(%q) = true, want false", "best_of")
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	if !FailFastAllowed("") || !FailFastAllowed(models.ScoringAllOrNothing) {
+		t.Error("all-or-nothing scoring should allow fail-fast")
+	}
+	if FailFastAllowed(models.ScoringSum) || FailFastAllowed(models.ScoringGroups) {
+		t.Error("partial scoring should not allow fail-fast")
+	}
+
+	if AnyFailed([]TestOutcome{{Passed: true}, {Passed: true}}) {
+		t.Error("AnyFailed = true for passing tests")
+	}
+	if !AnyFailed([]TestOutcome{{Passed: true}, {Passed: false, Status: models.TestResultStatusWrongAnswer}}) {
+		t.Error("AnyFailed = false after a wrong answer")
+	}
+	if AnyFailed(nil) {
+		t.Error("AnyFailed = true for no tests")
+	}
+}
@@ -0,0 +1,137 @@
+// Package languages is the registry of the languages submissions can be
+// written in: what they are called, how their executors build and run them,
+// and what those executors support.
+package languages
+
+import (
+	"strings"
+	"time"
+)
+
+// Language describes one language of the registry.
+type Language struct {
+	Name        string   `json:"name"`         // Canonical name stored with submissions, e.g. "cpp"
+	DisplayName string   `json:"display_name"` // e.g. "C++"
+	Aliases     []string `json:"aliases,omitempty"`
+	Extension   string   `json:"extension"` // File extension with the dot, e.g. ".cpp"
+	// CompileCommand and RunCommand are how the executor builds and runs a
+	// program saved as Source, for display. CompileCommand is empty for
+	// interpreted languages
+	Source         string `json:"source"`
+	CompileCommand string `json:"compile_command,omitempty"`
+	RunCommand     string `json:"run_command"`
+	Version        string `json:"version"`
+	// TimeMultiplier scales a problem's time limit to make up for a slower
+	// runtime
+	TimeMultiplier float64 `json:"time_multiplier"`
+	// ExecutorURL is the address of the Docker executor running the language
+	ExecutorURL string `json:"-"`
+	// Batch is set when the executor's /execute/batch is used to run all tests
+	// of a submission in one request. Python runs on AWS Lambda instead
+	Batch bool `json:"-"`
+	// Signature is set when the executor generates the harness for a
+	// problem's function signature
+	Signature bool `json:"signature"`
+	// CompletePrograms is set for languages whose submissions are complete
+	// programs reading the test input from stdin, which are never wrapped
+	// with the generated parsers
+	CompletePrograms bool `json:"complete_programs"`
+	// The fields below are filled in from the executor's last report, see
+	// Refresh. CompileFlags and Template are empty until it has reported
+	CompileFlags    []string `json:"compile_flags,omitempty"`
+	Template        string   `json:"template,omitempty"`
+	ExecutorVersion string   `json:"executor_version,omitempty"`
+	// Available is set while the executor answers on /info
+	Available  bool       `json:"available"`
+	ReportedAt *time.Time `json:"reported_at,omitempty"`
+}
+
+// registry lists the languages in the order they are offered in.
+var registry = []Language{
+	{
+		Name: "python", DisplayName: "Python", Aliases: []string{"py", "python3"}, Extension: ".py",
+		Source: "code.py", RunCommand: "python3 code.py", Version: "Python 3.12",
+		TimeMultiplier: 3, ExecutorURL: "http://localhost:8001", Signature: true,
+	},
+	{
+		Name: "javascript", DisplayName: "JavaScript", Aliases: []string{"js", "node"}, Extension: ".js",
+		Source: "code.js", RunCommand: "node code.js", Version: "Node.js 22",
+		TimeMultiplier: 1, ExecutorURL: "http://localhost:8002", Batch: true, Signature: true,
+	},
+	{
+		Name: "cpp", DisplayName: "C++", Aliases: []string{"c++", "cxx"}, Extension: ".cpp",
+		Source: "source.cpp", CompileCommand: "g++ -o main source.cpp -std=c++17", RunCommand: "./main", Version: "GCC 13 (C++17)",
+		TimeMultiplier: 1, ExecutorURL: "http://localhost:8003", Batch: true, Signature: true,
+	},
+	{
+		Name: "java", DisplayName: "Java", Extension: ".java",
+		Source: "Solution.java", CompileCommand: "javac Solution.java", RunCommand: "java -cp . Main", Version: "Java 21 (Temurin)",
+		TimeMultiplier: 2, ExecutorURL: "http://localhost:8004", Batch: true, Signature: true,
+	},
+	{
+		Name: "go", DisplayName: "Go", Aliases: []string{"golang"}, Extension: ".go",
+		Source: "main.go", CompileCommand: "go build -o main main.go", RunCommand: "./main", Version: "Go 1.22",
+		TimeMultiplier: 1, ExecutorURL: "http://localhost:8005", Batch: true, CompletePrograms: true,
+	},
+	{
+		Name: "rust", DisplayName: "Rust", Aliases: []string{"rs"}, Extension: ".rs",
+		Source: "main.rs", CompileCommand: "rustc -O --edition 2021 -o main main.rs", RunCommand: "./main", Version: "Rust 1.79",
+		TimeMultiplier: 1, ExecutorURL: "http://localhost:8006", Batch: true, CompletePrograms: true,
+	},
+	{
+		Name: "c", DisplayName: "C", Extension: ".c",
+		Source: "main.c", CompileCommand: "gcc -O2 -std=c17 -o main main.c -lm", RunCommand: "./main", Version: "GCC 13 (C17)",
+		TimeMultiplier: 1, ExecutorURL: "http://localhost:8007", Batch: true, CompletePrograms: true,
+	},
+	{
+		Name: "kotlin", DisplayName: "Kotlin", Aliases: []string{"kt"}, Extension: ".kt",
+		Source: "main.kt", CompileCommand: "kotlinc main.kt -include-runtime -d main.jar", RunCommand: "java -jar main.jar", Version: "Kotlin 2.0 (Java 21)",
+		TimeMultiplier: 2, ExecutorURL: "http://localhost:8008", Batch: true, CompletePrograms: true,
+	},
+	{
+		Name: "typescript", DisplayName: "TypeScript", Aliases: []string{"ts"}, Extension: ".ts",
+		Source: "main.ts", CompileCommand: "tsc --target es2022 --module commonjs main.ts", RunCommand: "node main.js", Version: "TypeScript 5.4 (Node.js 22)",
+		TimeMultiplier: 1, ExecutorURL: "http://localhost:8009", Batch: true, CompletePrograms: true,
+	},
+}
+
+// byName finds a language by its name or any of its aliases, in lower case.
+var byName = func() map[string]int {
+	m := make(map[string]int)
+	for i, lang := range registry {
+		m[lang.Name] = i
+		for _, alias := range lang.Aliases {
+			m[alias] = i
+		}
+	}
+	return m
+}()
+
+// Lookup finds the language called name, which may be an alias and is not
+// case-sensitive.
+func Lookup(name string) (Language, bool) {
+	i, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Language{}, false
+	}
+	return withReport(registry[i]), true
+}
+
+// All returns every language of the registry, in the order they are offered in.
+func All() []Language {
+	all := make([]Language, len(registry))
+	for i, lang := range registry {
+		all[i] = withReport(lang)
+	}
+	return all
+}
+
+// Names returns the canonical names of all languages, in the order they are
+// offered in.
+func Names() []string {
+	names := make([]string, len(registry))
+	for i, lang := range registry {
+		names[i] = lang.Name
+	}
+	return names
+}
@@ -0,0 +1,43 @@
+package languages
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"cpp", "cpp"},
+		{"C++", "cpp"},
+		{" JS ", "javascript"},
+		{"golang", "go"},
+		{"ts", "typescript"},
+	}
+	for _, tt := range tests {
+		lang, ok := Lookup(tt.name)
+		if !ok || lang.Name != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", tt.name, lang.Name, ok, tt.want)
+		}
+	}
+	if lang, ok := Lookup("pseudocode"); ok {
+		t.Errorf("Lookup(pseudocode) = %q, want no language", lang.Name)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	seen := make(map[string]string)
+	for _, lang := range All() {
+		if lang.DisplayName == "" || lang.Extension == "" || lang.ExecutorURL == "" || lang.TimeMultiplier <= 0 {
+			t.Errorf("%s is missing fields: %+v", lang.Name, lang)
+		}
+		for _, name := range append([]string{lang.Name}, lang.Aliases...) {
+			if other, ok := seen[name]; ok {
+				t.Errorf("%q names both %s and %s", name, other, lang.Name)
+			}
+			seen[name] = lang.Name
+		}
+		if lang.Signature && lang.CompletePrograms {
+			t.Errorf("%s has a signature harness but takes complete programs", lang.Name)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package languages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Report is what an executor serves on GET /info about the language it runs.
+type Report struct {
+	Language        string   `json:"language"`
+	DisplayName     string   `json:"display_name"`
+	Version         string   `json:"version"` // First line of e.g. "g++ --version"
+	CompileFlags    []string `json:"compile_flags"`
+	Template        string   `json:"template"`
+	TimeMultiplier  float64  `json:"time_multiplier"`
+	ExecutorVersion string   `json:"executor_version"`
+}
+
+// RefreshInterval is how often StartRefresher asks the executors for their
+// reports.
+var RefreshInterval = 5 * time.Minute
+
+var (
+	reportsMu sync.RWMutex
+	// reports holds the last report of each executor that answered, by
+	// canonical name, with the time it was received
+	reports = make(map[string]receivedReport)
+)
+
+type receivedReport struct {
+	Report
+	at time.Time
+}
+
+var infoClient = &http.Client{Timeout: 10 * time.Second}
+
+// ExecutorURLFor returns the address of the executor a language's report is
+// fetched from. Package executor replaces it to pick an instance of the
+// language's pool.
+var ExecutorURLFor = func(lang Language) string {
+	return lang.ExecutorURL
+}
+
+// Refresh asks every executor for its report on GET /info. Languages whose
+// executor does not answer are marked unavailable, keeping the registry's
+// defaults for everything else.
+func Refresh(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, lang := range registry {
+		wg.Add(1)
+		go func(lang Language) {
+			defer wg.Done()
+			report, err := fetchReport(ctx, ExecutorURLFor(lang))
+			reportsMu.Lock()
+			defer reportsMu.Unlock()
+			if err != nil {
+				if _, ok := reports[lang.Name]; ok {
+					log.Printf("%s executor stopped reporting: %v", lang.DisplayName, err)
+				}
+				delete(reports, lang.Name)
+				return
+			}
+			reports[lang.Name] = receivedReport{Report: report, at: time.Now()}
+		}(lang)
+	}
+	wg.Wait()
+}
+
+// StartRefresher refreshes the reports in the background, now and then every
+// RefreshInterval until ctx is cancelled.
+func StartRefresher(ctx context.Context) {
+	go func() {
+		Refresh(ctx)
+		ticker := time.NewTicker(RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				Refresh(ctx)
+			}
+		}
+	}()
+}
+
+func fetchReport(ctx context.Context, executorURL string) (Report, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, executorURL+"/info", nil)
+	if err != nil {
+		return Report{}, err
+	}
+	resp, err := infoClient.Do(req)
+	if err != nil {
+		return Report{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Report{}, fmt.Errorf("executor returned status %s", resp.Status)
+	}
+
+	var report Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return Report{}, fmt.Errorf("failed to decode executor info: %w", err)
+	}
+	return report, nil
+}
+
+// withReport returns lang with what its executor last reported filled in.
+func withReport(lang Language) Language {
+	reportsMu.RLock()
+	received, ok := reports[lang.Name]
+	reportsMu.RUnlock()
+	if !ok {
+		return lang
+	}
+
+	lang.Available = true
+	at := received.at
+	lang.ReportedAt = &at
+	if received.DisplayName != "" {
+		lang.DisplayName = received.DisplayName
+	}
+	if received.Version != "" {
+		lang.Version = received.Version
+	}
+	if received.TimeMultiplier > 0 {
+		lang.TimeMultiplier = received.TimeMultiplier
+	}
+	lang.CompileFlags = received.CompileFlags
+	lang.Template = received.Template
+	lang.ExecutorVersion = received.ExecutorVersion
+	return lang
+}
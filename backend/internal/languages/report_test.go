@@ -0,0 +1,58 @@
+package languages
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRefresh(t *testing.T) {
+	executor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Report{
+			Language:       "go",
+			DisplayName:    "Go",
+			Version:        "go version go1.22.5 linux/amd64",
+			Template:       "package main\n",
+			TimeMultiplier: 1.5,
+		})
+	}))
+	defer executor.Close()
+
+	saved := make([]Language, len(registry))
+	copy(saved, registry)
+	defer func() {
+		copy(registry, saved)
+		reports = make(map[string]receivedReport)
+	}()
+	for i := range registry {
+		registry[i].ExecutorURL = executor.URL + "/missing"
+		if registry[i].Name == "go" {
+			registry[i].ExecutorURL = executor.URL
+		}
+	}
+
+	defaults, _ := Lookup("cpp")
+	Refresh(context.Background())
+
+	golang, _ := Lookup("golang")
+	if !golang.Available || golang.ReportedAt == nil {
+		t.Errorf("go is not available after reporting: %+v", golang)
+	}
+	if golang.Version != "go version go1.22.5 linux/amd64" || golang.Template != "package main\n" || golang.TimeMultiplier != 1.5 {
+		t.Errorf("go does not have its report filled in: %+v", golang)
+	}
+
+	cpp, _ := Lookup("cpp")
+	if cpp.Available {
+		t.Errorf("cpp is available without reporting")
+	}
+	if cpp.Version != defaults.Version || cpp.TimeMultiplier != defaults.TimeMultiplier {
+		t.Errorf("cpp lost its registry defaults: %+v", cpp)
+	}
+}
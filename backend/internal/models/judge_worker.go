@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JudgeWorker is the heartbeat record a judge process keeps in the
+// judge_workers collection so admins can see which judges are alive.
+type JudgeWorker struct {
+	ID                  primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WorkerID            string             `json:"worker_id" bson:"worker_id"` // hostname-pid of the judge process
+	Hostname            string             `json:"hostname" bson:"hostname"`
+	PID                 int                `json:"pid" bson:"pid"`
+	Concurrency         int                `json:"concurrency" bson:"concurrency"`                     // Number of worker goroutines
+	ActiveJobs          int64              `json:"active_jobs" bson:"active_jobs"`                     // Submissions being judged right now
+	JudgedTotal         int64              `json:"judged_total" bson:"judged_total"`                   // Submissions judged since the process started
+	FailedTotal         int64              `json:"failed_total" bson:"failed_total"`                   // Attempts that ended in an infrastructure error
+	ThroughputPerMinute int                `json:"throughput_per_minute" bson:"throughput_per_minute"` // Submissions finished in the last minute
+	StartedAt           time.Time          `json:"started_at" bson:"started_at"`
+	LastHeartbeatAt     time.Time          `json:"last_heartbeat_at" bson:"last_heartbeat_at"`
+	StoppedAt           *time.Time         `json:"stopped_at,omitempty" bson:"stopped_at,omitempty"` // Set on clean shutdown
+	Alive               bool               `json:"alive" bson:"-"`                                   // Computed from LastHeartbeatAt when listed
+	// ExecutorPools is the state of the process's executor pools at its last
+	// heartbeat
+	ExecutorPools []ExecutorPoolStatus `json:"executor_pools,omitempty" bson:"executor_pools,omitempty"`
+}
+
+// ExecutorPoolStatus is the state of the executor instances of one language
+// as seen by one process.
+type ExecutorPoolStatus struct {
+	Language   string                   `json:"language" bson:"language"`
+	Configured bool                     `json:"configured" bson:"configured"` // Set when the instances come from EXECUTOR_POOLS
+	Instances  []ExecutorInstanceStatus `json:"instances" bson:"instances"`
+}
+
+// ExecutorInstanceStatus is the state of one executor instance of a pool.
+type ExecutorInstanceStatus struct {
+	URL                 string     `json:"url" bson:"url"`
+	Healthy             bool       `json:"healthy" bson:"healthy"` // Passed its last health check
+	Circuit             string     `json:"circuit" bson:"circuit"` // "closed", "open" or "half_open"
+	Outstanding         int        `json:"outstanding" bson:"outstanding"`
+	Requests            int64      `json:"requests" bson:"requests"`
+	Failures            int64      `json:"failures" bson:"failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures" bson:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	LastCheckedAt       *time.Time `json:"last_checked_at,omitempty" bson:"last_checked_at,omitempty"`
+}
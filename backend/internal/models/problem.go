@@ -6,16 +6,44 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ScoringMode decides how a submission's score is computed from its test results.
+type ScoringMode string
+
+const (
+	ScoringAllOrNothing ScoringMode = "all_or_nothing" // Full points only if every test passes (the default)
+	ScoringSum          ScoringMode = "sum"            // Points of every passed test
+	ScoringGroups       ScoringMode = "groups"         // A group's points only if all of its tests pass
+)
+
+// CheckerMode decides how a test's output is compared with the expected output.
+type CheckerMode string
+
+const (
+	CheckerExact          CheckerMode = "exact"           // Identical apart from leading and trailing whitespace (the default)
+	CheckerTokens         CheckerMode = "tokens"          // Same whitespace-separated tokens
+	CheckerUnorderedLines CheckerMode = "unordered_lines" // Same lines in any order
+	CheckerFloat          CheckerMode = "float"           // Numeric tokens equal within an absolute or relative epsilon
+	CheckerCustom         CheckerMode = "custom"          // The problem's checker program decides
+)
+
 // Problem defines the structure for a programming problem stored in MongoDB.
 type Problem struct {
 	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	ProblemID       string             `json:"problem_id" bson:"problem_id"` // A custom, perhaps shorter/human-readable ID
 	Title           string             `json:"title" bson:"title"`
-	Difficulty      string             `json:"difficulty" bson:"difficulty"`                               // e.g., "Easy", "Medium", "Hard"
-	Statement       string             `json:"statement" bson:"statement"`                                 // Full problem description, examples, etc.
-	ConstraintsText string             `json:"constraints_text" bson:"constraints_text"`                   // Text block for input constraints (e.g., "1 <= nums.length <= 10^4", "-10^9 <= nums[i] <= 10^9")
-	TimeLimitMs     int                `json:"time_limit_ms" bson:"time_limit_ms"`                         // Time limit in milliseconds
-	MemoryLimitMB   int                `json:"memory_limit_mb" bson:"memory_limit_mb"`                     // Memory limit in Megabytes
+	Difficulty      string             `json:"difficulty" bson:"difficulty"`                                   // e.g., "Easy", "Medium", "Hard"
+	Statement       string             `json:"statement" bson:"statement"`                                     // Full problem description, examples, etc.
+	ConstraintsText string             `json:"constraints_text" bson:"constraints_text"`                       // Text block for input constraints (e.g., "1 <= nums.length <= 10^4", "-10^9 <= nums[i] <= 10^9")
+	TimeLimitMs     int                `json:"time_limit_ms" bson:"time_limit_ms"`                             // Time limit in milliseconds
+	MemoryLimitMB   int                `json:"memory_limit_mb" bson:"memory_limit_mb"`                         // Memory limit in Megabytes
+	ScoringMode     ScoringMode        `json:"scoring_mode,omitempty" bson:"scoring_mode,omitempty"`           // Empty means all_or_nothing
+	SkipFailedGroup bool               `json:"skip_failed_group,omitempty" bson:"skip_failed_group,omitempty"` // Stop running a subtask group after its first failed test
+	Checker         CheckerMode        `json:"checker,omitempty" bson:"checker,omitempty"`                     // Empty means exact
+	CheckerAbsEps   float64            `json:"checker_abs_epsilon,omitempty" bson:"checker_abs_epsilon,omitempty"`
+	CheckerRelEps   float64            `json:"checker_rel_epsilon,omitempty" bson:"checker_rel_epsilon,omitempty"`
+	Interactive     bool               `json:"interactive,omitempty" bson:"interactive,omitempty"`         // Solutions talk to the problem's interactor instead of printing an answer
+	QueryLimit      int                `json:"query_limit,omitempty" bson:"query_limit,omitempty"`         // Lines an interactive solution may send, 0 for no limit
+	Signature       *FunctionSignature `json:"signature,omitempty" bson:"signature,omitempty"`             // Typed function solutions implement; tests then use the canonical input format
 	Author          string             `json:"author,omitempty" bson:"author,omitempty"`                   // Optional: username or ID of the author
 	Tags            []string           `json:"tags,omitempty" bson:"tags,omitempty"`                       // Optional: e.g., ["Array", "Two Pointers", "Dynamic Programming"]
 	AcceptanceRate  float64            `json:"acceptance_rate,omitempty" bson:"acceptance_rate,omitempty"` // Percentage of accepted submissions
@@ -28,6 +56,24 @@ type Problem struct {
 	// Editorial string `json:"editorial,omitempty" bson:"editorial,omitempty"`
 }
 
+// FunctionSignature is the typed signature of the function a problem's
+// solutions implement, e.g. twoSum(nums int[], target int) int[]. Executors
+// generate the code that reads each parameter from a test input and prints
+// the result, so no parser code is needed. Types are int, long, double, bool,
+// string, ListNode and TreeNode, arrays of them such as int[][], and lists
+// such as List<String>.
+type FunctionSignature struct {
+	FunctionName string           `json:"function_name" bson:"function_name"`
+	Params       []SignatureParam `json:"params" bson:"params"`
+	ReturnType   string           `json:"return_type" bson:"return_type"`
+}
+
+// SignatureParam is one parameter of a FunctionSignature.
+type SignatureParam struct {
+	Name string `json:"name" bson:"name"`
+	Type string `json:"type" bson:"type"`
+}
+
 // ProblemListItem defines a simplified structure for listing problems.
 type ProblemListItem struct {
 	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rejudge records an admin request to judge already judged submissions again,
+// e.g. after a wrong expected output was fixed. It is kept in the rejudges
+// collection; once its last submission has a new verdict, the stats of every
+// affected user and problem are recomputed and FinishedAt is set.
+type Rejudge struct {
+	ID           primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	RequestedBy  primitive.ObjectID   `json:"requested_by" bson:"requested_by"`
+	SubmissionID *primitive.ObjectID  `json:"submission_id,omitempty" bson:"submission_id,omitempty"`
+	ProblemID    string               `json:"problem_id,omitempty" bson:"problem_id,omitempty"`
+	UserID       *primitive.ObjectID  `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	OnlyAccepted bool                 `json:"only_accepted,omitempty" bson:"only_accepted,omitempty"`
+	From         *time.Time           `json:"from,omitempty" bson:"from,omitempty"` // Submitted at or after
+	To           *time.Time           `json:"to,omitempty" bson:"to,omitempty"`     // Submitted before
+	Total        int                  `json:"total" bson:"total"`                   // Submissions requeued
+	Remaining    int                  `json:"remaining" bson:"-"`                   // Still waiting for a new verdict, computed when read
+	UserIDs      []primitive.ObjectID `json:"-" bson:"user_ids"`                    // Users whose stats need recomputing
+	ProblemIDs   []string             `json:"-" bson:"problem_ids"`                 // Problems whose stats need recomputing
+	CreatedAt    time.Time            `json:"created_at" bson:"created_at"`
+	FinishedAt   *time.Time           `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
@@ -17,6 +17,24 @@ const (
 	StatusMemoryLimitExceeded SubmissionStatus = "MEMORY_LIMIT_EXCEEDED"
 	StatusRuntimeError        SubmissionStatus = "RUNTIME_ERROR"
 	StatusCompilationError    SubmissionStatus = "COMPILATION_ERROR"
+	StatusQueryLimitExceeded  SubmissionStatus = "QUERY_LIMIT_EXCEEDED" // Interactive problems only
+	StatusSecurityViolation   SubmissionStatus = "SECURITY_VIOLATION"   // Killed by the executor's sandbox
+	StatusOutputLimitExceeded SubmissionStatus = "OUTPUT_LIMIT_EXCEEDED"
+	// StatusJudgeError is given to submissions that could not be judged, e.g.
+	// as the executor kept failing. It says nothing about the code, so it is
+	// left out of user and problem stats.
+	StatusJudgeError SubmissionStatus = "JUDGE_ERROR"
+)
+
+// JudgeState tracks where a submission is in the persistent judging queue.
+// It is independent of Status, which holds the verdict shown to users.
+type JudgeState string
+
+const (
+	JudgeStateQueued  JudgeState = "QUEUED"
+	JudgeStateJudging JudgeState = "JUDGING"
+	JudgeStateDone    JudgeState = "DONE"
+	JudgeStateDead    JudgeState = "DEAD" // Dead-lettered after exhausting its retry attempts
 )
 
 // Submission defines the structure for a user's code submission
@@ -31,8 +49,60 @@ type Submission struct {
 	SubmittedAt      time.Time          `json:"submitted_at" bson:"submitted_at"`
 	TestCasesPassed  int                `json:"test_cases_passed" bson:"test_cases_passed"`
 	TestCasesTotal   int                `json:"test_cases_total" bson:"test_cases_total"`
+	Score            int                `json:"score" bson:"score"`                       // Points earned under the problem's scoring mode
+	MaxScore         int                `json:"max_score" bson:"max_score"`               // Points available
+	Groups           []GroupResult      `json:"groups,omitempty" bson:"groups,omitempty"` // Per-subtask verdicts, empty if the problem has no groups
 	TimeComplexity   string             `json:"time_complexity,omitempty" bson:"time_complexity,omitempty"`
 	MemoryComplexity string             `json:"memory_complexity,omitempty" bson:"memory_complexity,omitempty"`
+	// Compiler messages when Status is COMPILATION_ERROR
+	CompileDiagnostics []CompileDiagnostic `json:"compile_diagnostics,omitempty" bson:"compile_diagnostics,omitempty"`
+
+	// Every verdict given, oldest first; the fields above hold the latest
+	VerdictHistory []VerdictRecord     `json:"verdict_history,omitempty" bson:"verdict_history,omitempty"`
+	RejudgeID      *primitive.ObjectID `json:"rejudge_id,omitempty" bson:"rejudge_id,omitempty"` // Most recent rejudge of this submission
+
+	// Judging queue bookkeeping
+	JudgeState     JudgeState `json:"judge_state,omitempty" bson:"judge_state,omitempty"`
+	Attempts       int        `json:"attempts,omitempty" bson:"attempts,omitempty"`                 // Number of times a worker has claimed this submission
+	AvailableAt    *time.Time `json:"-" bson:"available_at,omitempty"`                              // Earliest time a worker may claim it (used for retry backoff)
+	ClaimedBy      string     `json:"-" bson:"claimed_by,omitempty"`                                // ID of the worker currently holding the lease
+	LeaseExpiresAt *time.Time `json:"-" bson:"lease_expires_at,omitempty"`                          // Claim is released to other workers after this time
+	LastJudgeError string     `json:"last_judge_error,omitempty" bson:"last_judge_error,omitempty"` // Infrastructure error from the most recent failed attempt
+	EventSeq       int64      `json:"-" bson:"event_seq,omitempty"`                                 // Sequence number of the last judging event published, see SubmissionEvent
+}
+
+// VerdictRecord is one verdict a submission was given. Every judging that
+// ends in a verdict adds one to the submission's VerdictHistory, so a
+// disputed or rejudged verdict can be traced to the judge and executor that
+// produced it. Verdicts given before the history was kept have no worker,
+// executor or times.
+type VerdictRecord struct {
+	Status          SubmissionStatus    `json:"status" bson:"status"`
+	TestCasesPassed int                 `json:"test_cases_passed" bson:"test_cases_passed"`
+	TestCasesTotal  int                 `json:"test_cases_total" bson:"test_cases_total"`
+	Score           int                 `json:"score" bson:"score"`
+	MaxScore        int                 `json:"max_score" bson:"max_score"`
+	ExecutionTimeMs int                 `json:"execution_time_ms" bson:"execution_time_ms"`
+	MemoryUsedKB    int                 `json:"memory_used_kb" bson:"memory_used_kb"`
+	WorkerID        string              `json:"worker_id,omitempty" bson:"worker_id,omitempty"`               // Judge worker that gave the verdict
+	Attempt         int                 `json:"attempt,omitempty" bson:"attempt,omitempty"`                   // Which claim of the submission it came from
+	ExecutorVersion string              `json:"executor_version,omitempty" bson:"executor_version,omitempty"` // Executor builds that ran the tests, comma separated
+	StartedAt       *time.Time          `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	JudgedAt        *time.Time          `json:"judged_at,omitempty" bson:"judged_at,omitempty"`
+	RejudgeID       *primitive.ObjectID `json:"rejudge_id,omitempty" bson:"rejudge_id,omitempty"` // Set when a rejudge asked for it
+}
+
+// GroupResult is the verdict for one subtask group of a submission.
+type GroupResult struct {
+	Group            string           `json:"group" bson:"group"`
+	Name             string           `json:"name,omitempty" bson:"name,omitempty"`
+	Status           TestResultStatus `json:"status" bson:"status"` // PASSED, the first failed test's status, or SKIPPED
+	Score            int              `json:"score" bson:"score"`
+	MaxScore         int              `json:"max_score" bson:"max_score"`
+	TestsPassed      int              `json:"tests_passed" bson:"tests_passed"`
+	TestsSkipped     int              `json:"tests_skipped" bson:"tests_skipped"`
+	TestsTotal       int              `json:"tests_total" bson:"tests_total"`
+	DependencyFailed bool             `json:"dependency_failed,omitempty" bson:"dependency_failed,omitempty"` // A group it depends on did not pass
 }
 
 // Parse submission data
@@ -52,5 +122,7 @@ type SubmissionListItem struct {
 	Language        string             `json:"language" bson:"language"`
 	Status          SubmissionStatus   `json:"status" bson:"status"`
 	ExecutionTimeMs int                `json:"execution_time_ms" bson:"execution_time_ms"`
+	Score           int                `json:"score" bson:"score"`
+	MaxScore        int                `json:"max_score" bson:"max_score"`
 	SubmittedAt     time.Time          `json:"submitted_at" bson:"submitted_at"`
 }
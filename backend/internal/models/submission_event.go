@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SubmissionEventType names a step of judging reported to clients watching a
+// submission.
+type SubmissionEventType string
+
+const (
+	EventQueued     SubmissionEventType = "queued"      // Accepted and waiting for a judge
+	EventCompiling  SubmissionEventType = "compiling"   // A judge picked it up and is preparing the code
+	EventRunning    SubmissionEventType = "running"     // Test N of M started
+	EventTestResult SubmissionEventType = "test_result" // Test N of M was judged
+	EventVerdict    SubmissionEventType = "verdict"     // The final verdict was recorded
+)
+
+// SubmissionEvent is one step of judging a submission, kept in the
+// submission_events collection so clients can replay what they missed. Seq
+// numbers the events of a submission from 1 and is what the event stream
+// sends as the event ID.
+type SubmissionEvent struct {
+	ID           primitive.ObjectID     `json:"-" bson:"_id,omitempty"`
+	SubmissionID primitive.ObjectID     `json:"submission_id" bson:"submission_id"`
+	Seq          int64                  `json:"seq" bson:"seq"`
+	Type         SubmissionEventType    `json:"type" bson:"type"`
+	Data         map[string]interface{} `json:"data,omitempty" bson:"data,omitempty"`
+	CreatedAt    time.Time              `json:"created_at" bson:"created_at"`
+}
@@ -11,19 +11,42 @@ const (
 	TestResultStatusTimeLimitExceeded   TestResultStatus = "TIME_LIMIT_EXCEEDED"
 	TestResultStatusMemoryLimitExceeded TestResultStatus = "MEMORY_LIMIT_EXCEEDED"
 	TestResultStatusRuntimeError        TestResultStatus = "RUNTIME_ERROR"
+	TestResultStatusSkipped             TestResultStatus = "SKIPPED" // Not run because its group had already failed
+	TestResultStatusQueryLimitExceeded  TestResultStatus = "QUERY_LIMIT_EXCEEDED"
+	TestResultStatusCompilationError    TestResultStatus = "COMPILATION_ERROR"     // The code did not compile, so no test ran
+	TestResultStatusSecurityViolation   TestResultStatus = "SECURITY_VIOLATION"    // Killed for a forbidden system call, e.g. opening a socket
+	TestResultStatusOutputLimitExceeded TestResultStatus = "OUTPUT_LIMIT_EXCEEDED" // Killed for writing more than the executor's output limit
 )
 
+// CompileDiagnostic is one compiler message about a submission. File is the
+// name the user's code is reported under, e.g. "solution.cpp", with Line and
+// Column in the code as the user wrote it, or "<wrapper>" for messages about
+// the code generated around it.
+type CompileDiagnostic struct {
+	File     string `json:"file" bson:"file"`
+	Line     int    `json:"line" bson:"line"`
+	Column   int    `json:"column,omitempty" bson:"column,omitempty"`
+	Severity string `json:"severity" bson:"severity"` // "error", "warning" or "note"
+	Message  string `json:"message" bson:"message"`
+}
+
 // SubmissionResult stores the outcome of a single test case for a submission.
 type SubmissionResult struct {
-	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	SubmissionID    primitive.ObjectID `json:"submission_id" bson:"submission_id"`
-	TestCaseID      primitive.ObjectID `json:"test_case_id" bson:"test_case_id"`
-	SequenceNumber  int                `json:"sequence_number" bson:"sequence_number"`
-	Status          TestResultStatus   `json:"status" bson:"status"`
-	Input           string             `json:"input" bson:"input"`
-	ExpectedOutput  string             `json:"expected_output" bson:"expected_output"`
-	ActualOutput    string             `json:"actual_output" bson:"actual_output"`
-	ExecutionTimeMs int                `json:"execution_time_ms" bson:"execution_time_ms"`
-	MemoryUsedKB    int                `json:"memory_used_kb" bson:"memory_used_kb"`
-	Error           string             `json:"error,omitempty" bson:"error,omitempty"`
+	ID              primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	SubmissionID    primitive.ObjectID  `json:"submission_id" bson:"submission_id"`
+	TestCaseID      primitive.ObjectID  `json:"test_case_id" bson:"test_case_id"`
+	SequenceNumber  int                 `json:"sequence_number" bson:"sequence_number"`
+	Status          TestResultStatus    `json:"status" bson:"status"`
+	Input           string              `json:"input" bson:"input"`
+	ExpectedOutput  string              `json:"expected_output" bson:"expected_output"`
+	ActualOutput    string              `json:"actual_output" bson:"actual_output"`
+	ExecutionTimeMs int                 `json:"execution_time_ms" bson:"execution_time_ms"` // CPU time
+	WallTimeMs      int                 `json:"wall_time_ms" bson:"wall_time_ms"`
+	TimeLimitMs     int                 `json:"time_limit_ms" bson:"time_limit_ms"` // CPU time limit after the language multiplier
+	MemoryUsedKB    int                 `json:"memory_used_kb" bson:"memory_used_kb"`
+	MemoryLimitKB   int                 `json:"memory_limit_kb" bson:"memory_limit_kb"` // 0 if the test ran without a memory limit
+	Error           string              `json:"error,omitempty" bson:"error,omitempty"`
+	CheckerMessage  string              `json:"checker_message,omitempty" bson:"checker_message,omitempty"` // Explanation from a custom checker or interactor
+	Transcript      string              `json:"transcript,omitempty" bson:"transcript,omitempty"`           // Traffic between an interactive solution and the interactor
+	Diagnostics     []CompileDiagnostic `json:"diagnostics,omitempty" bson:"diagnostics,omitempty"`         // Set when the code did not compile
 }
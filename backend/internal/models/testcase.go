@@ -16,11 +16,27 @@ type TestCase struct {
 	Points         int                `json:"points" bson:"points"`                   // Points awarded for passing this test case (e.g., for partial scoring)
 	Notes          string             `json:"notes,omitempty" bson:"notes,omitempty"` // Optional notes: e.g., "Tests edge case: empty array", "Tests large inputs"
 	SequenceNumber int                `json:"sequence_number" bson:"sequence_number"` // To maintain an order if needed
+	Group          string             `json:"group,omitempty" bson:"group,omitempty"` // Subtask group ID, used by the "groups" scoring mode
 	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	// Subtask metadata, repeated on each test of the group; the first test
+	// that sets a field wins. GroupPoints replaces the sum of the tests'
+	// points, and a group earns nothing unless every group it depends on passes.
+	GroupName      string   `json:"group_name,omitempty" bson:"group_name,omitempty"`
+	GroupPoints    int      `json:"group_points,omitempty" bson:"group_points,omitempty"`
+	GroupDependsOn []string `json:"group_depends_on,omitempty" bson:"group_depends_on,omitempty"`
+	// Limits that replace the problem's for this test only, e.g. for a large
+	// stress test. Zero means the problem's limit applies. The time limit is
+	// still scaled by the language multiplier.
+	TimeLimitMsOverride   int `json:"time_limit_ms_override,omitempty" bson:"time_limit_ms_override,omitempty"`
+	MemoryLimitMBOverride int `json:"memory_limit_mb_override,omitempty" bson:"memory_limit_mb_override,omitempty"`
+	// Set by cmd/migrate_test_cases. FormatError says why a test could not be
+	// converted to the canonical format of the problem's signature; the legacy
+	// fields keep the free text of a test it did convert.
+	FormatError          string `json:"format_error,omitempty" bson:"format_error,omitempty"`
+	LegacyInput          string `json:"legacy_input,omitempty" bson:"legacy_input,omitempty"`
+	LegacyExpectedOutput string `json:"legacy_expected_output,omitempty" bson:"legacy_expected_output,omitempty"`
 	// Future considerations:
 	// IsHidden bool `json:"is_hidden" bson:"is_hidden"` // Could replace/complement IsSample if more granularity is needed
-	// TimeLimitMsOverride int `json:"time_limit_ms_override,omitempty" bson:"time_limit_ms_override,omitempty"` // If this TC has a specific time limit
-	// MemoryLimitMBOverride int `json:"memory_limit_mb_override,omitempty" bson:"memory_limit_mb_override,omitempty"` // If this TC has a specific memory limit
 }
 
 // AddTestCasePayload defines the structure for the request body when adding a new test case.
@@ -33,4 +49,12 @@ type AddTestCasePayload struct {
 	Points         int    `json:"points"`          // Add points here
 	SequenceNumber int    `json:"sequence_number"` // Add sequence number
 	Notes          string `json:"notes,omitempty"`
+	Group          string `json:"group,omitempty"`
+	// Optional subtask metadata, see TestCase
+	GroupName      string   `json:"group_name,omitempty"`
+	GroupPoints    int      `json:"group_points,omitempty"`
+	GroupDependsOn []string `json:"group_depends_on,omitempty"`
+	// Optional per-test limits, see TestCase
+	TimeLimitMsOverride   int `json:"time_limit_ms_override,omitempty"`
+	MemoryLimitMBOverride int `json:"memory_limit_mb_override,omitempty"`
 }
@@ -15,6 +15,7 @@ type UserStats struct {
 	EasySolved       int                `json:"easy_solved" bson:"easy_solved"`
 	MediumSolved     int                `json:"medium_solved" bson:"medium_solved"`
 	HardSolved       int                `json:"hard_solved" bson:"hard_solved"`
+	TotalScore       int                `json:"total_score" bson:"total_score"` // Sum of the best score on each problem, counting partial credit
 	TotalSubmissions int                `json:"total_submissions" bson:"total_submissions"`
 	AcceptanceRate   float64            `json:"acceptance_rate" bson:"acceptance_rate"`
 	Ranking          int                `json:"ranking" bson:"ranking"`
@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/executor"
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HeartbeatInterval is how often a judge process reports into the
+// judge_workers collection. A worker that has not reported for
+// AliveThreshold is shown as dead.
+var (
+	HeartbeatInterval = 10 * time.Second
+	AliveThreshold    = 3 * HeartbeatInterval
+)
+
+// RunHeartbeat reports the pool's status every HeartbeatInterval until ctx is
+// cancelled, then records a final heartbeat with the stop time.
+func (p *Pool) RunHeartbeat(ctx context.Context) {
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+
+	p.reportHeartbeat(false)
+	for {
+		select {
+		case <-ctx.Done():
+			p.reportHeartbeat(true)
+			return
+		case <-ticker.C:
+			p.reportHeartbeat(false)
+		}
+	}
+}
+
+func (p *Pool) reportHeartbeat(stopped bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hostname, _ := os.Hostname()
+	now := time.Now()
+	set := bson.M{
+		"worker_id":             p.WorkerID,
+		"hostname":              hostname,
+		"pid":                   os.Getpid(),
+		"concurrency":           p.Concurrency,
+		"active_jobs":           p.active.Load(),
+		"judged_total":          p.judged.Load(),
+		"failed_total":          p.failed.Load(),
+		"throughput_per_minute": p.throughputPerMinute(),
+		"started_at":            p.startedAt,
+		"last_heartbeat_at":     now,
+		"executor_pools":        executor.Status(),
+	}
+	update := bson.M{"$set": set, "$unset": bson.M{"stopped_at": ""}}
+	if stopped {
+		update = bson.M{"$set": set}
+		set["stopped_at"] = now
+	}
+
+	workersCollection := database.GetCollection("OJ", "judge_workers")
+	_, err := workersCollection.UpdateOne(ctx, bson.M{"worker_id": p.WorkerID}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Printf("Failed to report judge heartbeat for %s: %v", p.WorkerID, err)
+	}
+}
+
+// ListWorkers returns every judge process that has reported in, with Alive set
+// from the age of its last heartbeat.
+func ListWorkers(ctx context.Context) ([]models.JudgeWorker, error) {
+	workersCollection := database.GetCollection("OJ", "judge_workers")
+	cursor, err := workersCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "last_heartbeat_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	workers := []models.JudgeWorker{}
+	if err := cursor.All(ctx, &workers); err != nil {
+		return nil, err
+	}
+	for i := range workers {
+		workers[i].Alive = workers[i].StoppedAt == nil && time.Since(workers[i].LastHeartbeatAt) < AliveThreshold
+	}
+	return workers, nil
+}
@@ -0,0 +1,213 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProcessFunc judges a single submission. Verdicts (wrong answer, time limit,
+// ...) are not errors; a non-nil error means the attempt could not be
+// completed, e.g. the database or an executor was unreachable, and the
+// submission should be retried.
+type ProcessFunc func(submissionID primitive.ObjectID) error
+
+// PollInterval is how often an idle worker checks the queue for new work.
+var PollInterval = time.Second
+
+// WorkerCount is the default number of concurrent judge workers, configured
+// through JUDGE_WORKERS.
+var WorkerCount = 4
+
+func init() {
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_WORKERS")); err == nil && v > 0 {
+		WorkerCount = v
+	}
+}
+
+// DefaultWorkerID identifies this process in claimed_by fields.
+func DefaultWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// Pool runs a fixed number of workers that claim and judge submissions.
+type Pool struct {
+	WorkerID    string
+	Concurrency int
+	Process     ProcessFunc
+
+	wg        sync.WaitGroup
+	startedAt time.Time
+
+	// Counters reported in heartbeats
+	active atomic.Int64
+	judged atomic.Int64
+	failed atomic.Int64
+
+	finishedMu sync.Mutex
+	finished   []time.Time // Completion times within the last minute
+}
+
+// NewPool creates a pool with the given concurrency. A concurrency below one
+// falls back to WorkerCount.
+func NewPool(workerID string, concurrency int, process ProcessFunc) *Pool {
+	if concurrency < 1 {
+		concurrency = WorkerCount
+	}
+	return &Pool{
+		WorkerID:    workerID,
+		Concurrency: concurrency,
+		Process:     process,
+	}
+}
+
+// Start launches the workers. They stop once ctx is cancelled; use Wait to
+// block until in-flight submissions have finished.
+func (p *Pool) Start(ctx context.Context) {
+	p.startedAt = time.Now()
+	for i := 0; i < p.Concurrency; i++ {
+		p.wg.Add(1)
+		go p.work(ctx, fmt.Sprintf("%s/%d", p.WorkerID, i))
+	}
+	log.Printf("Judge pool %s started with %d workers", p.WorkerID, p.Concurrency)
+}
+
+// Wait blocks until all workers have exited.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Pool) work(ctx context.Context, workerID string) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before going back to sleep
+		for ctx.Err() == nil {
+			claimed, err := p.runOnce(ctx, workerID)
+			if err != nil {
+				log.Printf("Judge worker %s: %v", workerID, err)
+				break
+			}
+			if !claimed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce claims and judges at most one submission. It reports whether a
+// submission was claimed.
+func (p *Pool) runOnce(ctx context.Context, workerID string) (bool, error) {
+	claimCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	submission, err := Claim(claimCtx, workerID)
+	cancel()
+	if err != nil || submission == nil {
+		return false, err
+	}
+
+	// A lease that expired on its final attempt is dead-lettered without
+	// running it again.
+	if submission.Attempts > MaxAttempts {
+		err := Fail(context.Background(), submission, workerID, fmt.Errorf("lease expired on final attempt (worker crashed or timed out)"))
+		return true, err
+	}
+
+	log.Printf("Judge worker %s claimed submission %s (attempt %d)", workerID, submission.ID.Hex(), submission.Attempts)
+
+	p.active.Add(1)
+	stopLease := p.keepLeaseAlive(submission.ID, workerID)
+	processErr := p.safeProcess(submission.ID)
+	stopLease()
+	p.active.Add(-1)
+	p.recordFinished(processErr)
+
+	finishCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if processErr != nil {
+		return true, Fail(finishCtx, submission, workerID, processErr)
+	}
+	return true, Complete(finishCtx, submission.ID, workerID)
+}
+
+// recordFinished updates the throughput counters after a judging attempt.
+func (p *Pool) recordFinished(processErr error) {
+	if processErr != nil {
+		p.failed.Add(1)
+		return
+	}
+	p.judged.Add(1)
+
+	p.finishedMu.Lock()
+	p.finished = append(p.finished, time.Now())
+	p.finishedMu.Unlock()
+}
+
+// throughputPerMinute returns how many submissions finished in the last
+// minute, dropping older completion times as it goes.
+func (p *Pool) throughputPerMinute() int {
+	cutoff := time.Now().Add(-time.Minute)
+
+	p.finishedMu.Lock()
+	defer p.finishedMu.Unlock()
+	i := 0
+	for i < len(p.finished) && p.finished[i].Before(cutoff) {
+		i++
+	}
+	p.finished = p.finished[i:]
+	return len(p.finished)
+}
+
+// keepLeaseAlive periodically extends the lease on a submission until the
+// returned stop function is called.
+func (p *Pool) keepLeaseAlive(submissionID primitive.ObjectID, workerID string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(LeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				if err := ExtendLease(ctx, submissionID, workerID); err != nil {
+					log.Printf("Failed to extend lease on submission %s: %v", submissionID.Hex(), err)
+				}
+				cancel()
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// safeProcess runs the ProcessFunc, turning a panic into a failed attempt so
+// one bad submission cannot take a worker down.
+func (p *Pool) safeProcess(submissionID primitive.ObjectID) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while judging: %v", r)
+		}
+	}()
+	return p.Process(submissionID)
+}
@@ -0,0 +1,231 @@
+// Package queue implements a durable judging queue on top of the MongoDB
+// submissions collection. Workers claim a submission by taking a lease on it;
+// if a worker dies mid-judging the lease expires and another worker picks the
+// submission up again. Submissions that keep failing are dead-lettered.
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/events"
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// LeaseDuration is how long a claimed submission stays invisible to other
+	// workers before it is considered abandoned. Workers extend the lease while
+	// they are still judging.
+	LeaseDuration = 2 * time.Minute
+
+	// MaxAttempts is the number of claims after which a failing submission is
+	// moved to the dead-letter state instead of being retried.
+	MaxAttempts = 3
+
+	// RetryBackoff is multiplied by the attempt count to delay retries.
+	RetryBackoff = 5 * time.Second
+)
+
+// wake lets Enqueue nudge idle workers running in the same process so they
+// don't have to wait for the next poll.
+var wake = make(chan struct{}, 1)
+
+func init() {
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_LEASE_SECONDS")); err == nil && v > 0 {
+		LeaseDuration = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("JUDGE_MAX_ATTEMPTS")); err == nil && v > 0 {
+		MaxAttempts = v
+	}
+}
+
+func submissionsCollection() *mongo.Collection {
+	return database.GetCollection("OJ", "submissions")
+}
+
+// EnsureIndexes creates the indexes used by Claim and RequeueStale.
+func EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := submissionsCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "judge_state", Value: 1}, {Key: "available_at", Value: 1}}},
+		{Keys: bson.D{{Key: "judge_state", Value: 1}, {Key: "lease_expires_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create judge queue indexes: %w", err)
+	}
+	return nil
+}
+
+// Enqueue marks a submission as ready to be judged.
+func Enqueue(ctx context.Context, submissionID primitive.ObjectID) error {
+	_, err := submissionsCollection().UpdateOne(ctx,
+		bson.M{"_id": submissionID},
+		bson.M{
+			"$set": bson.M{
+				"judge_state":  models.JudgeStateQueued,
+				"available_at": time.Now(),
+			},
+			"$unset": bson.M{"claimed_by": "", "lease_expires_at": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue submission %s: %w", submissionID.Hex(), err)
+	}
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Claim leases the oldest available submission to workerID. It returns nil
+// when there is nothing to judge. Submissions whose lease has expired are
+// claimable again, which is how work held by a crashed worker is recovered.
+func Claim(ctx context.Context, workerID string) (*models.Submission, error) {
+	now := time.Now()
+	filter := bson.M{
+		"$or": []bson.M{
+			{"judge_state": models.JudgeStateQueued, "available_at": bson.M{"$lte": now}},
+			{"judge_state": models.JudgeStateJudging, "lease_expires_at": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"judge_state":      models.JudgeStateJudging,
+			"claimed_by":       workerID,
+			"lease_expires_at": now.Add(LeaseDuration),
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "available_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var submission models.Submission
+	err := submissionsCollection().FindOneAndUpdate(ctx, filter, update, opts).Decode(&submission)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim submission: %w", err)
+	}
+	return &submission, nil
+}
+
+// ExtendLease pushes the lease of a submission still being judged by workerID
+// further into the future.
+func ExtendLease(ctx context.Context, submissionID primitive.ObjectID, workerID string) error {
+	_, err := submissionsCollection().UpdateOne(ctx,
+		bson.M{"_id": submissionID, "claimed_by": workerID, "judge_state": models.JudgeStateJudging},
+		bson.M{"$set": bson.M{"lease_expires_at": time.Now().Add(LeaseDuration)}},
+	)
+	return err
+}
+
+// Complete releases the lease and marks the submission as judged.
+func Complete(ctx context.Context, submissionID primitive.ObjectID, workerID string) error {
+	_, err := submissionsCollection().UpdateOne(ctx,
+		bson.M{"_id": submissionID, "claimed_by": workerID},
+		bson.M{
+			"$set":   bson.M{"judge_state": models.JudgeStateDone},
+			"$unset": bson.M{"claimed_by": "", "lease_expires_at": "", "last_judge_error": ""},
+		},
+	)
+	return err
+}
+
+// Fail records a failed judging attempt. The submission is put back on the
+// queue with a backoff, or dead-lettered once it has used up MaxAttempts.
+// Dead-lettered submissions get a JUDGE_ERROR verdict, kept in their verdict
+// history like any other, so users are not left looking at a submission that
+// stays PENDING forever.
+func Fail(ctx context.Context, submission *models.Submission, workerID string, cause error) error {
+	set := bson.M{"last_judge_error": cause.Error()}
+	if submission.Attempts >= MaxAttempts {
+		set["judge_state"] = models.JudgeStateDead
+		set["status"] = models.StatusJudgeError
+		log.Printf("Submission %s dead-lettered after %d attempts: %v", submission.ID.Hex(), submission.Attempts, cause)
+	} else {
+		set["judge_state"] = models.JudgeStateQueued
+		set["available_at"] = time.Now().Add(time.Duration(submission.Attempts) * RetryBackoff)
+		log.Printf("Submission %s failed attempt %d/%d, retrying: %v", submission.ID.Hex(), submission.Attempts, MaxAttempts, cause)
+	}
+
+	update := bson.M{"$set": set, "$unset": bson.M{"claimed_by": "", "lease_expires_at": ""}}
+	if set["judge_state"] == models.JudgeStateDead {
+		now := time.Now()
+		update["$push"] = bson.M{"verdict_history": models.VerdictRecord{
+			Status:    models.StatusJudgeError,
+			WorkerID:  workerID,
+			Attempt:   submission.Attempts,
+			JudgedAt:  &now,
+			RejudgeID: submission.RejudgeID,
+		}}
+	}
+
+	result, err := submissionsCollection().UpdateOne(ctx,
+		bson.M{"_id": submission.ID, "claimed_by": workerID},
+		update,
+	)
+	if err != nil || result.ModifiedCount == 0 {
+		return err
+	}
+
+	// Let clients watching the submission know it is waiting again, or done
+	var eventErr error
+	if set["judge_state"] == models.JudgeStateDead {
+		eventErr = events.Publish(ctx, submission.ID, models.EventVerdict, map[string]interface{}{
+			"status": models.StatusJudgeError,
+		})
+	} else {
+		eventErr = events.Publish(ctx, submission.ID, models.EventQueued, map[string]interface{}{
+			"retry": submission.Attempts,
+		})
+	}
+	if eventErr != nil {
+		log.Printf("Failed to publish event: %v", eventErr)
+	}
+	return nil
+}
+
+// RequeueStale is run once at startup. It puts back on the queue every PENDING
+// submission that is not currently leased: submissions accepted before the
+// persistent queue existed, ones whose enqueue step never ran, and ones
+// abandoned by a worker that went away. It returns the number requeued.
+func RequeueStale(ctx context.Context) (int64, error) {
+	now := time.Now()
+	filter := bson.M{
+		"status": models.StatusPending,
+		"$or": []bson.M{
+			// Give in-flight submit requests a moment to finish saving code before enqueueing.
+			{"judge_state": bson.M{"$exists": false}, "submitted_at": bson.M{"$lt": now.Add(-time.Minute)}},
+			{"judge_state": models.JudgeStateJudging, "lease_expires_at": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"judge_state":  models.JudgeStateQueued,
+			"available_at": now,
+		},
+		"$unset": bson.M{"claimed_by": "", "lease_expires_at": ""},
+	}
+
+	result, err := submissionsCollection().UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale submissions: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
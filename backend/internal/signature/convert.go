@@ -0,0 +1,495 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"backend/internal/models"
+)
+
+// CanonicalInput checks that input is a canonical test input for sig, one
+// JSON value per parameter and one per line, and returns it re-encoded
+// compactly.
+func CanonicalInput(sig models.FunctionSignature, input string) (string, error) {
+	params, _, err := Types(sig)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(input, "\r\n", "\n"), "\n"), "\n")
+	if len(params) == 0 && len(lines) == 1 && strings.TrimSpace(lines[0]) == "" {
+		return "", nil
+	}
+	if len(lines) != len(params) {
+		return "", fmt.Errorf("got %d lines for %d parameters", len(lines), len(params))
+	}
+
+	encoded := make([]string, len(params))
+	for i, t := range params {
+		var value interface{}
+		decoder := json.NewDecoder(strings.NewReader(lines[i]))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return "", fmt.Errorf("%s: %v", sig.Params[i].Name, err)
+		}
+		if decoder.More() {
+			return "", fmt.Errorf("%s: more than one value on line %d", sig.Params[i].Name, i+1)
+		}
+		if encoded[i], err = encode(t, value, false); err != nil {
+			return "", fmt.Errorf("%s: %w", sig.Params[i].Name, err)
+		}
+	}
+	return strings.Join(encoded, "\n"), nil
+}
+
+// CanonicalOutput checks that output is the canonical JSON of a value of
+// sig's return type and returns it re-encoded the way harnesses print it.
+func CanonicalOutput(sig models.FunctionSignature, output string) (string, error) {
+	_, ret, err := Types(sig)
+	if err != nil {
+		return "", err
+	}
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	if decoder.More() {
+		return "", fmt.Errorf("more than one value")
+	}
+	return encode(ret, value, true)
+}
+
+// ConvertInput converts a test input to the canonical format of sig. Besides
+// canonical inputs it accepts the free text tests were written in before,
+// such as "nums = [2,7,11,15], target = 9" or one value per line, with
+// Python literals like True, None and 'single-quoted' strings. Values given
+// by name may come in any order.
+func ConvertInput(sig models.FunctionSignature, input string) (string, error) {
+	if canonical, err := CanonicalInput(sig, input); err == nil {
+		return canonical, nil
+	}
+	params, _, err := Types(sig)
+	if err != nil {
+		return "", err
+	}
+
+	p := &literalParser{text: input}
+	var named map[string]interface{}
+	var positional []interface{}
+	for {
+		p.skipSeparators()
+		if p.done() {
+			break
+		}
+		name := p.name()
+		value, err := p.value()
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			positional = append(positional, value)
+			continue
+		}
+		if named == nil {
+			named = make(map[string]interface{})
+		}
+		if _, ok := named[name]; ok {
+			return "", fmt.Errorf("%s is given twice", name)
+		}
+		named[name] = value
+	}
+	if named != nil && positional != nil {
+		return "", fmt.Errorf("mixes named and unnamed values")
+	}
+
+	values := positional
+	if named != nil {
+		values = make([]interface{}, len(params))
+		for i, param := range sig.Params {
+			value, ok := named[param.Name]
+			if !ok {
+				return "", fmt.Errorf("missing value for %s", param.Name)
+			}
+			values[i] = value
+			delete(named, param.Name)
+		}
+		for name := range named {
+			return "", fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+	if len(values) != len(params) {
+		return "", fmt.Errorf("got %d values for %d parameters", len(values), len(params))
+	}
+
+	encoded := make([]string, len(params))
+	for i, t := range params {
+		if encoded[i], err = encode(t, values[i], false); err != nil {
+			return "", fmt.Errorf("%s: %w", sig.Params[i].Name, err)
+		}
+	}
+	return strings.Join(encoded, "\n"), nil
+}
+
+// ConvertOutput converts an expected output to the canonical JSON of sig's
+// return type, accepting the same literals as ConvertInput. A string that is
+// not quoted is taken as it is.
+func ConvertOutput(sig models.FunctionSignature, output string) (string, error) {
+	_, ret, err := Types(sig)
+	if err != nil {
+		return "", err
+	}
+	p := &literalParser{text: output}
+	p.skipSpace()
+	value, err := p.value()
+	if err == nil {
+		p.skipSpace()
+		if !p.done() {
+			err = fmt.Errorf("unexpected %q after the value", p.rest())
+		}
+	}
+	if err != nil {
+		if ret.Kind == KindString {
+			return encode(ret, strings.TrimSpace(output), true)
+		}
+		return "", err
+	}
+	return encode(ret, value, true)
+}
+
+// ConvertTestCase converts the input and expected output of a test with
+// ConvertInput and ConvertOutput. Problems with a custom checker may leave the
+// expected output empty, which stays empty.
+func ConvertTestCase(sig models.FunctionSignature, input, expectedOutput string) (string, string, error) {
+	input, err := ConvertInput(sig, input)
+	if err != nil {
+		return "", "", fmt.Errorf("input: %w", err)
+	}
+	if expectedOutput == "" {
+		return input, "", nil
+	}
+	expectedOutput, err = ConvertOutput(sig, expectedOutput)
+	if err != nil {
+		return "", "", fmt.Errorf("expected output: %w", err)
+	}
+	return input, expectedOutput, nil
+}
+
+// encode checks that value, as decoded from JSON with numbers kept as
+// json.Number, has type t and returns its canonical JSON. Doubles are
+// printed with five decimals in outputs, and in their shortest form in inputs.
+func encode(t *Type, value interface{}, output bool) (string, error) {
+	switch t.Kind {
+	case KindInt, KindLong:
+		n, ok := value.(json.Number)
+		if !ok {
+			return "", fmt.Errorf("expected an integer, got %s", describe(value))
+		}
+		bits := 64
+		if t.Kind == KindInt {
+			bits = 32
+		}
+		i, err := strconv.ParseInt(n.String(), 10, bits)
+		if err != nil {
+			return "", fmt.Errorf("%s is not a valid %s", n, t.Kind)
+		}
+		return strconv.FormatInt(i, 10), nil
+	case KindDouble:
+		n, ok := value.(json.Number)
+		if !ok {
+			return "", fmt.Errorf("expected a number, got %s", describe(value))
+		}
+		f, err := n.Float64()
+		if err != nil || math.IsInf(f, 0) {
+			return "", fmt.Errorf("%s is not a valid double", n)
+		}
+		if output {
+			return fmt.Sprintf("%.5f", f), nil
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	case KindBool:
+		b, ok := value.(bool)
+		if !ok {
+			return "", fmt.Errorf("expected true or false, got %s", describe(value))
+		}
+		return strconv.FormatBool(b), nil
+	case KindString:
+		s, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("expected a string, got %s", describe(value))
+		}
+		return quote(s), nil
+	case KindArray, KindList, KindListNode:
+		items, ok := value.([]interface{})
+		if !ok {
+			return "", fmt.Errorf("expected an array, got %s", describe(value))
+		}
+		elem := t.Elem
+		if t.Kind == KindListNode {
+			elem = &Type{Kind: KindInt}
+		}
+		encoded := make([]string, len(items))
+		for i, item := range items {
+			var err error
+			if encoded[i], err = encode(elem, item, output); err != nil {
+				return "", fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return "[" + strings.Join(encoded, ",") + "]", nil
+	case KindTreeNode:
+		items, ok := value.([]interface{})
+		if !ok {
+			return "", fmt.Errorf("expected a level-order array, got %s", describe(value))
+		}
+		// Trailing nulls say nothing, and a null root is the empty tree
+		for len(items) > 0 && items[len(items)-1] == nil {
+			items = items[:len(items)-1]
+		}
+		if len(items) > 0 && items[0] == nil {
+			return "", fmt.Errorf("the root of a non-empty tree cannot be null")
+		}
+		encoded := make([]string, len(items))
+		for i, item := range items {
+			if item == nil {
+				encoded[i] = "null"
+				continue
+			}
+			var err error
+			if encoded[i], err = encode(&Type{Kind: KindInt}, item, output); err != nil {
+				return "", fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return "[" + strings.Join(encoded, ",") + "]", nil
+	case KindGraphNode:
+		lists, ok := value.([]interface{})
+		if !ok {
+			return "", fmt.Errorf("expected an adjacency list, got %s", describe(value))
+		}
+		encoded := make([]string, len(lists))
+		for i, list := range lists {
+			neighbors, ok := list.([]interface{})
+			if !ok {
+				return "", fmt.Errorf("[%d]: expected an array of neighbors, got %s", i, describe(list))
+			}
+			values := make([]string, len(neighbors))
+			for j, neighbor := range neighbors {
+				n, ok := neighbor.(json.Number)
+				v, err := strconv.Atoi(string(n))
+				if !ok || err != nil || v < 1 || v > len(lists) {
+					return "", fmt.Errorf("[%d][%d]: %s is not a node of the graph", i, j, describe(neighbor))
+				}
+				values[j] = strconv.Itoa(v)
+			}
+			encoded[i] = "[" + strings.Join(values, ",") + "]"
+		}
+		return "[" + strings.Join(encoded, ",") + "]", nil
+	}
+	return "", fmt.Errorf("unknown type %s", t)
+}
+
+// quote returns s as a JSON string without escaping HTML characters, as the
+// harnesses print it.
+func quote(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// describe names the JSON type of value for error messages.
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return "a string"
+	case []interface{}:
+		return "an array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// literalParser reads the values of free-text test inputs: JSON values, plus
+// Python's True, False, None and single-quoted strings. Numbers are kept as
+// json.Number so they convert like decoded JSON.
+type literalParser struct {
+	text string
+	pos  int
+}
+
+func (p *literalParser) done() bool   { return p.pos >= len(p.text) }
+func (p *literalParser) rest() string { return p.text[p.pos:] }
+
+func (p *literalParser) skipSpace() {
+	for !p.done() && strings.IndexByte(" \t\r\n", p.text[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// skipSeparators skips the whitespace and commas between values.
+func (p *literalParser) skipSeparators() {
+	for !p.done() && strings.IndexByte(" \t\r\n,;", p.text[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// name consumes "name =" or "name:" and returns the name, or returns "" and
+// consumes nothing when the next value is not named.
+func (p *literalParser) name() string {
+	end := p.pos
+	for end < len(p.text) && (p.text[end] == '_' || isAlphaNum(p.text[end])) {
+		end++
+	}
+	name := p.text[p.pos:end]
+	if !isIdentifier(name) {
+		return ""
+	}
+	next := end
+	for next < len(p.text) && (p.text[next] == ' ' || p.text[next] == '\t') {
+		next++
+	}
+	if next == len(p.text) || (p.text[next] != '=' && p.text[next] != ':') {
+		return ""
+	}
+	p.pos = next + 1
+	p.skipSpace()
+	return name
+}
+
+func (p *literalParser) value() (interface{}, error) {
+	if p.done() {
+		return nil, fmt.Errorf("expected a value at the end of the input")
+	}
+	switch c := p.text[p.pos]; {
+	case c == '[' || c == '(':
+		return p.array()
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+
+	end := p.pos
+	for end < len(p.text) && isAlphaNum(p.text[end]) {
+		end++
+	}
+	word := p.text[p.pos:end]
+	var value interface{}
+	switch word {
+	case "true", "True":
+		value = true
+	case "false", "False":
+		value = false
+	case "null", "None":
+		value = nil
+	default:
+		if word == "" {
+			word = p.text[p.pos : p.pos+1]
+		}
+		return nil, fmt.Errorf("unexpected %q at offset %d", word, p.pos)
+	}
+	p.pos = end
+	return value, nil
+}
+
+// array reads a JSON array or a Python tuple, allowing a trailing comma.
+func (p *literalParser) array() (interface{}, error) {
+	closing := byte(']')
+	if p.text[p.pos] == '(' {
+		closing = ')'
+	}
+	p.pos++
+	items := []interface{}{}
+	for {
+		p.skipSpace()
+		if p.done() {
+			return nil, fmt.Errorf("missing %q", closing)
+		}
+		if p.text[p.pos] == closing {
+			p.pos++
+			return items, nil
+		}
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.skipSpace()
+		if !p.done() && p.text[p.pos] == ',' {
+			p.pos++
+		} else if !p.done() && p.text[p.pos] != closing {
+			return nil, fmt.Errorf("expected ',' or %q at offset %d", closing, p.pos)
+		}
+	}
+}
+
+// str reads a double- or single-quoted string with JSON escapes.
+func (p *literalParser) str() (interface{}, error) {
+	quoteChar := p.text[p.pos]
+	start := p.pos
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.text); p.pos++ {
+		c := p.text[p.pos]
+		if c == quoteChar {
+			p.pos++
+			return b.String(), nil
+		}
+		if c != '\\' || p.pos+1 == len(p.text) {
+			b.WriteByte(c)
+			continue
+		}
+		p.pos++
+		switch e := p.text[p.pos]; e {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if p.pos+4 >= len(p.text) {
+				return nil, fmt.Errorf("invalid escape at offset %d", p.pos)
+			}
+			r, err := strconv.ParseUint(p.text[p.pos+1:p.pos+5], 16, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid escape at offset %d", p.pos)
+			}
+			b.WriteRune(rune(r))
+			p.pos += 4
+		default:
+			b.WriteByte(e) // \\, \", \', \/
+		}
+	}
+	return nil, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func (p *literalParser) number() (interface{}, error) {
+	end := p.pos
+	for end < len(p.text) && strings.IndexByte("+-.eE0123456789", p.text[end]) >= 0 {
+		end++
+	}
+	text := strings.TrimPrefix(p.text[p.pos:end], "+")
+	if _, err := strconv.ParseFloat(text, 64); err != nil {
+		return nil, fmt.Errorf("invalid number %q at offset %d", p.text[p.pos:end], p.pos)
+	}
+	p.pos = end
+	return json.Number(text), nil
+}
+
+func isAlphaNum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
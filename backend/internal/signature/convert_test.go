@@ -0,0 +1,112 @@
+package signature
+
+import (
+	"testing"
+
+	"backend/internal/models"
+)
+
+func newSignature(ret string, params ...string) models.FunctionSignature {
+	sig := models.FunctionSignature{FunctionName: "solve", ReturnType: ret}
+	for i := 0; i < len(params); i += 2 {
+		sig.Params = append(sig.Params, models.SignatureParam{Name: params[i], Type: params[i+1]})
+	}
+	return sig
+}
+
+func TestConvertInput(t *testing.T) {
+	twoSum := newSignature("int[]", "nums", "int[]", "target", "int")
+	tests := []struct {
+		sig   models.FunctionSignature
+		input string
+		want  string
+	}{
+		{twoSum, "nums = [2,7,11,15], target = 9", "[2,7,11,15]\n9"},
+		{twoSum, "target = 9\nnums = [2, 7, 11, 15]", "[2,7,11,15]\n9"},
+		{twoSum, "[2, 7, 11, 15]\n9\n", "[2,7,11,15]\n9"},
+		{twoSum, "[2,7,11,15] 9", "[2,7,11,15]\n9"},
+		{newSignature("bool", "words", "List<String>", "flag", "boolean"), "words = ['a', \"b\\\"c\"], flag = True", "[\"a\",\"b\\\"c\"]\ntrue"},
+		{newSignature("int", "root", "TreeNode"), "root = [1,None,2,3,None,None]", "[1,null,2,3]"},
+		{newSignature("int", "root", "TreeNode"), "[]", "[]"},
+		{newSignature("double", "x", "double", "pairs", "int[][]"), "x = .5, pairs = [(1, 2), (3, 4),]", "0.5\n[[1,2],[3,4]]"},
+		{newSignature("int"), "", ""},
+	}
+	for _, tt := range tests {
+		got, err := ConvertInput(tt.sig, tt.input)
+		if err != nil {
+			t.Errorf("ConvertInput(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ConvertInput(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{
+		"nums = [2,7,11,15]",               // Missing target
+		"nums = [2,7,11,15], k = 9",        // Unknown parameter
+		"nums = [2,7,11,15], 9",            // Mixes named and unnamed values
+		"nums = [2,7.5,11,15], target = 9", // Not an integer
+		"nums = [2,7,11,15], target = 3000000000",
+		"nums = [2,7,11, target = 9",
+		"nums = hello, target = 9",
+	} {
+		if got, err := ConvertInput(twoSum, input); err == nil {
+			t.Errorf("ConvertInput(%q) = %q, want an error", input, got)
+		}
+	}
+}
+
+func TestConvertOutput(t *testing.T) {
+	tests := []struct {
+		ret    string
+		output string
+		want   string
+	}{
+		{"int[]", "[0, 1]", "[0,1]"},
+		{"double", "2.5", "2.50000"},
+		{"boolean", "True", "true"},
+		{"String", "hello world", "\"hello world\""},
+		{"String", "'a<b'", "\"a<b\""},
+		{"ListNode", "[1, 2, 3]", "[1,2,3]"},
+		{"List<List<Integer>>", "[[1], []]", "[[1],[]]"},
+		{"GraphNode", "[[2, 4], [1, 3], [2, 4], [1, 3]]", "[[2,4],[1,3],[2,4],[1,3]]"},
+		{"GraphNode", "[]", "[]"},
+	}
+	for _, tt := range tests {
+		got, err := ConvertOutput(newSignature(tt.ret), tt.output)
+		if err != nil {
+			t.Errorf("ConvertOutput(%s, %q) failed: %v", tt.ret, tt.output, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ConvertOutput(%s, %q) = %q, want %q", tt.ret, tt.output, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ ret, output string }{
+		{"int", "three"},
+		{"int[]", "[1, 2] [3]"},
+		{"TreeNode", "[null, 1]"},
+		{"GraphNode", "[[2], [3]]"},
+		{"GraphNode", "[[0]]"},
+		{"GraphNode", "[1, 2]"},
+	} {
+		if got, err := ConvertOutput(newSignature(tt.ret), tt.output); err == nil {
+			t.Errorf("ConvertOutput(%s, %q) = %q, want an error", tt.ret, tt.output, got)
+		}
+	}
+}
+
+func TestCanonicalInput(t *testing.T) {
+	twoSum := newSignature("int[]", "nums", "int[]", "target", "int")
+	got, err := CanonicalInput(twoSum, "[2, 7, 11, 15]\r\n9\r\n")
+	if err != nil || got != "[2,7,11,15]\n9" {
+		t.Errorf("CanonicalInput = %q, %v, want the compact input", got, err)
+	}
+	for _, input := range []string{"nums = [2,7,11,15], target = 9", "[2,7,11,15]", "[2,7,11,15]\n9\n1", "[2,7,11,15] 9\n9"} {
+		if _, err := CanonicalInput(twoSum, input); err == nil {
+			t.Errorf("CanonicalInput(%q) succeeded, want an error", input)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package signature parses the types of a problem's function signature.
+// Executors generate a harness from the signature that reads each test input
+// as one JSON value per parameter, one per line, and prints the function's
+// result as one line of JSON. The executors parse types the same way, see
+// docker/runner/signature.go.
+package signature
+
+import (
+	"fmt"
+	"strings"
+
+	"backend/internal/models"
+)
+
+// Kind is the kind of a Type.
+type Kind string
+
+const (
+	KindInt       Kind = "int"
+	KindLong      Kind = "long"
+	KindDouble    Kind = "double"
+	KindBool      Kind = "bool"
+	KindString    Kind = "string"
+	KindArray     Kind = "array" // T[]
+	KindList      Kind = "list"  // List<T>, the same JSON as T[]
+	KindListNode  Kind = "ListNode"
+	KindTreeNode  Kind = "TreeNode"
+	KindGraphNode Kind = "GraphNode"
+)
+
+// Type is a parameter or return type of a signature. In JSON, numbers and
+// booleans are plain values, strings are JSON strings, arrays and lists are
+// JSON arrays, a ListNode is the array of its values and a TreeNode is its
+// level-order array with null for missing children, e.g. [1,null,2,3]. A
+// GraphNode is the adjacency list of its graph: node i+1 has value i+1 and
+// its neighbors' values at index i, e.g. [[2,3],[1],[1]], and stands for
+// node 1. Doubles are printed with five decimals.
+type Type struct {
+	Kind Kind  `json:"kind"`
+	Elem *Type `json:"elem,omitempty"` // Element type of arrays and lists
+}
+
+// scalarTypes maps the accepted names of non-container types to their kind.
+// Java's boxed names are accepted so List<Integer> reads naturally.
+var scalarTypes = map[string]Kind{
+	"int":       KindInt,
+	"Integer":   KindInt,
+	"long":      KindLong,
+	"Long":      KindLong,
+	"double":    KindDouble,
+	"Double":    KindDouble,
+	"bool":      KindBool,
+	"boolean":   KindBool,
+	"Boolean":   KindBool,
+	"string":    KindString,
+	"String":    KindString,
+	"ListNode":  KindListNode,
+	"TreeNode":  KindTreeNode,
+	"GraphNode": KindGraphNode,
+}
+
+// ParseType parses a type name such as "int", "int[][]", "List<String>" or
+// "TreeNode".
+func ParseType(name string) (*Type, error) {
+	name = strings.TrimSpace(name)
+	if strings.HasSuffix(name, "[]") {
+		elem, err := ParseType(strings.TrimSuffix(name, "[]"))
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Kind: KindArray, Elem: elem}, nil
+	}
+	if strings.HasPrefix(name, "List<") && strings.HasSuffix(name, ">") {
+		elem, err := ParseType(name[len("List<") : len(name)-1])
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Kind: KindList, Elem: elem}, nil
+	}
+	if kind, ok := scalarTypes[name]; ok {
+		return &Type{Kind: kind}, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", name)
+}
+
+// String returns the canonical name of t, e.g. "int[]" or "List<string>".
+func (t *Type) String() string {
+	switch t.Kind {
+	case KindArray:
+		return t.Elem.String() + "[]"
+	case KindList:
+		return "List<" + t.Elem.String() + ">"
+	default:
+		return string(t.Kind)
+	}
+}
+
+// Types parses the parameter and return types of sig, checking that it
+// describes a function that can be called.
+func Types(sig models.FunctionSignature) (params []*Type, ret *Type, err error) {
+	if !isIdentifier(sig.FunctionName) {
+		return nil, nil, fmt.Errorf("invalid function name %q", sig.FunctionName)
+	}
+	seen := make(map[string]bool)
+	for _, p := range sig.Params {
+		if !isIdentifier(p.Name) {
+			return nil, nil, fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		if seen[p.Name] {
+			return nil, nil, fmt.Errorf("duplicate parameter %q", p.Name)
+		}
+		seen[p.Name] = true
+		t, err := ParseType(p.Type)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		params = append(params, t)
+	}
+	ret, err = ParseType(sig.ReturnType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("return type: %w", err)
+	}
+	return params, ret, nil
+}
+
+// Validate checks that executors can generate a harness for sig.
+func Validate(sig models.FunctionSignature) error {
+	_, _, err := Types(sig)
+	return err
+}
+
+// isIdentifier reports whether s is a name every supported language accepts
+// for a function or variable.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package signature
+
+import (
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"int", "int"},
+		{"Integer", "int"},
+		{"boolean", "bool"},
+		{"String", "string"},
+		{"int[]", "int[]"},
+		{"long[][]", "long[][]"},
+		{"List<String>", "List<string>"},
+		{"List<List<Integer>>", "List<List<int>>"},
+		{"List<int[]>", "List<int[]>"},
+		{"TreeNode", "TreeNode"},
+		{"List<GraphNode>", "List<GraphNode>"},
+		{" ListNode[] ", "ListNode[]"},
+	}
+	for _, tt := range tests {
+		got, err := ParseType(tt.name)
+		if err != nil {
+			t.Errorf("ParseType(%q) failed: %v", tt.name, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseType(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	for _, name := range []string{"", "char", "List<>", "Map<String,Integer>", "int[", "[]"} {
+		if _, err := ParseType(name); err == nil {
+			t.Errorf("ParseType(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	twoSum := models.FunctionSignature{
+		FunctionName: "twoSum",
+		Params: []models.SignatureParam{
+			{Name: "nums", Type: "int[]"},
+			{Name: "target", Type: "int"},
+		},
+		ReturnType: "int[]",
+	}
+	if err := Validate(twoSum); err != nil {
+		t.Errorf("Validate(twoSum) failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*models.FunctionSignature)
+	}{
+		{"missing function name", func(s *models.FunctionSignature) { s.FunctionName = "" }},
+		{"function name with a space", func(s *models.FunctionSignature) { s.FunctionName = "two sum" }},
+		{"parameter name starting with a digit", func(s *models.FunctionSignature) { s.Params[0].Name = "1nums" }},
+		{"duplicate parameter", func(s *models.FunctionSignature) { s.Params[1].Name = "nums" }},
+		{"unknown parameter type", func(s *models.FunctionSignature) { s.Params[1].Type = "char" }},
+		{"missing return type", func(s *models.FunctionSignature) { s.ReturnType = "" }},
+	}
+	for _, tt := range tests {
+		sig := twoSum
+		sig.Params = append([]models.SignatureParam(nil), twoSum.Params...)
+		tt.modify(&sig)
+		if err := Validate(sig); err == nil {
+			t.Errorf("%s: Validate succeeded, want an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// gridFSBucketName is the GridFS bucket submission files are kept in, i.e. the
+// submission_files.files and submission_files.chunks collections.
+const gridFSBucketName = "submission_files"
+
+// GridFSStore keeps submission files in MongoDB GridFS, so every API server
+// and judge sees the same files. Each file is stored as "<submission id>/<name>"
+// with the submission ID and name in its metadata.
+type GridFSStore struct {
+	db *mongo.Database
+}
+
+// NewGridFSStore returns a store keeping files in db.
+func NewGridFSStore(db *mongo.Database) *GridFSStore {
+	return &GridFSStore{db: db}
+}
+
+// bucket opens the bucket for one operation. Deadlines are set on the bucket
+// rather than passed per call, so buckets are not shared between operations.
+func (s *GridFSStore) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(s.db, options.GridFSBucket().SetName(gridFSBucketName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GridFS bucket: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := bucket.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		if err := bucket.SetWriteDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+func gridFSFileName(submissionID primitive.ObjectID, name string) string {
+	return submissionID.Hex() + "/" + name
+}
+
+func (s *GridFSStore) Put(ctx context.Context, submissionID primitive.ObjectID, name string, data []byte) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	filename := gridFSFileName(submissionID, name)
+	uploadOpts := options.GridFSUpload().SetMetadata(bson.M{
+		"submission_id": submissionID,
+		"name":          name,
+	})
+	fileID, err := bucket.UploadFromStream(filename, bytes.NewReader(data), uploadOpts)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", filename, err)
+	}
+
+	// Older revisions are only removed once the new one is complete, so a
+	// reader always finds a whole file
+	cursor, err := bucket.FindContext(ctx, bson.M{"filename": filename, "_id": bson.M{"$ne": fileID}})
+	if err != nil {
+		return fmt.Errorf("failed to find old revisions of %s: %w", filename, err)
+	}
+	var old []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &old); err != nil {
+		return fmt.Errorf("failed to find old revisions of %s: %w", filename, err)
+	}
+	for _, file := range old {
+		if err := bucket.DeleteContext(ctx, file.ID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return fmt.Errorf("failed to delete old revision of %s: %w", filename, err)
+		}
+	}
+	return nil
+}
+
+func (s *GridFSStore) Get(ctx context.Context, submissionID primitive.ObjectID, name string) ([]byte, error) {
+	if err := validName(name); err != nil {
+		return nil, err
+	}
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The newest revision is downloaded by default
+	filename := gridFSFileName(submissionID, name)
+	var buf bytes.Buffer
+	if _, err := bucket.DownloadToStreamByName(filename, &buf); err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to download %s: %w", filename, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,117 @@
+// Package storage keeps the files that belong to a submission, such as its
+// source code and the log of its test results, somewhere every API server and
+// judge can reach them.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"backend/internal/database"
+	"backend/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotFound is returned by SourceStore.Get for a file that was never stored.
+var ErrNotFound = errors.New("submission file not found")
+
+// TestResultsLogName is the file the judge writes a submission's test results to.
+const TestResultsLogName = "test_results.log"
+
+// SourceStore keeps the files of submissions. A file is addressed by the
+// submission it belongs to and its name, e.g. "code.py". Putting a file that
+// already exists replaces it.
+type SourceStore interface {
+	Put(ctx context.Context, submissionID primitive.ObjectID, name string, data []byte) error
+	Get(ctx context.Context, submissionID primitive.ObjectID, name string) ([]byte, error)
+}
+
+// DefaultLocalDir is where LocalStore keeps files unless configured otherwise.
+const DefaultLocalDir = "./submissions"
+
+// NewFromEnv returns the store configured through SOURCE_STORE: "gridfs" keeps
+// files in MongoDB and needs database.DB connected, while "local", the
+// default, keeps them under SOURCE_STORE_DIR (./submissions if unset).
+func NewFromEnv() (SourceStore, error) {
+	switch kind := os.Getenv("SOURCE_STORE"); kind {
+	case "gridfs":
+		if database.DB == nil {
+			return nil, fmt.Errorf("mongodb client is not initialized")
+		}
+		return NewGridFSStore(database.DB.Database("OJ")), nil
+	case "", "local":
+		dir := os.Getenv("SOURCE_STORE_DIR")
+		if dir == "" {
+			dir = DefaultLocalDir
+		}
+		return NewLocalStore(dir), nil
+	default:
+		return nil, fmt.Errorf("unknown SOURCE_STORE %q, use gridfs or local", kind)
+	}
+}
+
+// CodeFileName returns the name a submission's code in language is stored under.
+func CodeFileName(language string) string {
+	return "code" + utils.GetFileExtension(language)
+}
+
+// validName rejects names that could escape a submission's directory.
+func validName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid submission file name %q", name)
+	}
+	return nil
+}
+
+// LocalStore keeps submission files on local disk, one directory per
+// submission. It only works while every API server and judge shares the
+// directory.
+type LocalStore struct {
+	Dir string
+}
+
+// NewLocalStore returns a store rooted at dir, e.g. "./submissions".
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{Dir: dir}
+}
+
+func (s *LocalStore) path(submissionID primitive.ObjectID, name string) (string, error) {
+	if err := validName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, submissionID.Hex(), name), nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, submissionID primitive.ObjectID, name string, data []byte) error {
+	path, err := s.path(submissionID, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create submission directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, submissionID primitive.ObjectID, name string) ([]byte, error) {
+	path, err := s.path(submissionID, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLocalStore(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+	ctx := context.Background()
+	id := primitive.NewObjectID()
+
+	if _, err := store.Get(ctx, id, "code.py"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get before Put = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Put(ctx, id, "code.py", []byte("print(1)")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Put(ctx, id, "code.py", []byte("print(2)")); err != nil {
+		t.Fatalf("Put over an existing file failed: %v", err)
+	}
+	data, err := store.Get(ctx, id, "code.py")
+	if err != nil || string(data) != "print(2)" {
+		t.Errorf("Get = %q, %v; want the last file put", data, err)
+	}
+
+	if _, err := store.Get(ctx, primitive.NewObjectID(), "code.py"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get for another submission = %v, want ErrNotFound", err)
+	}
+}
+
+func TestInvalidFileNames(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+	for _, name := range []string{"", ".", "..", "../code.py", `dir\code.py`} {
+		if err := store.Put(context.Background(), primitive.NewObjectID(), name, nil); err == nil {
+			t.Errorf("Put accepted file name %q", name)
+		}
+	}
+}
+
+func TestCodeFileName(t *testing.T) {
+	if got := CodeFileName("python"); got != "code.py" {
+		t.Errorf("CodeFileName(python) = %q, want code.py", got)
+	}
+	if got := CodeFileName("pseudocode"); got != "code.pseudo" {
+		t.Errorf("CodeFileName(pseudocode) = %q, want code.pseudo", got)
+	}
+}
@@ -1,6 +1,10 @@
 package types
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"backend/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 // This is the struct for the JWT token
 type Claims struct {
@@ -48,34 +52,105 @@ type ExecuteCodeResult struct {
 	Error           string           `json:"error,omitempty"`   // For errors in the execution service itself
 	Status          string           `json:"status"`            // e.g., "success", "compile_error", "runtime_error", "timeout"
 	Results         []TestCaseResult `json:"results,omitempty"` // Results for multiple test cases
+	// Compiler messages in the user's line numbers, set with status
+	// "compile_error"
+	Diagnostics []models.CompileDiagnostic `json:"diagnostics,omitempty"`
 }
 
 // TestCaseResult defines the result for a single test case
 type TestCaseResult struct {
 	Stdout          string `json:"stdout"`
 	Stderr          string `json:"stderr"`
-	ExecutionTimeMs int64  `json:"execution_time_ms"`
+	ExecutionTimeMs int64  `json:"execution_time_ms"` // CPU time
+	WallTimeMs      int64  `json:"wall_time_ms"`
+	TimeLimitMs     int    `json:"time_limit_ms"` // CPU time limit the test ran with
 	MemoryUsedKB    int    `json:"memory_used_kb"`
+	MemoryLimitKB   int    `json:"memory_limit_kb"` // Memory limit the test ran with, 0 for none
 	Error           string `json:"error,omitempty"`
 	Status          string `json:"status"`
+	// Set for interactive problems
+	Transcript        string `json:"transcript,omitempty"`
+	InteractorMessage string `json:"interactor_message,omitempty"`
+	ExecutorVersion   string `json:"executor_version,omitempty"`
+	// Set with status "compile_error"
+	Diagnostics []models.CompileDiagnostic `json:"diagnostics,omitempty"`
 }
 
 // ExecutionRequest defines the structure for a code execution request
 type ExecutionRequest struct {
-	Language     string `json:"language"`
-	Code         string `json:"code"`
-	Input        string `json:"input"`
-	TimeLimitMs  int    `json:"time_limit_ms"`
-	FunctionName string `json:"function_name"`
-	Parser       string `json:"parser"`
+	Language      string `json:"language"`
+	Code          string `json:"code"`
+	Input         string `json:"input"`
+	TimeLimitMs   int    `json:"time_limit_ms"`             // CPU time limit
+	MemoryLimitKB int    `json:"memory_limit_kb,omitempty"` // 0 means no memory limit
+	FunctionName  string `json:"function_name"`
+	Parser        string `json:"parser"`
+	// Signature replaces Parser for problems that have one: the executor wraps
+	// Code itself and Input is in the canonical format
+	Signature *models.FunctionSignature `json:"signature,omitempty"`
+	// Interactor turns this into an interactive run: Code is a complete
+	// program talking to the interactor, which is given Input
+	Interactor *Interactor `json:"interactor,omitempty"`
+	// Complete runs Code as a complete program even in languages whose
+	// executors would wrap it, as custom checkers are. The executor puts the
+	// structures library for signature types next to it
+	Complete bool `json:"complete,omitempty"`
+}
+
+// Interactor is the judge-side program of an interactive problem. It reads the
+// test input from the file named by its first argument, talks to the solution
+// over stdin/stdout and writes its verdict to the file named by its second
+// argument: OK (or AC) to accept, QLE for too many queries, anything else to
+// reject, followed by an optional message.
+type Interactor struct {
+	Code        string `json:"code"`
+	Language    string `json:"language"`
+	TimeLimitMs int    `json:"time_limit_ms"`         // CPU time limit
+	QueryLimit  int    `json:"query_limit,omitempty"` // Lines the solution may send, 0 for no limit
 }
 
 // ExecutionResult defines the structure for a code execution result
 type ExecutionResult struct {
 	Output          string `json:"output"`
-	ExecutionTimeMs int    `json:"execution_time_ms"`
+	ExecutionTimeMs int    `json:"execution_time_ms"` // CPU time
+	WallTimeMs      int    `json:"wall_time_ms"`
 	MemoryUsedKB    int    `json:"memory_used_kb"`
 	Status          string `json:"status"`
+	// Set for interactive runs
+	Transcript        string `json:"transcript,omitempty"`
+	InteractorMessage string `json:"interactor_message,omitempty"`
+	// Compiler messages in the user's line numbers, set with status
+	// "compile_error"
+	Diagnostics []models.CompileDiagnostic `json:"diagnostics,omitempty"`
+	// Build of the executor that ran the code, from its X-Executor-Version header
+	ExecutorVersion string `json:"executor_version,omitempty"`
+}
+
+// BatchExecutionRequest runs one program against many inputs in a single
+// executor request, so compiled languages are compiled once.
+type BatchExecutionRequest struct {
+	Language      string                    `json:"language"`
+	Code          string                    `json:"code"`
+	FunctionName  string                    `json:"function_name"`
+	Parser        string                    `json:"parser"`
+	Signature     *models.FunctionSignature `json:"signature,omitempty"`
+	Tests         []BatchTestInput          `json:"tests"`
+	StopOnFailure bool                      `json:"stop_on_failure"` // Start no more tests once one has failed to run cleanly
+	Parallelism   int                       `json:"parallelism"`     // Tests run at once
+}
+
+// BatchTestInput is one input of a batch with its limits.
+type BatchTestInput struct {
+	Input         string `json:"input"`
+	TimeLimitMs   int    `json:"time_limit_ms"`
+	MemoryLimitKB int    `json:"memory_limit_kb"`
+}
+
+// BatchExecutionResult holds a result for each test of a batch that ran, in
+// order. With StopOnFailure there may be fewer results than tests.
+type BatchExecutionResult struct {
+	Results         []ExecutionResult `json:"results"`
+	ExecutorVersion string            `json:"executor_version,omitempty"`
 }
 
 // ParserCheckPayload defines the structure for a parser check request
@@ -11,8 +11,9 @@ import (
 
 // CalculateAcceptanceRate calculates the percentage of accepted submissions for a problem
 func CalculateAcceptanceRate(ctx context.Context, submissionsCollection *mongo.Collection, problemID string) (float64, error) {
-	// Count total submissions for this problem
-	totalFilter := bson.M{"problem_id": problemID}
+	// Count total submissions for this problem, leaving out those the judge
+	// failed on
+	totalFilter := bson.M{"problem_id": problemID, "status": bson.M{"$ne": models.StatusJudgeError}}
 	totalCount, err := submissionsCollection.CountDocuments(ctx, totalFilter)
 	if err != nil {
 		return 0, fmt.Errorf("error counting total submissions: %v", err)
@@ -1,12 +1,8 @@
 package utils
 
 import (
-	"backend/internal/types"
-	"bytes"
-	"context"
+	"backend/internal/languages"
 	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -36,95 +32,31 @@ func ParseInt(s string) (int, error) {
 	return strconv.Atoi(s)
 }
 
-// ExecuteCode delegates code execution to a long-lived language-specific
-// executor container running on localhost.
-//
-// Language  →  Port
-//
-//	python      :8001
-//	javascript  :8002
-//	cpp         :8003
-//	java        :8004
-//
-// The container exposes POST /execute with body types.ExecutionRequest
-// and returns types.ExecutionResult.
-func ExecuteCode(ctx context.Context, req types.ExecutionRequest) (types.ExecutionResult, error) {
-	fmt.Println("Executing code in DOCKER in ", req.Language)
-	log.Printf("ExecuteCode called with: FunctionName=%q, Language=%s, Parser length=%d",
-		req.FunctionName, req.Language, len(req.Parser))
-
-	langToPort := map[string]string{
-		"python":     "8001",
-		"javascript": "8002",
-		"js":         "8002",
-		"cpp":        "8003",
-		"c++":        "8003",
-		"java":       "8004",
-	}
-
-	port, ok := langToPort[strings.ToLower(req.Language)]
-	if !ok {
-		return types.ExecutionResult{}, fmt.Errorf("unsupported language: %s", req.Language)
-	}
-
-	endpoint := fmt.Sprintf("http://localhost:%s/execute", port)
-	log.Printf("Sending request to: %s", endpoint)
-
-	payload, err := json.Marshal(req)
-	if err != nil {
-		return types.ExecutionResult{}, fmt.Errorf("marshal exec-request: %w", err)
-	}
-
-	// Log a portion of the payload for debugging
-	if len(payload) > 100 {
-		log.Printf("Payload (first 100 bytes): %s...", string(payload[:100]))
-	} else {
-		log.Printf("Payload: %s", string(payload))
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
-	if err != nil {
-		return types.ExecutionResult{}, err
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		return types.ExecutionResult{}, fmt.Errorf("executor call failed: %w", err)
+// GetFileExtension returns the file extension for a given language.
+// Pseudocode, which is converted before it runs, is not in the languages
+// registry but has its own.
+func GetFileExtension(language string) string {
+	if lang, ok := languages.Lookup(language); ok {
+		return lang.Extension
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		var e map[string]string
-		_ = json.NewDecoder(resp.Body).Decode(&e)
-		return types.ExecutionResult{}, fmt.Errorf("executor %d: %s", resp.StatusCode, e["message"])
+	if strings.ToLower(language) == "pseudocode" {
+		return ".pseudo"
 	}
+	return ".txt"
+}
 
-	var result types.ExecutionResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return types.ExecutionResult{}, fmt.Errorf("decode exec-result: %w", err)
+// TimeLimitMultiplier returns the factor a problem's time limit is scaled by
+// for a language, to make up for slower runtimes.
+func TimeLimitMultiplier(language string) float64 {
+	if lang, ok := languages.Lookup(language); ok {
+		return lang.TimeMultiplier
 	}
-
-	log.Printf("Received execution result: status=%s", result.Status)
-	return result, nil
+	return 1
 }
 
-// GetFileExtension returns the file extension for a given language.
-func GetFileExtension(language string) string {
-	switch strings.ToLower(language) {
-	case "python":
-		return ".py"
-	case "javascript":
-		return ".js"
-	case "cpp":
-		return ".cpp"
-	case "java":
-		return ".java"
-	case "pseudocode":
-		return ".pseudo"
-	default:
-		return ".txt"
-	}
+// ScaleTimeLimit applies the language's multiplier to a problem time limit.
+func ScaleTimeLimit(language string, timeLimitMs int) int {
+	return int(float64(timeLimitMs) * TimeLimitMultiplier(language))
 }
 
 // func cleanAIResponse(resp string) (string, error) {
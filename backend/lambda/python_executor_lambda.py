@@ -1,10 +1,105 @@
 import json
+import math
+import signal
 import time
 import sys
 import os
+import resource
 import subprocess
+import threading
 import traceback
 
+# Markers in stderr that mean the program ran out of memory
+MEMORY_ERROR_MARKERS = ("MemoryError",)
+
+# Characters kept of each of stdout and stderr; a program writing more is
+# killed. Lambda responses are capped at 6 MB, so this stays well below it
+OUTPUT_LIMIT_CHARS = int(os.environ.get("OUTPUT_LIMIT_KB", "2048")) * 1024
+
+
+def run_with_limits(args, input_path, cpu_limit_s, timeout_s, memory_limit_kb):
+    """
+    Runs args with its CPU time capped at cpu_limit_s, its address space capped
+    at memory_limit_kb (if set) and a wall-clock timeout as a safety net.
+    It is killed once it writes more than OUTPUT_LIMIT_CHARS to either stream.
+    Returns (returncode, stdout, stderr, timed_out, output_exceeded,
+    cpu_time_ms, peak_rss_kb).
+    """
+    def apply_limits():
+        if memory_limit_kb:
+            limit = memory_limit_kb * 1024
+            resource.setrlimit(resource.RLIMIT_AS, (limit, limit))
+        # RLIMIT_CPU has one-second granularity; the exact check is done on
+        # the measured CPU time afterwards
+        if cpu_limit_s:
+            cpu_seconds = int(math.ceil(cpu_limit_s))
+            resource.setrlimit(resource.RLIMIT_CPU, (cpu_seconds, cpu_seconds + 1))
+
+    with open(input_path, "r") as stdin:
+        process = subprocess.Popen(
+            args,
+            stdin=stdin,
+            stdout=subprocess.PIPE,
+            stderr=subprocess.PIPE,
+            text=True,
+            preexec_fn=apply_limits
+        )
+
+    timed_out = threading.Event()
+
+    def kill():
+        timed_out.set()
+        process.kill()
+
+    timer = threading.Timer(timeout_s, kill)
+    timer.start()
+
+    output_exceeded = threading.Event()
+
+    # Drain both pipes while the child runs so it cannot block on a full pipe
+    output = {}
+
+    def drain(name, stream):
+        chunks, kept = [], 0
+        while True:
+            chunk = stream.read(64 * 1024)
+            if not chunk:
+                break
+            if output_exceeded.is_set():
+                continue
+            if kept + len(chunk) > OUTPUT_LIMIT_CHARS:
+                chunks.append(chunk[:OUTPUT_LIMIT_CHARS - kept])
+                output_exceeded.set()
+                process.kill()
+                continue
+            chunks.append(chunk)
+            kept += len(chunk)
+        output[name] = "".join(chunks)
+
+    readers = [
+        threading.Thread(target=drain, args=("stdout", process.stdout)),
+        threading.Thread(target=drain, args=("stderr", process.stderr)),
+    ]
+    for reader in readers:
+        reader.start()
+
+    # wait4 gives the rusage of this child alone; RUSAGE_CHILDREN would carry
+    # the peak over from earlier invocations in a warm container
+    _, wait_status, usage = os.wait4(process.pid, 0)
+    process.returncode = os.waitstatus_to_exitcode(wait_status)
+    timer.cancel()
+    for reader in readers:
+        reader.join()
+    process.stdout.close()
+    process.stderr.close()
+
+    cpu_time_ms = int((usage.ru_utime + usage.ru_stime) * 1000)
+    signaled_cpu = process.returncode == -signal.SIGXCPU
+    timed_out = timed_out.is_set() or signaled_cpu or (cpu_limit_s and cpu_time_ms > cpu_limit_s * 1000)
+
+    return (process.returncode, output.get("stdout", ""), output.get("stderr", ""), timed_out,
+            output_exceeded.is_set(), cpu_time_ms, usage.ru_maxrss)
+
 def lambda_handler(event, context):
     """
     AWS Lambda handler for executing Python code.
@@ -13,22 +108,27 @@ def lambda_handler(event, context):
     {
         "code": "print('Hello, World!')",
         "input": "optional input string",
-        "time_limit_ms": 10000
+        "time_limit_ms": 10000,
+        "memory_limit_kb": 262144
     }
     
     Returns:
     {
         "output": "execution output or error message",
-        "execution_time_ms": execution time in milliseconds,
+        "execution_time_ms": CPU time in milliseconds,
+        "wall_time_ms": wall-clock time in milliseconds,
         "memory_used_kb": memory usage in KB,
-        "status": "success", "runtime_error", "time_limit_exceeded", or "compilation_error"
+        "status": "success", "runtime_error", "time_limit_exceeded",
+                  "memory_limit_exceeded", "output_limit_exceeded", or
+                  "compilation_error"
     }
     """
     try:
         # Extract parameters from event
         code = event.get('code', '')
         input_data = event.get('input', '')
-        time_limit_ms = event.get('time_limit_ms', 10000)  # Default 10 seconds
+        time_limit_ms = event.get('time_limit_ms') or 10000  # Default 10 seconds
+        memory_limit_kb = event.get('memory_limit_kb', 0)  # 0 means no limit
         
         if not code:
             return {
@@ -38,8 +138,10 @@ def lambda_handler(event, context):
                 "memory_used_kb": 0
             }
         
-        # Set a timeout slightly less than Lambda's timeout
-        execution_timeout = min(time_limit_ms / 1000, 8)  # Max 8 seconds
+        # The limit is CPU time; the wall-clock timeout only stops programs that
+        # block, and stays slightly less than Lambda's timeout
+        cpu_limit = time_limit_ms / 1000
+        execution_timeout = min(2 * cpu_limit + 1, 8)  # Max 8 seconds
         
         # Create a temporary file for the code
         with open("/tmp/code.py", "w") as f:
@@ -52,58 +154,57 @@ def lambda_handler(event, context):
         # Start timing
         start_time = time.time()
         
-        # Execute the code in a subprocess with timeout
-        try:
-            # Use subprocess to execute the code with input
-            process = subprocess.Popen(
-                ["python3", "/tmp/code.py"],
-                stdin=open("/tmp/input.txt", "r"),
-                stdout=subprocess.PIPE,
-                stderr=subprocess.PIPE,
-                text=True
-            )
-            
-            # Wait for the process to complete with timeout
-            stdout, stderr = process.communicate(timeout=execution_timeout)
-            
-            # Calculate execution time
-            execution_time = int((time.time() - start_time) * 1000)  # Convert to ms
-            
-            # Get memory usage (approximate)
-            try:
-                memory_used = int(os.popen('ps -o rss= -p %d' % os.getpid()).read().strip() or '0')
-            except:
-                memory_used = 0
-            
-            # Determine status based on return code
-            if process.returncode == 0:
-                status = "success"
-                output = stdout
-            else:
-                status = "runtime_error"
-                output = stderr
-                
+        # Execute the code in a subprocess with the time and memory limits
+        returncode, stdout, stderr, timed_out, output_exceeded, execution_time, memory_used = run_with_limits(
+            ["python3", "/tmp/code.py"], "/tmp/input.txt", cpu_limit, execution_timeout, memory_limit_kb
+        )
+        
+        # Calculate wall-clock time
+        wall_time = int((time.time() - start_time) * 1000)  # Convert to ms
+        
+        if output_exceeded:
             return {
-                "status": status,
-                "output": output,
+                "status": "output_limit_exceeded",
+                "output": "output limit exceeded",
                 "execution_time_ms": execution_time,
+                "wall_time_ms": wall_time,
                 "memory_used_kb": memory_used
             }
-            
-        except subprocess.TimeoutExpired:
-            # Kill the process if it times out
-            try:
-                process.kill()
-                _, _ = process.communicate()
-            except:
-                pass
-                
+
+        if timed_out:
             return {
                 "status": "time_limit_exceeded",
-                "output": "Execution timed out after {} seconds".format(execution_timeout),
-                "execution_time_ms": time_limit_ms,
-                "memory_used_kb": 0
+                "output": "Time limit of {} ms exceeded".format(time_limit_ms),
+                "execution_time_ms": execution_time,
+                "wall_time_ms": wall_time,
+                "memory_used_kb": memory_used
             }
+        
+        memory_exceeded = memory_limit_kb and memory_used > memory_limit_kb
+        if memory_exceeded or (returncode != 0 and any(m in stderr for m in MEMORY_ERROR_MARKERS)):
+            return {
+                "status": "memory_limit_exceeded",
+                "output": "memory limit exceeded",
+                "execution_time_ms": execution_time,
+                "wall_time_ms": wall_time,
+                "memory_used_kb": memory_used
+            }
+        
+        # Determine status based on return code
+        if returncode == 0:
+            status = "success"
+            output = stdout
+        else:
+            status = "runtime_error"
+            output = stderr
+            
+        return {
+            "status": status,
+            "output": output,
+            "execution_time_ms": execution_time,
+            "wall_time_ms": wall_time,
+            "memory_used_kb": memory_used
+        }
             
     except Exception as e:
         # Catch and format any other exceptions
@@ -122,6 +223,7 @@ if __name__ == "__main__":
     test_event = {
         "code": "print('Hello, World!')",
         "input": "",
-        "time_limit_ms": 5000
+        "time_limit_ms": 5000,
+        "memory_limit_kb": 65536
     }
     print(json.dumps(lambda_handler(test_event, None), indent=2)) 
\ No newline at end of file
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+
+	"runner"
+)
+
+// compileFlags are passed to gcc before the source file.
+var compileFlags = []string{"-O2", "-std=c17"}
+
+// Submissions are complete C17 programs, linked with the math library.
+// Allocations past the memory limit make malloc return NULL.
+func main() {
+	runner.Serve(runner.Toolchain{
+		Name:       "C",
+		SourceFile: "main.c",
+		Compile: func(dir string) runner.Command {
+			return runner.Command{Name: "gcc", Args: append(compileFlags, "-o", "main", "main.c", "-lm")}
+		},
+		CompileCapAddressSpace: true,
+		Diagnostics:            runner.ParseGCCDiagnostics,
+		Run: func(dir string, memoryLimitKB int) runner.Command {
+			return runner.Command{Name: filepath.Join(dir, "main")}
+		},
+		CapAddressSpace: true,
+		Info: runner.Info{
+			Language:       "c",
+			DisplayName:    "C",
+			CompileFlags:   append(compileFlags, "-lm"),
+			Template:       template,
+			TimeMultiplier: 1,
+		},
+		VersionCommand: []string{"gcc", "--version"},
+	})
+}
+
+const template = `#include <stdio.h>
+
+int main(void) {
+    int n;
+    if (scanf("%d", &n) != 1) return 0;
+    printf("%d\n", n);
+    return 0;
+}
+`
@@ -0,0 +1,522 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"runner"
+)
+
+// harnessPrelude comes before the user's code: the headers and the node types
+// LeetCode-style solutions expect to find already defined.
+const harnessPrelude = `#include <bits/stdc++.h>
+using namespace std;
+
+struct ListNode {
+    int val;
+    ListNode *next;
+    ListNode() : val(0), next(nullptr) {}
+    ListNode(int x) : val(x), next(nullptr) {}
+    ListNode(int x, ListNode *next) : val(x), next(next) {}
+};
+
+struct TreeNode {
+    int val;
+    TreeNode *left;
+    TreeNode *right;
+    TreeNode() : val(0), left(nullptr), right(nullptr) {}
+    TreeNode(int x) : val(x), left(nullptr), right(nullptr) {}
+    TreeNode(int x, TreeNode *left, TreeNode *right) : val(x), left(left), right(right) {}
+};
+
+struct GraphNode {
+    int val;
+    vector<GraphNode*> neighbors;
+    GraphNode() : val(0) {}
+    GraphNode(int x) : val(x) {}
+    GraphNode(int x, vector<GraphNode*> neighbors) : val(x), neighbors(neighbors) {}
+};
+
+`
+
+// harnessRuntime comes after the user's code: a JSON reader and writer with
+// an overload of read and write for every type a signature can use. Values
+// that do not fit throw harness::Error. With harnessPrelude it makes up
+// structures.h, see writeStructures, so checkers can parse their input with
+// harness::parse and convert nodes with listFromJson, treeFromJson,
+// graphFromJson and harness::toJson.
+const harnessRuntime = `
+namespace harness {
+
+struct Error : std::runtime_error {
+    using std::runtime_error::runtime_error;
+};
+
+[[noreturn]] void fail(const std::string& message) {
+    throw Error(message);
+}
+
+struct Json {
+    enum Kind { Null, Bool, Number, String, Array, Object } kind = Null;
+    bool boolean = false;
+    std::string text; // Digits of a number, or a decoded string
+    std::vector<Json> items;
+    std::vector<std::pair<std::string, Json>> fields; // Of an object, in order
+
+    const Json& operator[](const std::string& key) const {
+        for (const auto& field : fields) {
+            if (field.first == key) return field.second;
+        }
+        fail("missing key " + key);
+    }
+};
+
+struct JsonReader {
+    const std::string& s;
+    size_t pos = 0;
+
+    void space() {
+        while (pos < s.size() && std::isspace((unsigned char)s[pos])) pos++;
+    }
+
+    bool literal(const char* word) {
+        size_t n = std::strlen(word);
+        if (s.compare(pos, n, word) != 0) return false;
+        pos += n;
+        return true;
+    }
+
+    void utf8(std::string& out, unsigned long c) {
+        if (c < 0x80) {
+            out += (char)c;
+        } else if (c < 0x800) {
+            out += (char)(0xC0 | (c >> 6));
+            out += (char)(0x80 | (c & 0x3F));
+        } else if (c < 0x10000) {
+            out += (char)(0xE0 | (c >> 12));
+            out += (char)(0x80 | ((c >> 6) & 0x3F));
+            out += (char)(0x80 | (c & 0x3F));
+        } else {
+            out += (char)(0xF0 | (c >> 18));
+            out += (char)(0x80 | ((c >> 12) & 0x3F));
+            out += (char)(0x80 | ((c >> 6) & 0x3F));
+            out += (char)(0x80 | (c & 0x3F));
+        }
+    }
+
+    unsigned long hex4() {
+        if (pos + 4 > s.size()) fail("bad \\u escape");
+        unsigned long c = std::stoul(s.substr(pos, 4), nullptr, 16);
+        pos += 4;
+        return c;
+    }
+
+    std::string str() {
+        std::string out;
+        pos++; // Opening quote
+        while (true) {
+            if (pos >= s.size()) fail("unterminated string");
+            char c = s[pos++];
+            if (c == '"') return out;
+            if (c != '\\') {
+                out += c;
+                continue;
+            }
+            if (pos >= s.size()) fail("unterminated string");
+            char e = s[pos++];
+            switch (e) {
+            case 'n': out += '\n'; break;
+            case 't': out += '\t'; break;
+            case 'r': out += '\r'; break;
+            case 'b': out += '\b'; break;
+            case 'f': out += '\f'; break;
+            case 'u': {
+                unsigned long c = hex4();
+                if (c >= 0xD800 && c < 0xDC00 && literal("\\u")) {
+                    c = 0x10000 + ((c - 0xD800) << 10) + (hex4() - 0xDC00);
+                }
+                utf8(out, c);
+                break;
+            }
+            default: out += e;
+            }
+        }
+    }
+
+    Json value() {
+        space();
+        Json j;
+        if (pos >= s.size()) fail("unexpected end of input");
+        char c = s[pos];
+        if (c == '[') {
+            j.kind = Json::Array;
+            pos++;
+            space();
+            if (pos < s.size() && s[pos] == ']') {
+                pos++;
+                return j;
+            }
+            while (true) {
+                j.items.push_back(value());
+                space();
+                if (pos < s.size() && s[pos] == ',') {
+                    pos++;
+                } else if (pos < s.size() && s[pos] == ']') {
+                    pos++;
+                    return j;
+                } else {
+                    fail("expected , or ] in array");
+                }
+            }
+        }
+        if (c == '{') {
+            j.kind = Json::Object;
+            pos++;
+            space();
+            if (pos < s.size() && s[pos] == '}') {
+                pos++;
+                return j;
+            }
+            while (true) {
+                space();
+                if (pos >= s.size() || s[pos] != '"') fail("expected a key in object");
+                std::string key = str();
+                space();
+                if (pos >= s.size() || s[pos] != ':') fail("expected : in object");
+                pos++;
+                j.fields.emplace_back(key, value());
+                space();
+                if (pos < s.size() && s[pos] == ',') {
+                    pos++;
+                } else if (pos < s.size() && s[pos] == '}') {
+                    pos++;
+                    return j;
+                } else {
+                    fail("expected , or } in object");
+                }
+            }
+        }
+        if (c == '"') {
+            j.kind = Json::String;
+            j.text = str();
+            return j;
+        }
+        if (literal("null")) return j;
+        if (literal("true")) {
+            j.kind = Json::Bool;
+            j.boolean = true;
+            return j;
+        }
+        if (literal("false")) {
+            j.kind = Json::Bool;
+            return j;
+        }
+        size_t start = pos;
+        while (pos < s.size() && std::strchr("+-0123456789.eE", s[pos])) pos++;
+        if (pos == start) fail(std::string("unexpected character ") + c);
+        j.kind = Json::Number;
+        j.text = s.substr(start, pos - start);
+        return j;
+    }
+};
+
+Json parse(const std::string& line) {
+    JsonReader reader{line};
+    Json j = reader.value();
+    reader.space();
+    if (reader.pos != line.size()) fail("trailing characters after value");
+    return j;
+}
+
+bool integral(const std::string& text) {
+    return text.find_first_of(".eE") == std::string::npos;
+}
+
+void read(const Json& j, long long& v) {
+    if (j.kind != Json::Number || !integral(j.text)) fail("expected an integer");
+    try {
+        v = std::stoll(j.text);
+    } catch (...) {
+        fail("integer out of range: " + j.text);
+    }
+}
+
+void read(const Json& j, int& v) {
+    long long n;
+    read(j, n);
+    if (n < INT_MIN || n > INT_MAX) fail("integer out of range: " + j.text);
+    v = (int)n;
+}
+
+void read(const Json& j, double& v) {
+    if (j.kind != Json::Number) fail("expected a number");
+    try {
+        v = std::stod(j.text);
+    } catch (...) {
+        fail("invalid number: " + j.text);
+    }
+}
+
+void read(const Json& j, bool& v) {
+    if (j.kind != Json::Bool) fail("expected true or false");
+    v = j.boolean;
+}
+
+void read(const Json& j, std::string& v) {
+    if (j.kind != Json::String) fail("expected a string");
+    v = j.text;
+}
+
+template <typename T>
+void read(const Json& j, std::vector<T>& v) {
+    if (j.kind != Json::Array) fail("expected an array");
+    v.clear();
+    for (const Json& item : j.items) {
+        T x;
+        read(item, x);
+        v.push_back(x);
+    }
+}
+
+ListNode* listFromJson(const Json& j) {
+    std::vector<int> values;
+    read(j, values);
+    ListNode dummy;
+    ListNode* tail = &dummy;
+    for (int x : values) {
+        tail->next = new ListNode(x);
+        tail = tail->next;
+    }
+    return dummy.next;
+}
+
+// Level order, with null for missing children: [1,null,2,3]
+TreeNode* treeFromJson(const Json& j) {
+    if (j.kind != Json::Array) fail("expected an array");
+    if (j.items.empty() || j.items[0].kind == Json::Null) return nullptr;
+    auto node = [](const Json& item) -> TreeNode* {
+        if (item.kind == Json::Null) return nullptr;
+        int x;
+        read(item, x);
+        return new TreeNode(x);
+    };
+    TreeNode* root = node(j.items[0]);
+    std::queue<TreeNode*> parents;
+    parents.push(root);
+    size_t i = 1;
+    while (!parents.empty() && i < j.items.size()) {
+        TreeNode* parent = parents.front();
+        parents.pop();
+        parent->left = node(j.items[i++]);
+        if (parent->left) parents.push(parent->left);
+        if (i < j.items.size()) {
+            parent->right = node(j.items[i++]);
+            if (parent->right) parents.push(parent->right);
+        }
+    }
+    return root;
+}
+
+// The adjacency list: node i+1 has value i+1 and its neighbors' values at
+// index i, as in [[2,3],[1],[1]]. Returns node 1, or nullptr for [].
+GraphNode* graphFromJson(const Json& j) {
+    std::vector<std::vector<int>> lists;
+    read(j, lists);
+    std::vector<GraphNode*> nodes;
+    for (size_t i = 0; i < lists.size(); i++) nodes.push_back(new GraphNode((int)i + 1));
+    for (size_t i = 0; i < lists.size(); i++) {
+        for (int x : lists[i]) {
+            if (x < 1 || x > (int)nodes.size()) fail("neighbor " + std::to_string(x) + " is not a node of the graph");
+            nodes[i]->neighbors.push_back(nodes[x - 1]);
+        }
+    }
+    return nodes.empty() ? nullptr : nodes[0];
+}
+
+void read(const Json& j, ListNode*& head) { head = listFromJson(j); }
+void read(const Json& j, TreeNode*& root) { root = treeFromJson(j); }
+void read(const Json& j, GraphNode*& node) { node = graphFromJson(j); }
+
+void write(std::ostream& out, long long v) { out << v; }
+void write(std::ostream& out, int v) { out << v; }
+void write(std::ostream& out, bool v) { out << (v ? "true" : "false"); }
+
+void write(std::ostream& out, double v) {
+    char buf[64];
+    std::snprintf(buf, sizeof buf, "%.5f", v);
+    out << buf;
+}
+
+void write(std::ostream& out, const std::string& v) {
+    out << '"';
+    for (unsigned char c : v) {
+        switch (c) {
+        case '"': out << "\\\""; break;
+        case '\\': out << "\\\\"; break;
+        case '\n': out << "\\n"; break;
+        case '\t': out << "\\t"; break;
+        case '\r': out << "\\r"; break;
+        case '\b': out << "\\b"; break;
+        case '\f': out << "\\f"; break;
+        default:
+            if (c < 0x20) {
+                char buf[8];
+                std::snprintf(buf, sizeof buf, "\\u%04x", c);
+                out << buf;
+            } else {
+                out << c;
+            }
+        }
+    }
+    out << '"';
+}
+
+void write(std::ostream& out, ListNode* head) {
+    out << '[';
+    for (ListNode* n = head; n; n = n->next) {
+        if (n != head) out << ',';
+        out << n->val;
+    }
+    out << ']';
+}
+
+void write(std::ostream& out, TreeNode* root) {
+    std::vector<TreeNode*> level;
+    std::queue<TreeNode*> pending;
+    if (root) pending.push(root);
+    while (!pending.empty()) {
+        TreeNode* n = pending.front();
+        pending.pop();
+        level.push_back(n);
+        if (n) {
+            pending.push(n->left);
+            pending.push(n->right);
+        }
+    }
+    while (!level.empty() && !level.back()) level.pop_back();
+    out << '[';
+    for (size_t i = 0; i < level.size(); i++) {
+        if (i > 0) out << ',';
+        if (level[i]) out << level[i]->val; else out << "null";
+    }
+    out << ']';
+}
+
+// The adjacency list of the nodes reachable from node, in the order of their values
+void write(std::ostream& out, GraphNode* node) {
+    std::set<GraphNode*> seen;
+    std::vector<GraphNode*> nodes, pending;
+    if (node) pending.push_back(node);
+    while (!pending.empty()) {
+        GraphNode* n = pending.back();
+        pending.pop_back();
+        if (!seen.insert(n).second) continue;
+        nodes.push_back(n);
+        for (GraphNode* m : n->neighbors) pending.push_back(m);
+    }
+    std::stable_sort(nodes.begin(), nodes.end(), [](GraphNode* a, GraphNode* b) { return a->val < b->val; });
+    out << '[';
+    for (size_t i = 0; i < nodes.size(); i++) {
+        if (i > 0) out << ',';
+        out << '[';
+        for (size_t k = 0; k < nodes[i]->neighbors.size(); k++) {
+            if (k > 0) out << ',';
+            out << nodes[i]->neighbors[k]->val;
+        }
+        out << ']';
+    }
+    out << ']';
+}
+
+// Declared after the node writers so it can call them for vectors of nodes
+template <typename T>
+void write(std::ostream& out, const std::vector<T>& v) {
+    out << '[';
+    for (size_t i = 0; i < v.size(); i++) {
+        if (i > 0) out << ',';
+        const T& x = v[i];
+        write(out, x);
+    }
+    out << ']';
+}
+
+// toJson returns the canonical JSON of v.
+template <typename T>
+std::string toJson(const T& v) {
+    std::ostringstream out;
+    write(out, v);
+    return out.str();
+}
+
+// argument reads the parameter name from the next input line into v.
+template <typename T>
+void argument(const char* name, T& v) {
+    std::string line;
+    if (!std::getline(std::cin, line)) fail(std::string("missing value for ") + name);
+    try {
+        read(parse(line), v);
+    } catch (const Error& e) {
+        fail(std::string(name) + ": " + e.what());
+    }
+}
+
+} // namespace harness
+`
+
+// generateHarness builds the program that runs the user's code against a
+// canonical test input: the user's code defines class Solution with a method
+// matching sig, which is called with one argument read from each input line.
+func generateHarness(code string, sig runner.Signature) (string, error) {
+	params, ret, err := sig.Types()
+	if err != nil {
+		return "", err
+	}
+
+	var main, reads strings.Builder
+	main.WriteString("\nint main() {\n")
+	args := make([]string, len(params))
+	for i, t := range params {
+		args[i] = fmt.Sprintf("arg%d", i)
+		fmt.Fprintf(&main, "    %s %s;\n", cppType(t), args[i])
+		fmt.Fprintf(&reads, "        harness::argument(%q, %s);\n", sig.Params[i].Name, args[i])
+	}
+	fmt.Fprintf(&main, "    try {\n%s    } catch (const harness::Error& e) {\n", reads.String())
+	main.WriteString("        std::cerr << \"invalid test input: \" << e.what() << std::endl;\n        return 1;\n    }\n")
+	fmt.Fprintf(&main, "    %s result = Solution().%s(%s);\n", cppType(ret), sig.FunctionName, strings.Join(args, ", "))
+	main.WriteString("    harness::write(std::cout, result);\n    std::cout << std::endl;\n    return 0;\n}\n")
+
+	return harnessPrelude + code + "\n" + harnessRuntime + main.String(), nil
+}
+
+// cppType is the C++ type a value of type t is held in.
+func cppType(t *runner.Type) string {
+	switch t.Kind {
+	case runner.KindInt:
+		return "int"
+	case runner.KindLong:
+		return "long long"
+	case runner.KindDouble:
+		return "double"
+	case runner.KindBool:
+		return "bool"
+	case runner.KindString:
+		return "std::string"
+	case runner.KindListNode:
+		return "ListNode*"
+	case runner.KindTreeNode:
+		return "TreeNode*"
+	case runner.KindGraphNode:
+		return "GraphNode*"
+	default:
+		return "std::vector<" + cppType(t.Elem) + ">"
+	}
+}
+
+// writeStructures saves the node types and the runtime as structures.h in
+// dir, so the program compiled there can include it.
+func writeStructures(dir string) error {
+	library := "#pragma once\n" + harnessPrelude + harnessRuntime
+	return os.WriteFile(filepath.Join(dir, "structures.h"), []byte(library), 0644)
+}
@@ -8,11 +8,11 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"text/template"
 	"time"
+
+	"runner"
 )
 
 type ExecRequest struct {
@@ -23,19 +23,51 @@ type ExecRequest struct {
 	Language      string `json:"language"`
 	FunctionName  string `json:"function_name"`
 	Parser        string `json:"parser"` // Parser code provided by the backend
+	// Signature, when set, replaces Parser: the executor generates the harness
+	// and Input is in the canonical format, see runner.Signature
+	Signature *runner.Signature `json:"signature,omitempty"`
+	// Complete, when set, runs Code as the complete program it is, as custom
+	// checkers are, instead of wrapping it. It can #include "structures.h",
+	// see writeStructures
+	Complete bool `json:"complete,omitempty"`
+	// Interactor, when set, makes this an interactive run: Code is a complete
+	// program talking to the interactor, and Input is given to the interactor
+	Interactor *runner.Interactor `json:"interactor,omitempty"`
 }
 
-type ExecResult struct {
-	Output          string `json:"output"`
-	ExecutionTimeMs int    `json:"execution_time_ms"`
-	MemoryUsedKB    int    `json:"memory_used_kb"`
-	Status          string `json:"status"`
+// BatchRequest runs one program against many tests, so compiled languages are
+// compiled once rather than once per test.
+type BatchRequest struct {
+	Code          string             `json:"code"`
+	Language      string             `json:"language"`
+	FunctionName  string             `json:"function_name"`
+	Parser        string             `json:"parser"`
+	Signature     *runner.Signature  `json:"signature,omitempty"`
+	Tests         []runner.BatchTest `json:"tests"`
+	StopOnFailure bool               `json:"stop_on_failure"` // Start no more tests once one has failed to run cleanly
+	Parallelism   int                `json:"parallelism"`     // Tests run at once, 1 if unset
+}
+
+// compileFlags are passed to g++ after the source file.
+var compileFlags = []string{"-std=c++17"}
+
+// info describes the executor on GET /info.
+var info = runner.Info{
+	Language:       "cpp",
+	DisplayName:    "C++",
+	CompileFlags:   compileFlags,
+	Template:       "#include <bits/stdc++.h>\nusing namespace std;\n\nclass Solution {\npublic:\n};\n",
+	TimeMultiplier: 1,
 }
 
 func main() {
-	http.HandleFunc("/execute", execHandler)
-	log.Println("🔵 C++-executor listening on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	runner.Init()
+
+	http.HandleFunc("/execute", runner.WithVersion(execHandler))
+	http.HandleFunc("/execute/batch", runner.WithVersion(batchHandler))
+	http.HandleFunc("/info", runner.WithVersion(runner.InfoHandler(info, "g++", "--version")))
+	log.Printf("🔵 C++-executor listening on %s", runner.Addr())
+	log.Fatal(http.ListenAndServe(runner.Addr(), nil))
 }
 
 func execHandler(w http.ResponseWriter, r *http.Request) {
@@ -45,10 +77,15 @@ func execHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(req.TimeLimitMs)*time.Millisecond)
-	defer cancel()
-
-	start := time.Now()
+	if req.Interactor != nil {
+		if req.Interactor.Language != "python" {
+			http.Error(w, fmt.Sprintf("unsupported interactor language %q", req.Interactor.Language), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(runInteractive(r.Context(), req))
+		return
+	}
 
 	wrappedCode, err := wrapCPPCode(req)
 	if err != nil {
@@ -56,63 +93,197 @@ func execHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	out, status := runCode(ctx, wrappedCode, req.Input)
-	execMs := int(time.Since(start).Milliseconds())
+	sourceMap := runner.NewSourceMap(userSourceFile, wrappedCode, req.Code)
+	res := runCode(r.Context(), wrappedCode, sourceMap, req.Input, req.TimeLimitMs, req.MemoryLimitKB)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// runCode compiles code and runs it against one input. sourceMap places
+// compiler diagnostics in the user's code.
+func runCode(ctx context.Context, code string, sourceMap runner.SourceMap, input string, timeLimitMs, memoryLimitKB int) runner.ExecResult {
+	log.Println("Running code...")
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
 
-	res := ExecResult{
+	exe, compileOut, err := compile(ctx, dir, code)
+	if err != nil {
+		return compileError(compileOut, sourceMap)
+	}
+	out, status, usage := runExecutable(ctx, exe, input, timeLimitMs, memoryLimitKB)
+	return runner.ExecResult{
 		Output:          out,
 		Status:          status,
-		ExecutionTimeMs: execMs,
-		MemoryUsedKB:    0,
+		ExecutionTimeMs: usage.CPUTimeMs,
+		WallTimeMs:      usage.WallTimeMs,
+		MemoryUsedKB:    usage.MemoryUsedKB,
+	}
+}
+
+// runExecutable runs a compiled solution against one input.
+func runExecutable(ctx context.Context, exe, input string, timeLimitMs, memoryLimitKB int) (output, status string, usage runner.Usage) {
+	res := runner.Run(ctx, input, solutionLimits(filepath.Dir(exe), timeLimitMs, memoryLimitKB), exe)
+	if output, status, failed := runner.Failure(res, outOfMemory); failed {
+		return output, status, res.Usage
 	}
 
+	if res.Stderr != "" {
+		return res.Stderr, "runtime_error", res.Usage
+	}
+
+	return res.Stdout, "success", res.Usage
+}
+
+// batchHandler compiles the code of a BatchRequest once and runs it against
+// each of its tests.
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wrappedCode, err := wrapCPPCode(ExecRequest{Code: req.Code, FunctionName: req.FunctionName, Parser: req.Parser, Signature: req.Signature})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to wrap code: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Running batch of %d tests...", len(req.Tests))
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
+	exe, compileOut, err := compile(r.Context(), dir, wrappedCode)
+	if err != nil {
+		// Every test would fail the same way, so only the first is reported
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(runner.BatchResult{Results: []runner.ExecResult{
+			compileError(compileOut, runner.NewSourceMap(userSourceFile, wrappedCode, req.Code)),
+		}})
+		return
+	}
+
+	results := make([]runner.ExecResult, len(req.Tests))
+	ran := runner.Batch(len(req.Tests), req.Parallelism, req.StopOnFailure, func(i int) bool {
+		test := req.Tests[i]
+		out, status, usage := runExecutable(r.Context(), exe, test.Input, test.TimeLimitMs, test.MemoryLimitKB)
+		results[i] = runner.ExecResult{
+			Output:          out,
+			Status:          status,
+			ExecutionTimeMs: usage.CPUTimeMs,
+			WallTimeMs:      usage.WallTimeMs,
+			MemoryUsedKB:    usage.MemoryUsedKB,
+		}
+		return status != "success"
+	})
+
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(res)
+	_ = json.NewEncoder(w).Encode(runner.BatchResult{Results: results[:ran]})
 }
 
-func runCode(ctx context.Context, code, input string) (output, status string) {
-	log.Println("Running code...")
+// runInteractive runs req.Code against req.Interactor, which gets req.Input.
+// The code is a complete program and is not wrapped.
+func runInteractive(ctx context.Context, req ExecRequest) runner.ExecResult {
+	log.Println("Running interactive code...")
 	dir, _ := os.MkdirTemp("", "exec-*")
 	defer os.RemoveAll(dir)
 
-	source := filepath.Join(dir, "source.cpp")
-	exe := filepath.Join(dir, "main")
-	_ = os.WriteFile(source, []byte(code), 0644)
+	exe, compileOut, err := compile(ctx, dir, req.Code)
+	if err != nil {
+		return compileError(compileOut, runner.NewSourceMap(userSourceFile, req.Code, req.Code))
+	}
+	// Kept out of dir, which the sandboxed solution can read
+	interactorDir, _ := os.MkdirTemp("", "interactor-*")
+	defer os.RemoveAll(interactorDir)
+	interactor := filepath.Join(interactorDir, "interactor.py")
+	_ = os.WriteFile(interactor, []byte(req.Interactor.Code), 0644)
 
-	// Compile
-	compileCmd := exec.Command("g++", "-o", exe, source, "-std=c++17")
-	var compileOut bytes.Buffer
-	compileCmd.Stderr = &compileOut
-	if err := compileCmd.Run(); err != nil {
-		return compileOut.String(), "compilation_error"
+	res := runner.Interact(ctx, runner.Interaction{
+		Input:            req.Input,
+		Solution:         runner.Command{Name: exe},
+		SolutionLimits:   solutionLimits(dir, req.TimeLimitMs, req.MemoryLimitKB),
+		Interactor:       runner.Command{Name: "python3", Args: []string{interactor}},
+		InteractorLimits: runner.Limits{CPUTime: time.Duration(req.Interactor.TimeLimitMs) * time.Millisecond},
+		QueryLimit:       req.Interactor.QueryLimit,
+	})
+	if res.Interactor.Stderr != "" {
+		log.Printf("Interactor stderr: %s", res.Interactor.Stderr)
 	}
 
-	// Run
-	cmd := exec.CommandContext(ctx, exe)
-	if input != "" {
-		cmd.Stdin = strings.NewReader(input)
+	output, status := "", "success"
+	if o, s, failed := runner.Failure(res.Solution, outOfMemory); failed {
+		output, status = o, s
 	}
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	output, status = res.Judge(output, status)
 
-	if err := cmd.Run(); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "time limit exceeded", "time_limit_exceeded"
-		}
-		if stderr.Len() > 0 {
-			return stderr.String(), "runtime_error"
-		}
-		return err.Error(), "runtime_error"
+	return runner.ExecResult{
+		Output:            output,
+		Status:            status,
+		ExecutionTimeMs:   res.Solution.CPUTimeMs,
+		WallTimeMs:        res.Solution.WallTimeMs,
+		MemoryUsedKB:      res.Solution.MemoryUsedKB,
+		Transcript:        res.Transcript,
+		InteractorMessage: res.Message,
+	}
+}
+
+// userSourceFile is the file name diagnostics in the user's code are
+// reported under.
+const userSourceFile = "solution.cpp"
+
+// compiledSourceFile is the file in the build directory g++ compiles.
+const compiledSourceFile = "source.cpp"
+
+// compileError is the result for code that failed to compile with output.
+// sourceMap places the compiler's diagnostics in the user's code.
+func compileError(output string, sourceMap runner.SourceMap) runner.ExecResult {
+	sourceMap.CompiledFile = compiledSourceFile
+	return runner.ExecResult{
+		Output:      output,
+		Status:      "compile_error",
+		Diagnostics: sourceMap.Map(runner.ParseGCCDiagnostics(output)),
 	}
+}
 
-	if stderr.Len() > 0 {
-		return stderr.String(), "runtime_error"
+// compile builds code into an executable in dir, returning the compiler's
+// output if it fails. structures.h is saved next to the source first. g++
+// runs sandboxed, within runner.CompileLimits.
+func compile(ctx context.Context, dir, code string) (exe, output string, err error) {
+	source := filepath.Join(dir, compiledSourceFile)
+	exe = filepath.Join(dir, "main")
+	_ = os.WriteFile(source, []byte(code), 0644)
+	_ = writeStructures(dir)
+
+	args := append([]string{"-o", exe, source}, compileFlags...)
+	if output, err := runner.Compile(ctx, runner.CompileLimits(dir, true), "g++", args...); err != nil {
+		return "", output, err
 	}
+	return exe, "", nil
+}
 
-	return stdout.String(), "success"
+// solutionLimits caps the address space so allocations past the limit throw
+// std::bad_alloc, and sandboxes the run of the program in dir.
+func solutionLimits(dir string, timeLimitMs, memoryLimitKB int) runner.Limits {
+	return runner.Limits{
+		CPUTime:        time.Duration(timeLimitMs) * time.Millisecond,
+		AddressSpaceKB: memoryLimitKB,
+		MemoryLimitKB:  memoryLimitKB,
+		SandboxDir:     dir,
+	}
 }
 
+// outOfMemory is on stderr when an allocation past the address space limit
+// throws.
+const outOfMemory = "std::bad_alloc"
+
 func wrapCPPCode(req ExecRequest) (string, error) {
+	if req.Complete {
+		return req.Code, nil
+	}
+	if req.Signature != nil {
+		return generateHarness(req.Code, *req.Signature)
+	}
+
 	// Use provided parser or fallback to default
 	parserCode := req.Parser
 	if parserCode == "" {
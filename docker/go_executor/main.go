@@ -0,0 +1,62 @@
+package main
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+
+	"runner"
+)
+
+// goCache is the build cache builds share, as each would spend seconds
+// rebuilding the standard library without it. It must be outside HOME, which
+// the sandbox cannot reach.
+var goCache = cmp.Or(os.Getenv("GOCACHE"), filepath.Join(os.TempDir(), "go-cache"))
+
+// Submissions are complete programs in package main. The Go runtime grows
+// its heap as it needs, so its address space is not capped and memory is
+// only checked through peak RSS.
+func main() {
+	// The compiler's sandbox gets the executor's environment
+	os.Setenv("GOCACHE", goCache)
+	runner.Serve(runner.Toolchain{
+		Name:       "Go",
+		SourceFile: "main.go",
+		Compile: func(dir string) runner.Command {
+			return runner.Command{Name: "go", Args: []string{"build", "-o", "main", "main.go"}}
+		},
+		CompileCapAddressSpace: true,
+		CompileCacheDir:        goCache,
+		Diagnostics:            runner.ParseGoDiagnostics,
+		Run: func(dir string, memoryLimitKB int) runner.Command {
+			return runner.Command{Name: filepath.Join(dir, "main")}
+		},
+		OutOfMemory: []string{"runtime: out of memory"},
+		Info: runner.Info{
+			Language:       "go",
+			DisplayName:    "Go",
+			Template:       template,
+			TimeMultiplier: 1,
+		},
+		VersionCommand: []string{"go", "version"},
+	})
+}
+
+const template = `package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+func main() {
+	in := bufio.NewReader(os.Stdin)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	var n int
+	fmt.Fscan(in, &n)
+	fmt.Fprintln(out, n)
+}
+`
@@ -0,0 +1,631 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"runner"
+)
+
+// harnessImports come before the user's code, on one line so the user's own
+// imports may follow them.
+const harnessImports = "import java.util.*; import java.io.*; import java.nio.charset.StandardCharsets;\n"
+
+// harnessNodes are the node classes LeetCode-style solutions expect to find
+// already defined. Java does not care that they come after the user's code.
+const harnessNodes = `
+class ListNode {
+    int val;
+    ListNode next;
+    ListNode() {}
+    ListNode(int val) { this.val = val; }
+    ListNode(int val, ListNode next) { this.val = val; this.next = next; }
+}
+
+class TreeNode {
+    int val;
+    TreeNode left;
+    TreeNode right;
+    TreeNode() {}
+    TreeNode(int val) { this.val = val; }
+    TreeNode(int val, TreeNode left, TreeNode right) { this.val = val; this.left = left; this.right = right; }
+}
+
+class GraphNode {
+    int val;
+    List<GraphNode> neighbors;
+    GraphNode() { this.neighbors = new ArrayList<>(); }
+    GraphNode(int val) { this.val = val; this.neighbors = new ArrayList<>(); }
+    GraphNode(int val, List<GraphNode> neighbors) { this.val = val; this.neighbors = neighbors; }
+}
+`
+
+// structuresClass parses JSON and converts the node classes from and to
+// canonical JSON. Parsed JSON is null, Boolean, Long (BigInteger past its
+// range), Double, String, List<Object> and Map<String, Object>. Values that
+// do not fit throw IllegalArgumentException. It is saved with harnessNodes
+// as Structures.java for checkers, see writeStructures, and is part of the
+// harness.
+const structuresClass = `
+class Structures {
+    static Object parse(String json) {
+        JsonReader reader = new JsonReader(json);
+        Object value = reader.value();
+        reader.space();
+        if (reader.pos != json.length()) throw new IllegalArgumentException("trailing characters after value");
+        return value;
+    }
+
+    static String toJson(Object value) {
+        StringBuilder out = new StringBuilder();
+        write(out, value);
+        return out.toString();
+    }
+
+    static void write(StringBuilder out, Object value) {
+        if (value == null || value instanceof Boolean || value instanceof Integer || value instanceof Long || value instanceof java.math.BigInteger) {
+            out.append(value);
+        } else if (value instanceof Number) {
+            out.append(String.format(Locale.ROOT, "%.5f", ((Number) value).doubleValue()));
+        } else if (value instanceof String) {
+            quote(out, (String) value);
+        } else if (value instanceof List) {
+            out.append('[');
+            boolean first = true;
+            for (Object item : (List<?>) value) {
+                if (!first) out.append(',');
+                first = false;
+                write(out, item);
+            }
+            out.append(']');
+        } else if (value instanceof Map) {
+            out.append('{');
+            boolean first = true;
+            for (Map.Entry<?, ?> field : ((Map<?, ?>) value).entrySet()) {
+                if (!first) out.append(',');
+                first = false;
+                quote(out, String.valueOf(field.getKey()));
+                out.append(':');
+                write(out, field.getValue());
+            }
+            out.append('}');
+        } else if (value instanceof ListNode) {
+            out.append(listToJson((ListNode) value));
+        } else if (value instanceof TreeNode) {
+            out.append(treeToJson((TreeNode) value));
+        } else if (value instanceof GraphNode) {
+            out.append(graphToJson((GraphNode) value));
+        } else {
+            throw new IllegalArgumentException("cannot write " + value.getClass().getName() + " as JSON");
+        }
+    }
+
+    static void quote(StringBuilder out, String v) {
+        out.append('"');
+        for (int i = 0; i < v.length(); i++) {
+            char c = v.charAt(i);
+            switch (c) {
+                case '"': out.append("\\\""); break;
+                case '\\': out.append("\\\\"); break;
+                case '\n': out.append("\\n"); break;
+                case '\t': out.append("\\t"); break;
+                case '\r': out.append("\\r"); break;
+                case '\b': out.append("\\b"); break;
+                case '\f': out.append("\\f"); break;
+                default:
+                    if (c < 0x20) out.append(String.format("\\u%04x", (int) c));
+                    else out.append(c);
+            }
+        }
+        out.append('"');
+    }
+
+    static List<?> array(Object o) {
+        if (!(o instanceof List)) throw new IllegalArgumentException("expected an array");
+        return (List<?>) o;
+    }
+
+    static int nodeValue(Object o) {
+        if (!(o instanceof Long) || (Long) o < Integer.MIN_VALUE || (Long) o > Integer.MAX_VALUE) {
+            throw new IllegalArgumentException("expected a 32-bit integer node value");
+        }
+        return (int) (long) (Long) o;
+    }
+
+    static ListNode listFromJson(Object o) {
+        ListNode dummy = new ListNode();
+        ListNode tail = dummy;
+        for (Object item : array(o)) {
+            tail.next = new ListNode(nodeValue(item));
+            tail = tail.next;
+        }
+        return dummy.next;
+    }
+
+    static String listToJson(ListNode head) {
+        StringBuilder out = new StringBuilder("[");
+        for (ListNode n = head; n != null; n = n.next) {
+            if (n != head) out.append(',');
+            out.append(n.val);
+        }
+        return out.append(']').toString();
+    }
+
+    // Level order, with null for missing children: [1,null,2,3]
+    static TreeNode treeFromJson(Object o) {
+        List<?> items = array(o);
+        if (items.isEmpty() || items.get(0) == null) return null;
+        TreeNode root = new TreeNode(nodeValue(items.get(0)));
+        Deque<TreeNode> parents = new ArrayDeque<>();
+        parents.add(root);
+        int i = 1;
+        while (!parents.isEmpty() && i < items.size()) {
+            TreeNode parent = parents.poll();
+            Object left = items.get(i++);
+            if (left != null) {
+                parent.left = new TreeNode(nodeValue(left));
+                parents.add(parent.left);
+            }
+            if (i < items.size()) {
+                Object right = items.get(i++);
+                if (right != null) {
+                    parent.right = new TreeNode(nodeValue(right));
+                    parents.add(parent.right);
+                }
+            }
+        }
+        return root;
+    }
+
+    static String treeToJson(TreeNode root) {
+        List<TreeNode> level = new ArrayList<>();
+        LinkedList<TreeNode> pending = new LinkedList<>(); // Holds nulls, unlike ArrayDeque
+        if (root != null) pending.add(root);
+        while (!pending.isEmpty()) {
+            TreeNode n = pending.poll();
+            level.add(n);
+            if (n != null) {
+                pending.add(n.left);
+                pending.add(n.right);
+            }
+        }
+        while (!level.isEmpty() && level.get(level.size() - 1) == null) level.remove(level.size() - 1);
+        StringBuilder out = new StringBuilder("[");
+        for (int i = 0; i < level.size(); i++) {
+            if (i > 0) out.append(',');
+            if (level.get(i) == null) out.append("null");
+            else out.append(level.get(i).val);
+        }
+        return out.append(']').toString();
+    }
+
+    // The adjacency list: node i+1 has value i+1 and its neighbors' values at
+    // index i, as in [[2,3],[1],[1]]. Returns node 1, or null for [].
+    static GraphNode graphFromJson(Object o) {
+        List<?> lists = array(o);
+        List<GraphNode> nodes = new ArrayList<>();
+        for (int i = 0; i < lists.size(); i++) nodes.add(new GraphNode(i + 1));
+        for (int i = 0; i < lists.size(); i++) {
+            for (Object item : array(lists.get(i))) {
+                if (!(item instanceof Long) || (Long) item < 1 || (Long) item > nodes.size()) {
+                    throw new IllegalArgumentException("neighbor " + item + " is not a node of the graph");
+                }
+                nodes.get(i).neighbors.add(nodes.get((int) (long) (Long) item - 1));
+            }
+        }
+        return nodes.isEmpty() ? null : nodes.get(0);
+    }
+
+    // The adjacency list of the nodes reachable from node, in the order of their values
+    static String graphToJson(GraphNode node) {
+        Set<GraphNode> seen = Collections.newSetFromMap(new IdentityHashMap<>());
+        List<GraphNode> nodes = new ArrayList<>();
+        Deque<GraphNode> pending = new ArrayDeque<>();
+        if (node != null) pending.push(node);
+        while (!pending.isEmpty()) {
+            GraphNode n = pending.pop();
+            if (!seen.add(n)) continue;
+            nodes.add(n);
+            for (GraphNode m : n.neighbors) pending.push(m);
+        }
+        nodes.sort(Comparator.comparingInt(n -> n.val));
+        StringBuilder out = new StringBuilder("[");
+        for (int i = 0; i < nodes.size(); i++) {
+            if (i > 0) out.append(',');
+            out.append('[');
+            List<GraphNode> neighbors = nodes.get(i).neighbors;
+            for (int k = 0; k < neighbors.size(); k++) {
+                if (k > 0) out.append(',');
+                out.append(neighbors.get(k).val);
+            }
+            out.append(']');
+        }
+        return out.append(']').toString();
+    }
+
+    static final class JsonReader {
+        final String s;
+        int pos;
+
+        JsonReader(String s) { this.s = s; }
+
+        void space() {
+            while (pos < s.length() && Character.isWhitespace(s.charAt(pos))) pos++;
+        }
+
+        IllegalArgumentException fail(String message) {
+            return new IllegalArgumentException(message);
+        }
+
+        Object value() {
+            space();
+            if (pos >= s.length()) throw fail("unexpected end of input");
+            char c = s.charAt(pos);
+            if (c == '[') {
+                pos++;
+                List<Object> items = new ArrayList<>();
+                space();
+                if (pos < s.length() && s.charAt(pos) == ']') {
+                    pos++;
+                    return items;
+                }
+                while (true) {
+                    items.add(value());
+                    space();
+                    if (pos < s.length() && s.charAt(pos) == ',') {
+                        pos++;
+                    } else if (pos < s.length() && s.charAt(pos) == ']') {
+                        pos++;
+                        return items;
+                    } else {
+                        throw fail("expected , or ] in array");
+                    }
+                }
+            }
+            if (c == '{') {
+                pos++;
+                Map<String, Object> fields = new LinkedHashMap<>();
+                space();
+                if (pos < s.length() && s.charAt(pos) == '}') {
+                    pos++;
+                    return fields;
+                }
+                while (true) {
+                    space();
+                    if (pos >= s.length() || s.charAt(pos) != '"') throw fail("expected a key in object");
+                    String key = string();
+                    space();
+                    if (pos >= s.length() || s.charAt(pos) != ':') throw fail("expected : in object");
+                    pos++;
+                    fields.put(key, value());
+                    space();
+                    if (pos < s.length() && s.charAt(pos) == ',') {
+                        pos++;
+                    } else if (pos < s.length() && s.charAt(pos) == '}') {
+                        pos++;
+                        return fields;
+                    } else {
+                        throw fail("expected , or } in object");
+                    }
+                }
+            }
+            if (c == '"') return string();
+            if (s.startsWith("null", pos)) {
+                pos += 4;
+                return null;
+            }
+            if (s.startsWith("true", pos)) {
+                pos += 4;
+                return Boolean.TRUE;
+            }
+            if (s.startsWith("false", pos)) {
+                pos += 5;
+                return Boolean.FALSE;
+            }
+            int start = pos;
+            while (pos < s.length() && "+-0123456789.eE".indexOf(s.charAt(pos)) >= 0) pos++;
+            if (pos == start) throw fail("unexpected character " + c);
+            String text = s.substring(start, pos);
+            try {
+                if (text.matches("-?[0-9]+")) {
+                    try {
+                        return Long.parseLong(text);
+                    } catch (NumberFormatException e) {
+                        return new java.math.BigInteger(text);
+                    }
+                }
+                return Double.parseDouble(text);
+            } catch (NumberFormatException e) {
+                throw fail("invalid number: " + text);
+            }
+        }
+
+        String string() {
+            StringBuilder out = new StringBuilder();
+            pos++; // Opening quote
+            while (true) {
+                if (pos >= s.length()) throw fail("unterminated string");
+                char c = s.charAt(pos++);
+                if (c == '"') return out.toString();
+                if (c != '\\') {
+                    out.append(c);
+                    continue;
+                }
+                if (pos >= s.length()) throw fail("unterminated string");
+                char e = s.charAt(pos++);
+                switch (e) {
+                    case 'n': out.append('\n'); break;
+                    case 't': out.append('\t'); break;
+                    case 'r': out.append('\r'); break;
+                    case 'b': out.append('\b'); break;
+                    case 'f': out.append('\f'); break;
+                    case 'u':
+                        try {
+                            out.append((char) Integer.parseInt(s.substring(pos, pos + 4), 16));
+                        } catch (RuntimeException ex) {
+                            throw fail("bad \\u escape");
+                        }
+                        pos += 4;
+                        break;
+                    default: out.append(e);
+                }
+            }
+        }
+    }
+}
+`
+
+// harnessRuntime is the start of class Main: readers and writers for the
+// types that are not arrays or lists. Those are generated per signature,
+// since Java's generics cannot tell List<Integer> from List<String> at run
+// time.
+const harnessRuntime = `
+class Main {
+    static IllegalArgumentException fail(String message) {
+        return new IllegalArgumentException(message);
+    }
+
+    // Reads the parameter name from the next input line with reader
+    static <T> T argument(BufferedReader in, String name, java.util.function.Function<Object, T> reader) throws IOException {
+        String line = in.readLine();
+        if (line == null) throw fail("missing value for " + name);
+        try {
+            return reader.apply(Structures.parse(line));
+        } catch (IllegalArgumentException e) {
+            throw fail(name + ": " + e.getMessage());
+        }
+    }
+
+    static List<?> array(Object o) {
+        return Structures.array(o);
+    }
+
+    static long readLong(Object o) {
+        if (o instanceof java.math.BigInteger) throw fail("integer out of range: " + o);
+        if (!(o instanceof Long)) throw fail("expected an integer");
+        return (Long) o;
+    }
+
+    static int readInt(Object o) {
+        long n = readLong(o);
+        if (n < Integer.MIN_VALUE || n > Integer.MAX_VALUE) throw fail("integer out of range: " + n);
+        return (int) n;
+    }
+
+    static double readDouble(Object o) {
+        if (!(o instanceof Number)) throw fail("expected a number");
+        return ((Number) o).doubleValue();
+    }
+
+    static boolean readBool(Object o) {
+        if (!(o instanceof Boolean)) throw fail("expected true or false");
+        return (Boolean) o;
+    }
+
+    static String readString(Object o) {
+        if (!(o instanceof String)) throw fail("expected a string");
+        return (String) o;
+    }
+
+    static ListNode readListNode(Object o) { return Structures.listFromJson(o); }
+    static TreeNode readTreeNode(Object o) { return Structures.treeFromJson(o); }
+    static GraphNode readGraphNode(Object o) { return Structures.graphFromJson(o); }
+
+    static void writeInt(StringBuilder out, int v) { out.append(v); }
+    static void writeLong(StringBuilder out, long v) { out.append(v); }
+    static void writeBool(StringBuilder out, boolean v) { out.append(v); }
+
+    static void writeDouble(StringBuilder out, double v) {
+        out.append(String.format(Locale.ROOT, "%.5f", v));
+    }
+
+    static void writeString(StringBuilder out, String v) {
+        if (v == null) out.append("null");
+        else Structures.quote(out, v);
+    }
+
+    static void writeListNode(StringBuilder out, ListNode v) { out.append(Structures.listToJson(v)); }
+    static void writeTreeNode(StringBuilder out, TreeNode v) { out.append(Structures.treeToJson(v)); }
+    static void writeGraphNode(StringBuilder out, GraphNode v) { out.append(Structures.graphToJson(v)); }
+`
+
+// generateHarness builds the program that runs the user's code against a
+// canonical test input: the user's code defines class Solution with a method
+// matching sig, which is called with one argument read from each input line.
+func generateHarness(code string, sig runner.Signature) (string, error) {
+	params, ret, err := sig.Types()
+	if err != nil {
+		return "", err
+	}
+
+	h := javaHarness{done: make(map[string]bool)}
+	var main strings.Builder
+	main.WriteString("\n    public static void main(String[] args) throws IOException {\n")
+	main.WriteString("        BufferedReader in = new BufferedReader(new InputStreamReader(System.in, StandardCharsets.UTF_8));\n")
+	args := make([]string, len(params))
+	for i, t := range params {
+		args[i] = fmt.Sprintf("arg%d", i)
+		fmt.Fprintf(&main, "        %s %s;\n", javaType(t, false), args[i])
+	}
+	main.WriteString("        try {\n")
+	for i, t := range params {
+		fmt.Fprintf(&main, "            %s = argument(in, %q, Main::%s);\n", args[i], sig.Params[i].Name, h.reader(t))
+	}
+	main.WriteString("        } catch (IllegalArgumentException e) {\n")
+	main.WriteString("            System.err.println(\"invalid test input: \" + e.getMessage());\n")
+	main.WriteString("            System.exit(1);\n")
+	main.WriteString("            return;\n")
+	main.WriteString("        }\n")
+	fmt.Fprintf(&main, "        %s result = new Solution().%s(%s);\n", javaType(ret, false), sig.FunctionName, strings.Join(args, ", "))
+	main.WriteString("        StringBuilder out = new StringBuilder();\n")
+	fmt.Fprintf(&main, "        %s(out, result);\n", h.writer(ret))
+	main.WriteString("        System.out.flush();\n")
+	main.WriteString("        PrintStream stdout = new PrintStream(new FileOutputStream(FileDescriptor.out), true, \"UTF-8\");\n")
+	main.WriteString("        stdout.println(out);\n    }\n}\n")
+
+	return harnessImports + code + "\n" + harnessNodes + structuresClass + harnessRuntime + h.methods.String() + main.String(), nil
+}
+
+// javaHarness collects the reader and writer methods generated for the array
+// and list types of a signature.
+type javaHarness struct {
+	methods strings.Builder
+	done    map[string]bool
+}
+
+// reader returns the name of the method that converts parsed JSON to a value
+// of type t, generating it if needed.
+func (h *javaHarness) reader(t *runner.Type) string {
+	name := "read" + javaTypeID(t)
+	if !t.Container() || h.done[name] {
+		return name
+	}
+	h.done[name] = true
+	elem := h.reader(t.Elem)
+	typ := javaType(t, false)
+	if t.Kind == runner.KindArray {
+		fmt.Fprintf(&h.methods, `
+    static %s %s(Object o) {
+        List<?> items = array(o);
+        %s a = %s;
+        for (int i = 0; i < a.length; i++) a[i] = %s(items.get(i));
+        return a;
+    }
+`, typ, name, typ, newJavaArray(t.Elem, "items.size()"), elem)
+	} else {
+		fmt.Fprintf(&h.methods, `
+    static %s %s(Object o) {
+        %s a = new ArrayList<>();
+        for (Object item : array(o)) a.add(%s(item));
+        return a;
+    }
+`, typ, name, typ, elem)
+	}
+	return name
+}
+
+// writer returns the name of the method that writes a value of type t as
+// JSON, generating it if needed.
+func (h *javaHarness) writer(t *runner.Type) string {
+	name := "write" + javaTypeID(t)
+	if !t.Container() || h.done[name] {
+		return name
+	}
+	h.done[name] = true
+	elem := h.writer(t.Elem)
+	loop := "for (int i = 0; i < v.length; i++) {\n            if (i > 0) out.append(',');\n            " + elem + "(out, v[i]);\n        }"
+	if t.Kind == runner.KindList {
+		loop = "for (int i = 0; i < v.size(); i++) {\n            if (i > 0) out.append(',');\n            " + elem + "(out, v.get(i));\n        }"
+	}
+	fmt.Fprintf(&h.methods, `
+    static void %s(StringBuilder out, %s v) {
+        if (v == null) {
+            out.append("null");
+            return;
+        }
+        out.append('[');
+        %s
+        out.append(']');
+    }
+`, name, javaType(t, false), loop)
+	return name
+}
+
+// javaType is the Java type a value of type t is held in. Lists need boxed
+// element types.
+func javaType(t *runner.Type, boxed bool) string {
+	switch t.Kind {
+	case runner.KindInt:
+		if boxed {
+			return "Integer"
+		}
+		return "int"
+	case runner.KindLong:
+		if boxed {
+			return "Long"
+		}
+		return "long"
+	case runner.KindDouble:
+		if boxed {
+			return "Double"
+		}
+		return "double"
+	case runner.KindBool:
+		if boxed {
+			return "Boolean"
+		}
+		return "boolean"
+	case runner.KindString:
+		return "String"
+	case runner.KindListNode:
+		return "ListNode"
+	case runner.KindTreeNode:
+		return "TreeNode"
+	case runner.KindGraphNode:
+		return "GraphNode"
+	case runner.KindArray:
+		return javaType(t.Elem, false) + "[]"
+	default:
+		return "List<" + javaType(t.Elem, true) + ">"
+	}
+}
+
+// javaTypeID names t in generated method names, e.g. IntArrayList for
+// List<int[]>.
+func javaTypeID(t *runner.Type) string {
+	switch t.Kind {
+	case runner.KindArray:
+		return javaTypeID(t.Elem) + "Array"
+	case runner.KindList:
+		return javaTypeID(t.Elem) + "List"
+	case runner.KindListNode, runner.KindTreeNode, runner.KindGraphNode:
+		return string(t.Kind)
+	default:
+		return strings.ToUpper(string(t.Kind[:1])) + string(t.Kind[1:])
+	}
+}
+
+// newJavaArray is the expression creating an array of n elements of type
+// elem. Java cannot create arrays of generic types, so those are created raw
+// and cast.
+func newJavaArray(elem *runner.Type, n string) string {
+	typ := javaType(elem, false)
+	base, dims := typ, ""
+	// Brackets inside a generic's arguments, as in List<int[]>, are not dims
+	if i := strings.Index(typ[strings.LastIndex(typ, ">")+1:], "["); i >= 0 {
+		i += strings.LastIndex(typ, ">") + 1
+		base, dims = typ[:i], typ[i:]
+	}
+	if i := strings.Index(base, "<"); i >= 0 {
+		return fmt.Sprintf("(%s[]) new %s[%s]%s", typ, base[:i], n, dims)
+	}
+	return fmt.Sprintf("new %s[%s]%s", base, n, dims)
+}
+
+// writeStructures saves harnessNodes and structuresClass as Structures.java
+// in dir, for complete programs to be compiled with.
+func writeStructures(dir string) error {
+	return os.WriteFile(filepath.Join(dir, structuresFile), []byte(harnessImports+harnessNodes+structuresClass), 0644)
+}
@@ -8,11 +8,11 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"text/template"
 	"time"
+
+	"runner"
 )
 
 type ExecRequest struct {
@@ -23,19 +23,47 @@ type ExecRequest struct {
 	Language      string `json:"language"`
 	FunctionName  string `json:"function_name"`
 	Parser        string `json:"parser"` // Parser code provided by the backend
+	// Signature, when set, replaces Parser: the executor generates the harness
+	// and Input is in the canonical format, see runner.Signature
+	Signature *runner.Signature `json:"signature,omitempty"`
+	// Interactor, when set, makes this an interactive run: Code is a complete
+	// program talking to the interactor, and Input is given to the interactor
+	Interactor *runner.Interactor `json:"interactor,omitempty"`
+	// Complete, when set, runs Code as a complete program with a public class
+	// Main, as custom checkers are, rather than wrapping it. It is compiled
+	// with Structures.java, see writeStructures
+	Complete bool `json:"complete,omitempty"`
+}
+
+// BatchRequest runs one program against many tests, so compiled languages are
+// compiled once rather than once per test.
+type BatchRequest struct {
+	Code          string             `json:"code"`
+	Language      string             `json:"language"`
+	FunctionName  string             `json:"function_name"`
+	Parser        string             `json:"parser"`
+	Signature     *runner.Signature  `json:"signature,omitempty"`
+	Tests         []runner.BatchTest `json:"tests"`
+	StopOnFailure bool               `json:"stop_on_failure"` // Start no more tests once one has failed to run cleanly
+	Parallelism   int                `json:"parallelism"`     // Tests run at once, 1 if unset
 }
 
-type ExecResult struct {
-	Output          string `json:"output"`
-	ExecutionTimeMs int    `json:"execution_time_ms"`
-	MemoryUsedKB    int    `json:"memory_used_kb"`
-	Status          string `json:"status"`
+// info describes the executor on GET /info.
+var info = runner.Info{
+	Language:       "java",
+	DisplayName:    "Java",
+	Template:       "class Solution {\n}\n",
+	TimeMultiplier: 2,
 }
 
 func main() {
-	http.HandleFunc("/execute", execHandler)
-	log.Println("☕ Java-executor listening on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	runner.Init()
+
+	http.HandleFunc("/execute", runner.WithVersion(execHandler))
+	http.HandleFunc("/execute/batch", runner.WithVersion(batchHandler))
+	http.HandleFunc("/info", runner.WithVersion(runner.InfoHandler(info, "javac", "-version")))
+	log.Printf("☕ Java-executor listening on %s", runner.Addr())
+	log.Fatal(http.ListenAndServe(runner.Addr(), nil))
 }
 
 func execHandler(w http.ResponseWriter, r *http.Request) {
@@ -45,10 +73,15 @@ func execHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(req.TimeLimitMs)*time.Millisecond)
-	defer cancel()
-
-	start := time.Now()
+	if req.Interactor != nil {
+		if req.Interactor.Language != "python" {
+			http.Error(w, fmt.Sprintf("unsupported interactor language %q", req.Interactor.Language), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(runInteractive(r.Context(), req))
+		return
+	}
 
 	wrappedCode, err := wrapJavaCode(req)
 	if err != nil {
@@ -56,62 +89,212 @@ func execHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	out, status := runCode(ctx, wrappedCode, req.Input)
-	execMs := int(time.Since(start).Milliseconds())
-
-	res := ExecResult{
-		Output:          out,
-		Status:          status,
-		ExecutionTimeMs: execMs,
-		MemoryUsedKB:    0,
+	file := userSourceFile
+	if req.Complete {
+		file = "Main.java"
 	}
+	sourceMap := runner.NewSourceMap(file, wrappedCode, req.Code)
+	res := runCode(r.Context(), wrappedCode, sourceMap, req.Input, req.TimeLimitMs, req.MemoryLimitKB)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(res)
 }
 
-func runCode(ctx context.Context, code, input string) (output, status string) {
+// runCode compiles code and runs it against one input. sourceMap places
+// compiler diagnostics in the user's code.
+func runCode(ctx context.Context, code string, sourceMap runner.SourceMap, input string, timeLimitMs, memoryLimitKB int) runner.ExecResult {
 	log.Println("Running code...")
 	dir, _ := os.MkdirTemp("", "exec-*")
 	defer os.RemoveAll(dir)
 
-	source := filepath.Join(dir, "Main.java")
-	_ = os.WriteFile(source, []byte(code), 0644)
+	compileOut, err := compile(ctx, dir, sourceMap.File, code)
+	if err != nil {
+		return compileError(compileOut, sourceMap)
+	}
+	out, status, usage := runClass(ctx, dir, input, timeLimitMs, memoryLimitKB)
+	return runner.ExecResult{
+		Output:          out,
+		Status:          status,
+		ExecutionTimeMs: usage.CPUTimeMs,
+		WallTimeMs:      usage.WallTimeMs,
+		MemoryUsedKB:    usage.MemoryUsedKB,
+	}
+}
 
-	// Compile
-	compileCmd := exec.Command("javac", source)
-	var compileOut bytes.Buffer
-	compileCmd.Stderr = &compileOut
-	if err := compileCmd.Run(); err != nil {
-		return compileOut.String(), "compilation_error"
+// runClass runs the compiled class Main in dir against one input.
+func runClass(ctx context.Context, dir, input string, timeLimitMs, memoryLimitKB int) (output, status string, usage runner.Usage) {
+	res := runner.Run(ctx, input, solutionLimits(dir, timeLimitMs), "java", javaArgs(memoryLimitKB, dir)...)
+	if output, status, failed := runner.Failure(res, outOfMemory); failed {
+		return output, status, res.Usage
 	}
 
-	// Run
-	cmd := exec.CommandContext(ctx, "java", "-cp", dir, "Main")
-	if input != "" {
-		cmd.Stdin = strings.NewReader(input)
+	if res.Stderr != "" {
+		return res.Stderr, "runtime_error", res.Usage
 	}
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout, cmd.Stderr = &stdout, &stderr
 
-	if err := cmd.Run(); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "time limit exceeded", "time_limit_exceeded"
-		}
-		if stderr.Len() > 0 {
-			return stderr.String(), "runtime_error"
+	return res.Stdout, "success", res.Usage
+}
+
+// batchHandler compiles the code of a BatchRequest once and runs it against
+// each of its tests.
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wrappedCode, err := wrapJavaCode(ExecRequest{Code: req.Code, FunctionName: req.FunctionName, Parser: req.Parser, Signature: req.Signature})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to wrap code: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Running batch of %d tests...", len(req.Tests))
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
+	compileOut, err := compile(r.Context(), dir, userSourceFile, wrappedCode)
+	if err != nil {
+		// Every test would fail the same way, so only the first is reported
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(runner.BatchResult{Results: []runner.ExecResult{
+			compileError(compileOut, runner.NewSourceMap(userSourceFile, wrappedCode, req.Code)),
+		}})
+		return
+	}
+
+	results := make([]runner.ExecResult, len(req.Tests))
+	ran := runner.Batch(len(req.Tests), req.Parallelism, req.StopOnFailure, func(i int) bool {
+		test := req.Tests[i]
+		out, status, usage := runClass(r.Context(), dir, test.Input, test.TimeLimitMs, test.MemoryLimitKB)
+		results[i] = runner.ExecResult{
+			Output:          out,
+			Status:          status,
+			ExecutionTimeMs: usage.CPUTimeMs,
+			WallTimeMs:      usage.WallTimeMs,
+			MemoryUsedKB:    usage.MemoryUsedKB,
 		}
-		return err.Error(), "runtime_error"
+		return status != "success"
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(runner.BatchResult{Results: results[:ran]})
+}
+
+// runInteractive runs req.Code against req.Interactor, which gets req.Input.
+// The code is a complete program declaring class Main and is not wrapped.
+func runInteractive(ctx context.Context, req ExecRequest) runner.ExecResult {
+	log.Println("Running interactive code...")
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
+
+	compileOut, err := compile(ctx, dir, "Main.java", req.Code)
+	if err != nil {
+		return compileError(compileOut, runner.NewSourceMap("Main.java", req.Code, req.Code))
+	}
+	// Kept out of dir, which the sandboxed solution can read
+	interactorDir, _ := os.MkdirTemp("", "interactor-*")
+	defer os.RemoveAll(interactorDir)
+	interactor := filepath.Join(interactorDir, "interactor.py")
+	_ = os.WriteFile(interactor, []byte(req.Interactor.Code), 0644)
+
+	res := runner.Interact(ctx, runner.Interaction{
+		Input:            req.Input,
+		Solution:         runner.Command{Name: "java", Args: javaArgs(req.MemoryLimitKB, dir)},
+		SolutionLimits:   solutionLimits(dir, req.TimeLimitMs),
+		Interactor:       runner.Command{Name: "python3", Args: []string{interactor}},
+		InteractorLimits: runner.Limits{CPUTime: time.Duration(req.Interactor.TimeLimitMs) * time.Millisecond},
+		QueryLimit:       req.Interactor.QueryLimit,
+	})
+	if res.Interactor.Stderr != "" {
+		log.Printf("Interactor stderr: %s", res.Interactor.Stderr)
 	}
 
-	if stderr.Len() > 0 {
-		return stderr.String(), "runtime_error"
+	output, status := "", "success"
+	if o, s, failed := runner.Failure(res.Solution, outOfMemory); failed {
+		output, status = o, s
 	}
+	output, status = res.Judge(output, status)
 
-	return stdout.String(), "success"
+	return runner.ExecResult{
+		Output:            output,
+		Status:            status,
+		ExecutionTimeMs:   res.Solution.CPUTimeMs,
+		WallTimeMs:        res.Solution.WallTimeMs,
+		MemoryUsedKB:      res.Solution.MemoryUsedKB,
+		Transcript:        res.Transcript,
+		InteractorMessage: res.Message,
+	}
+}
+
+// userSourceFile is the file wrapped code is compiled from, which is also
+// the name diagnostics in the user's code are reported under.
+const userSourceFile = "Solution.java"
+
+// structuresFile holds the node classes and class Structures for complete
+// programs.
+const structuresFile = "Structures.java"
+
+// compileError is the result for code that failed to compile with output.
+// sourceMap places the compiler's diagnostics in the user's code.
+func compileError(output string, sourceMap runner.SourceMap) runner.ExecResult {
+	return runner.ExecResult{
+		Output:      output,
+		Status:      "compile_error",
+		Diagnostics: sourceMap.Map(runner.ParseJavacDiagnostics(output)),
+	}
+}
+
+// compile compiles code saved as file into dir, returning the compiler's
+// output if it fails. Wrapped code is saved as userSourceFile with a
+// package-private class Main, so a user's public class Solution compiles;
+// complete programs need their public class Main in Main.java, and are
+// compiled with Structures.java. The harness has its own copy of it. javac
+// runs sandboxed, within runner.CompileLimits, with its heap capped by -J-Xmx.
+func compile(ctx context.Context, dir, file, code string) (output string, err error) {
+	source := filepath.Join(dir, file)
+	_ = os.WriteFile(source, []byte(code), 0644)
+
+	args := []string{fmt.Sprintf("-J-Xmx%dk", runner.CompileMemoryLimitKB), source}
+	if file != userSourceFile {
+		_ = writeStructures(dir)
+		args = append(args, filepath.Join(dir, structuresFile))
+	}
+	if output, err := runner.Compile(ctx, runner.CompileLimits(dir, false), "javac", args...); err != nil {
+		return output, err
+	}
+	return "", nil
+}
+
+// solutionLimits limits only CPU time: the JVM reserves far more address space
+// than it uses, so the heap is capped with -Xmx rather than RLIMIT_AS and a
+// full heap shows up as OutOfMemoryError. The run of the classes in dir is
+// sandboxed.
+func solutionLimits(dir string, timeLimitMs int) runner.Limits {
+	return runner.Limits{CPUTime: time.Duration(timeLimitMs) * time.Millisecond, SandboxDir: dir}
+}
+
+// outOfMemory is on stderr when the heap is full.
+const outOfMemory = "java.lang.OutOfMemoryError"
+
+// javaArgs returns the arguments that run class Main from dir with its heap
+// capped at memoryLimitKB, or uncapped when it is 0.
+func javaArgs(memoryLimitKB int, dir string) []string {
+	args := []string{"-cp", dir, "Main"}
+	if memoryLimitKB > 0 {
+		args = append([]string{fmt.Sprintf("-Xmx%dk", memoryLimitKB)}, args...)
+	}
+	return args
 }
 
 func wrapJavaCode(req ExecRequest) (string, error) {
+	if req.Complete {
+		return req.Code, nil
+	}
+	if req.Signature != nil {
+		return generateHarness(req.Code, *req.Signature)
+	}
+
 	// Use provided parser or fallback to default
 	parserCode := req.Parser
 	if parserCode == "" {
@@ -189,7 +372,7 @@ func wrapJavaCode(req ExecRequest) (string, error) {
 	const tpl = `
 import java.util.*;
 
-public class Main {
+class Main {
     {{.Code}}
 
     {{.ParserCode}}
@@ -1,3 +1,7 @@
 module js_executor
 
 go 1.22
+
+require runner v0.0.0
+
+replace runner => ../runner
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"runner"
+)
+
+// harnessPrelude comes before the user's code: the node types LeetCode-style
+// solutions expect to find already defined.
+const harnessPrelude = `function ListNode(val, next) { this.val = (val === undefined ? 0 : val); this.next = (next === undefined ? null : next); }
+function TreeNode(val, left, right) { this.val = (val === undefined ? 0 : val); this.left = (left === undefined ? null : left); this.right = (right === undefined ? null : right); }
+function GraphNode(val, neighbors) { this.val = (val === undefined ? 0 : val); this.neighbors = (neighbors === undefined ? [] : neighbors); }
+`
+
+// structuresFunctions convert the node types from and to canonical JSON. The
+// fromJson functions take parsed JSON and throw for values that do not fit;
+// the toJson functions return canonical JSON text. With harnessPrelude they
+// make up structures.js, see writeStructures.
+const structuresFunctions = `
+function nodeValue(item) {
+    if (!Number.isInteger(item) || item < -2147483648 || item > 2147483647) throw new Error('expected a 32-bit integer node value');
+    return item;
+}
+
+function listFromJson(value) {
+    if (!Array.isArray(value)) throw new Error('expected an array');
+    const dummy = new ListNode();
+    let tail = dummy;
+    for (const item of value) {
+        tail.next = new ListNode(nodeValue(item));
+        tail = tail.next;
+    }
+    return dummy.next;
+}
+
+function listToJson(head) {
+    const values = [];
+    for (let n = head; n; n = n.next) values.push(n.val);
+    return JSON.stringify(values);
+}
+
+// Level order, with null for missing children: [1,null,2,3]
+function treeFromJson(value) {
+    if (!Array.isArray(value)) throw new Error('expected an array');
+    const node = item => item === null ? null : new TreeNode(nodeValue(item));
+    if (value.length === 0 || value[0] === null) return null;
+    const root = node(value[0]);
+    const parents = [root];
+    let i = 1;
+    for (let p = 0; p < parents.length && i < value.length; p++) {
+        const parent = parents[p];
+        parent.left = node(value[i++]);
+        if (parent.left) parents.push(parent.left);
+        if (i < value.length) {
+            parent.right = node(value[i++]);
+            if (parent.right) parents.push(parent.right);
+        }
+    }
+    return root;
+}
+
+function treeToJson(root) {
+    const level = [];
+    const pending = root ? [root] : [];
+    for (let i = 0; i < pending.length; i++) {
+        const n = pending[i];
+        level.push(n ? n.val : null);
+        if (n) pending.push(n.left, n.right);
+    }
+    while (level.length > 0 && level[level.length - 1] === null) level.pop();
+    return JSON.stringify(level);
+}
+
+// The adjacency list: node i+1 has value i+1 and its neighbors' values at
+// index i, as in [[2,3],[1],[1]]. Returns node 1, or null for [].
+function graphFromJson(value) {
+    if (!Array.isArray(value)) throw new Error('expected an array');
+    const nodes = value.map((_, i) => new GraphNode(i + 1));
+    value.forEach((neighbors, i) => {
+        if (!Array.isArray(neighbors)) throw new Error('expected an array of neighbors');
+        for (const item of neighbors) {
+            if (!Number.isInteger(item) || item < 1 || item > nodes.length) throw new Error('neighbor ' + JSON.stringify(item) + ' is not a node of the graph');
+            nodes[i].neighbors.push(nodes[item - 1]);
+        }
+    });
+    return nodes.length > 0 ? nodes[0] : null;
+}
+
+// The adjacency list of the nodes reachable from node, in the order of their values
+function graphToJson(node) {
+    const seen = new Set();
+    const pending = node ? [node] : [];
+    while (pending.length > 0) {
+        const n = pending.pop();
+        if (seen.has(n)) continue;
+        seen.add(n);
+        pending.push(...n.neighbors);
+    }
+    const nodes = Array.from(seen).sort((a, b) => a.val - b.val);
+    return JSON.stringify(nodes.map(n => n.neighbors.map(m => m.val)));
+}
+`
+
+// harnessRuntime comes after the user's code. It converts each input line to
+// the parameter's type and the result to canonical JSON, guided by the types
+// of the signature (runner.Type as JSON). It is wrapped in a function so its
+// names cannot clash with the user's, and starts with a semicolon in case the
+// user's code does not end with one.
+const harnessRuntime = `
+;(function (solve, paramNames, paramTypes, returnType) {
+` + structuresFunctions + `
+    const nodeReaders = { ListNode: listFromJson, TreeNode: treeFromJson, GraphNode: graphFromJson };
+    const nodeWriters = { ListNode: listToJson, TreeNode: treeToJson, GraphNode: graphToJson };
+
+    function read(value, type) {
+        switch (type.kind) {
+        case 'int':
+        case 'long':
+            if (!Number.isInteger(value)) throw new Error('expected an integer');
+            if (type.kind === 'int' && (value < -2147483648 || value > 2147483647)) throw new Error('integer out of range: ' + value);
+            return value;
+        case 'double':
+            if (typeof value !== 'number') throw new Error('expected a number');
+            return value;
+        case 'bool':
+            if (typeof value !== 'boolean') throw new Error('expected true or false');
+            return value;
+        case 'string':
+            if (typeof value !== 'string') throw new Error('expected a string');
+            return value;
+        case 'array':
+        case 'list':
+            if (!Array.isArray(value)) throw new Error('expected an array');
+            return value.map(item => read(item, type.elem));
+        default:
+            return nodeReaders[type.kind](value);
+        }
+    }
+
+    function write(value, type) {
+        if (type.kind in nodeWriters) return nodeWriters[type.kind](value);
+        if (value === null || value === undefined) return 'null';
+        switch (type.kind) {
+        case 'double':
+            return value.toFixed(5);
+        case 'array':
+        case 'list':
+            return '[' + Array.from(value, item => write(item, type.elem)).join(',') + ']';
+        default:
+            return JSON.stringify(value);
+        }
+    }
+
+    function fail(message) {
+        process.stderr.write('invalid test input: ' + message + '\n');
+        process.exit(1);
+    }
+
+    const lines = require('fs').readFileSync(0, 'utf-8').split('\n');
+    const args = paramTypes.map((type, i) => {
+        if (i >= lines.length || lines[i].trim() === '') fail('missing value for ' + paramNames[i]);
+        try {
+            return read(JSON.parse(lines[i]), type);
+        } catch (e) {
+            fail(paramNames[i] + ': ' + e.message);
+        }
+    });
+    console.log(write(solve(...args), returnType));
+})(`
+
+// generateHarness builds the program that runs the user's code against a
+// canonical test input: the user's code defines a function matching sig,
+// which is called with one argument read from each input line. Numbers are
+// JavaScript numbers, so longs beyond 2^53 lose precision.
+func generateHarness(code string, sig runner.Signature) (string, error) {
+	params, ret, err := sig.Types()
+	if err != nil {
+		return "", err
+	}
+	if params == nil {
+		params = []*runner.Type{} // [] rather than null for functions without parameters
+	}
+	names := make([]string, len(sig.Params))
+	for i, p := range sig.Params {
+		names[i] = p.Name
+	}
+
+	args, err := json.Marshal([]interface{}{names, params, ret})
+	if err != nil {
+		return "", err
+	}
+	// The arguments are a JSON array, so its brackets are dropped to pass
+	// them one by one
+	return fmt.Sprintf("%s%s\n%s%s, %s);\n", harnessPrelude, code, harnessRuntime, sig.FunctionName, args[1:len(args)-1]), nil
+}
+
+// writeStructures saves the node types and their conversions as
+// structures.js in dir, so the program run from there can require it.
+func writeStructures(dir string) error {
+	library := harnessPrelude + structuresFunctions + `
+module.exports = { ListNode, TreeNode, GraphNode, listFromJson, listToJson, treeFromJson, treeToJson, graphFromJson, graphToJson };
+`
+	return os.WriteFile(filepath.Join(dir, "structures.js"), []byte(library), 0644)
+}
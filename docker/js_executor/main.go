@@ -8,11 +8,11 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"text/template"
 	"time"
+
+	"runner"
 )
 
 type ExecRequest struct {
@@ -23,19 +23,47 @@ type ExecRequest struct {
 	Language      string `json:"language"`
 	FunctionName  string `json:"function_name"`
 	Parser        string `json:"parser"` // Parser code provided by the backend
+	// Signature, when set, replaces Parser: the executor generates the harness
+	// and Input is in the canonical format, see runner.Signature
+	Signature *runner.Signature `json:"signature,omitempty"`
+	// Complete, when set, runs Code as the complete program it is, as custom
+	// checkers are, instead of wrapping it. It can require ./structures.js,
+	// see writeStructures
+	Complete bool `json:"complete,omitempty"`
+	// Interactor, when set, makes this an interactive run: Code is a complete
+	// program talking to the interactor, and Input is given to the interactor
+	Interactor *runner.Interactor `json:"interactor,omitempty"`
+}
+
+// BatchRequest runs one program against many tests, so compiled languages are
+// compiled once rather than once per test.
+type BatchRequest struct {
+	Code          string             `json:"code"`
+	Language      string             `json:"language"`
+	FunctionName  string             `json:"function_name"`
+	Parser        string             `json:"parser"`
+	Signature     *runner.Signature  `json:"signature,omitempty"`
+	Tests         []runner.BatchTest `json:"tests"`
+	StopOnFailure bool               `json:"stop_on_failure"` // Start no more tests once one has failed to run cleanly
+	Parallelism   int                `json:"parallelism"`     // Tests run at once, 1 if unset
 }
 
-type ExecResult struct {
-	Output          string `json:"output"`
-	ExecutionTimeMs int    `json:"execution_time_ms"`
-	MemoryUsedKB    int    `json:"memory_used_kb"`
-	Status          string `json:"status"`
+// info describes the executor on GET /info.
+var info = runner.Info{
+	Language:       "javascript",
+	DisplayName:    "JavaScript",
+	Template:       "function solve() {\n}\n",
+	TimeMultiplier: 1,
 }
 
 func main() {
-	http.HandleFunc("/execute", execHandler)
-	log.Println("🟢 JS-executor listening on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	runner.Init()
+
+	http.HandleFunc("/execute", runner.WithVersion(execHandler))
+	http.HandleFunc("/execute/batch", runner.WithVersion(batchHandler))
+	http.HandleFunc("/info", runner.WithVersion(runner.InfoHandler(info, "node", "--version")))
+	log.Printf("🟢 JS-executor listening on %s", runner.Addr())
+	log.Fatal(http.ListenAndServe(runner.Addr(), nil))
 }
 
 func execHandler(w http.ResponseWriter, r *http.Request) {
@@ -45,10 +73,15 @@ func execHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(req.TimeLimitMs)*time.Millisecond)
-	defer cancel()
-
-	start := time.Now()
+	if req.Interactor != nil {
+		if req.Interactor.Language != "python" {
+			http.Error(w, fmt.Sprintf("unsupported interactor language %q", req.Interactor.Language), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(runInteractive(r.Context(), req))
+		return
+	}
 
 	wrappedCode, err := wrapJSCode(req)
 	if err != nil {
@@ -56,54 +89,155 @@ func execHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	out, status := runCode(ctx, wrappedCode, req.Input)
-	execMs := int(time.Since(start).Milliseconds())
+	out, status, usage := runCode(r.Context(), wrappedCode, req.Input, req.TimeLimitMs, req.MemoryLimitKB)
 
-	res := ExecResult{
+	res := runner.ExecResult{
 		Output:          out,
 		Status:          status,
-		ExecutionTimeMs: execMs,
-		MemoryUsedKB:    0,
+		ExecutionTimeMs: usage.CPUTimeMs,
+		WallTimeMs:      usage.WallTimeMs,
+		MemoryUsedKB:    usage.MemoryUsedKB,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(res)
 }
 
-func runCode(ctx context.Context, code, input string) (output, status string) {
+func runCode(ctx context.Context, code, input string, timeLimitMs, memoryLimitKB int) (output, status string, usage runner.Usage) {
 	log.Println("Running code...")
 	dir, _ := os.MkdirTemp("", "exec-*")
 	defer os.RemoveAll(dir)
 
 	script := filepath.Join(dir, "script.js")
 	_ = os.WriteFile(script, []byte(code), 0644)
+	_ = writeStructures(dir)
+	return runScript(ctx, script, input, timeLimitMs, memoryLimitKB)
+}
 
-	cmd := exec.CommandContext(ctx, "node", script)
-	if input != "" {
-		cmd.Stdin = strings.NewReader(input)
+// runScript runs script against one input.
+func runScript(ctx context.Context, script, input string, timeLimitMs, memoryLimitKB int) (output, status string, usage runner.Usage) {
+	res := runner.Run(ctx, input, solutionLimits(filepath.Dir(script), timeLimitMs), "node", nodeArgs(memoryLimitKB, script)...)
+	if output, status, failed := runner.Failure(res, outOfMemory); failed {
+		return output, status, res.Usage
 	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if res.Stderr != "" {
+		return res.Stderr, "runtime_error", res.Usage
+	}
 
-	if err := cmd.Run(); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "time limit exceeded", "time_limit_exceeded"
-		}
-		if stderr.Len() > 0 {
-			return stderr.String(), "runtime_error"
+	return res.Stdout, "success", res.Usage
+}
+
+// batchHandler runs the code of a BatchRequest against each of its tests.
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wrappedCode, err := wrapJSCode(ExecRequest{Code: req.Code, FunctionName: req.FunctionName, Parser: req.Parser, Signature: req.Signature})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to wrap code: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Running batch of %d tests...", len(req.Tests))
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
+	script := filepath.Join(dir, "script.js")
+	_ = os.WriteFile(script, []byte(wrappedCode), 0644)
+	_ = writeStructures(dir)
+
+	results := make([]runner.ExecResult, len(req.Tests))
+	ran := runner.Batch(len(req.Tests), req.Parallelism, req.StopOnFailure, func(i int) bool {
+		test := req.Tests[i]
+		out, status, usage := runScript(r.Context(), script, test.Input, test.TimeLimitMs, test.MemoryLimitKB)
+		results[i] = runner.ExecResult{
+			Output:          out,
+			Status:          status,
+			ExecutionTimeMs: usage.CPUTimeMs,
+			WallTimeMs:      usage.WallTimeMs,
+			MemoryUsedKB:    usage.MemoryUsedKB,
 		}
-		return err.Error(), "runtime_error"
+		return status != "success"
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(runner.BatchResult{Results: results[:ran]})
+}
+
+// runInteractive runs req.Code against req.Interactor, which gets req.Input.
+// The code is a complete program and is not wrapped.
+func runInteractive(ctx context.Context, req ExecRequest) runner.ExecResult {
+	log.Println("Running interactive code...")
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "script.js")
+	_ = os.WriteFile(script, []byte(req.Code), 0644)
+	// Kept out of dir, which the sandboxed solution can read
+	interactorDir, _ := os.MkdirTemp("", "interactor-*")
+	defer os.RemoveAll(interactorDir)
+	interactor := filepath.Join(interactorDir, "interactor.py")
+	_ = os.WriteFile(interactor, []byte(req.Interactor.Code), 0644)
+
+	res := runner.Interact(ctx, runner.Interaction{
+		Input:            req.Input,
+		Solution:         runner.Command{Name: "node", Args: nodeArgs(req.MemoryLimitKB, script)},
+		SolutionLimits:   solutionLimits(dir, req.TimeLimitMs),
+		Interactor:       runner.Command{Name: "python3", Args: []string{interactor}},
+		InteractorLimits: runner.Limits{CPUTime: time.Duration(req.Interactor.TimeLimitMs) * time.Millisecond},
+		QueryLimit:       req.Interactor.QueryLimit,
+	})
+	if res.Interactor.Stderr != "" {
+		log.Printf("Interactor stderr: %s", res.Interactor.Stderr)
+	}
+
+	output, status := "", "success"
+	if o, s, failed := runner.Failure(res.Solution, outOfMemory); failed {
+		output, status = o, s
 	}
+	output, status = res.Judge(output, status)
 
-	if stderr.Len() > 0 {
-		return stderr.String(), "runtime_error"
+	return runner.ExecResult{
+		Output:            output,
+		Status:            status,
+		ExecutionTimeMs:   res.Solution.CPUTimeMs,
+		WallTimeMs:        res.Solution.WallTimeMs,
+		MemoryUsedKB:      res.Solution.MemoryUsedKB,
+		Transcript:        res.Transcript,
+		InteractorMessage: res.Message,
 	}
+}
+
+// solutionLimits limits only CPU time: V8 reserves far more address space than
+// it uses, so the heap is capped with --max-old-space-size rather than
+// RLIMIT_AS. The run of the script in dir is sandboxed.
+func solutionLimits(dir string, timeLimitMs int) runner.Limits {
+	return runner.Limits{CPUTime: time.Duration(timeLimitMs) * time.Millisecond, SandboxDir: dir}
+}
+
+// outOfMemory is on stderr when the heap is full.
+const outOfMemory = "heap out of memory"
 
-	return stdout.String(), "success"
+// nodeArgs returns the arguments that run script with its heap capped at
+// memoryLimitKB, or uncapped when it is 0.
+func nodeArgs(memoryLimitKB int, script string) []string {
+	if memoryLimitKB > 0 {
+		return []string{fmt.Sprintf("--max-old-space-size=%d", max(memoryLimitKB/1024, 1)), script}
+	}
+	return []string{script}
 }
 
 func wrapJSCode(req ExecRequest) (string, error) {
+	if req.Complete {
+		return req.Code, nil
+	}
+	if req.Signature != nil {
+		return generateHarness(req.Code, *req.Signature)
+	}
+
 	// Use provided parser or fallback to default
 	parserCode := req.Parser
 	if parserCode == "" {
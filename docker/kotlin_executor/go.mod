@@ -0,0 +1,7 @@
+module kotlin_executor
+
+go 1.22
+
+require runner v0.0.0
+
+replace runner => ../runner
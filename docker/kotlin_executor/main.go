@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"runner"
+)
+
+// Submissions are complete programs with a top-level main, compiled into a
+// jar with the Kotlin runtime. Like Java, the heap is capped with -Xmx.
+func main() {
+	runner.Serve(runner.Toolchain{
+		Name:       "Kotlin",
+		SourceFile: "main.kt",
+		Compile: func(dir string) runner.Command {
+			heap := fmt.Sprintf("-J-Xmx%dk", runner.CompileMemoryLimitKB)
+			return runner.Command{Name: "kotlinc", Args: []string{heap, "main.kt", "-include-runtime", "-d", "main.jar"}}
+		},
+		Diagnostics: runner.ParseGCCDiagnostics, // "main.kt:3:5: error: message"
+		Run: func(dir string, memoryLimitKB int) runner.Command {
+			args := []string{"-jar", filepath.Join(dir, "main.jar")}
+			if memoryLimitKB > 0 {
+				args = append([]string{fmt.Sprintf("-Xmx%dk", memoryLimitKB)}, args...)
+			}
+			return runner.Command{Name: "java", Args: args}
+		},
+		OutOfMemory: []string{"java.lang.OutOfMemoryError"},
+		Info: runner.Info{
+			Language:       "kotlin",
+			DisplayName:    "Kotlin",
+			Template:       template,
+			TimeMultiplier: 2,
+		},
+		VersionCommand: []string{"kotlinc", "-version"},
+	})
+}
+
+const template = `fun main() {
+    val n = readLine()!!.trim().toInt()
+    println(n)
+}
+`
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"runner"
+)
+
+// userSourceFile is the name the user's code is compiled under, so tracebacks
+// point at its own lines.
+const userSourceFile = "solution.py"
+
+// structuresLibrary defines the node types of signatures and converts them
+// from and to canonical JSON. It is saved as structures.py next to every
+// program, so custom checkers can import it, and starts the harness. The
+// from_json functions take decoded JSON and raise ValueError for values that
+// do not fit; the to_json functions return canonical JSON text.
+const structuresLibrary = `import json
+
+
+class ListNode:
+    def __init__(self, val=0, next=None):
+        self.val = val
+        self.next = next
+
+
+class TreeNode:
+    def __init__(self, val=0, left=None, right=None):
+        self.val = val
+        self.left = left
+        self.right = right
+
+
+class GraphNode:
+    def __init__(self, val=0, neighbors=None):
+        self.val = val
+        self.neighbors = neighbors if neighbors is not None else []
+
+
+def dumps(value):
+    """Returns value as compact JSON."""
+    return json.dumps(value, separators=(',', ':'), ensure_ascii=False)
+
+
+def _array(value):
+    if type(value) is not list:
+        raise ValueError('expected an array')
+    return value
+
+
+def _node_value(item):
+    if type(item) is not int or not -2 ** 31 <= item < 2 ** 31:
+        raise ValueError('expected a 32-bit integer node value')
+    return item
+
+
+def list_from_json(value):
+    """Builds a linked list from the array of its values, e.g. [1,2,3]."""
+    dummy = tail = ListNode()
+    for item in _array(value):
+        tail.next = ListNode(_node_value(item))
+        tail = tail.next
+    return dummy.next
+
+
+def list_to_json(head):
+    values = []
+    while head:
+        values.append(head.val)
+        head = head.next
+    return dumps(values)
+
+
+def tree_from_json(value):
+    """Builds a binary tree from its level order, with None for missing
+    children, e.g. [1,None,2,3]."""
+    def node(item):
+        return None if item is None else TreeNode(_node_value(item))
+    items = _array(value)
+    if not items or items[0] is None:
+        return None
+    root = node(items[0])
+    parents = [root]
+    i = 1
+    p = 0
+    while p < len(parents) and i < len(items):
+        parent = parents[p]
+        p += 1
+        parent.left = node(items[i])
+        i += 1
+        if parent.left:
+            parents.append(parent.left)
+        if i < len(items):
+            parent.right = node(items[i])
+            i += 1
+            if parent.right:
+                parents.append(parent.right)
+    return root
+
+
+def tree_to_json(root):
+    level = []
+    pending = [root] if root else []
+    for n in pending:
+        level.append(n.val if n else None)
+        if n:
+            pending.extend((n.left, n.right))
+    while level and level[-1] is None:
+        level.pop()
+    return dumps(level)
+
+
+def graph_from_json(value):
+    """Builds a graph from its adjacency list, where node i+1 has value i+1
+    and its neighbors' values at index i, e.g. [[2,3],[1],[1]]. Returns node
+    1, or None for the empty graph."""
+    lists = _array(value)
+    nodes = [GraphNode(i + 1) for i in range(len(lists))]
+    for node, neighbors in zip(nodes, lists):
+        for item in _array(neighbors):
+            if type(item) is not int or not 1 <= item <= len(nodes):
+                raise ValueError('neighbor %r is not a node of the graph' % (item,))
+            node.neighbors.append(nodes[item - 1])
+    return nodes[0] if nodes else None
+
+
+def graph_to_json(node):
+    """Returns the adjacency list of the nodes reachable from node, in the
+    order of their values."""
+    seen = {}
+    pending = [node] if node else []
+    while pending:
+        n = pending.pop()
+        if id(n) not in seen:
+            seen[id(n)] = n
+            pending.extend(n.neighbors)
+    nodes = sorted(seen.values(), key=lambda n: n.val)
+    return dumps([[m.val for m in n.neighbors] for n in nodes])
+`
+
+// harnessRuntime follows structuresLibrary in the harness. It runs the user's
+// code, given as a string, and calls the function of the signature with one
+// argument read from each input line. The arguments are converted to the
+// parameters' types and the result to canonical JSON, guided by the types of
+// the signature (runner.Type as JSON). The user's code runs in its own
+// namespace, where the node types and the names of typing are already defined.
+const harnessRuntime = `
+import sys
+import traceback
+import typing
+
+
+INT_RANGES = {'int': 2 ** 31, 'long': 2 ** 63}
+NODE_READERS = {'ListNode': list_from_json, 'TreeNode': tree_from_json, 'GraphNode': graph_from_json}
+NODE_WRITERS = {'ListNode': list_to_json, 'TreeNode': tree_to_json, 'GraphNode': graph_to_json}
+
+
+def read(value, t):
+    kind = t['kind']
+    if kind in INT_RANGES:
+        if type(value) is not int:
+            raise ValueError('expected an integer')
+        if not -INT_RANGES[kind] <= value < INT_RANGES[kind]:
+            raise ValueError('integer out of range: %d' % value)
+        return value
+    if kind == 'double':
+        if type(value) not in (int, float):
+            raise ValueError('expected a number')
+        return float(value)
+    if kind == 'bool':
+        if type(value) is not bool:
+            raise ValueError('expected true or false')
+        return value
+    if kind == 'string':
+        if type(value) is not str:
+            raise ValueError('expected a string')
+        return value
+    if kind in NODE_READERS:
+        return NODE_READERS[kind](value)
+    if type(value) is not list:
+        raise ValueError('expected an array')
+    return [read(item, t['elem']) for item in value]
+
+
+def write(value, t):
+    kind = t['kind']
+    if kind in NODE_WRITERS:
+        return NODE_WRITERS[kind](value)
+    if value is None:
+        return 'null'
+    if kind in INT_RANGES and type(value) is float and value.is_integer():
+        return str(int(value))
+    if kind == 'double' and type(value) in (int, float):
+        return '%.5f' % value
+    if kind in ('array', 'list'):
+        return '[' + ','.join(write(item, t['elem']) for item in value) + ']'
+    return dumps(value)
+
+
+def fail(message):
+    sys.stderr.write('invalid test input: ' + message + '\n')
+    sys.exit(1)
+
+
+def run(code, function_name, param_names, param_types, return_type):
+    namespace = {'__name__': 'solution', 'ListNode': ListNode, 'TreeNode': TreeNode, 'GraphNode': GraphNode}
+    namespace.update((name, getattr(typing, name)) for name in typing.__all__)
+    exec(compile(code, '` + userSourceFile + `', 'exec'), namespace)
+
+    # LeetCode-style solutions define a method of class Solution, others a function
+    solution = namespace.get('Solution')
+    if isinstance(solution, type) and hasattr(solution, function_name):
+        solve = getattr(solution(), function_name)
+    else:
+        solve = namespace.get(function_name)
+    if not callable(solve):
+        sys.stderr.write('the solution does not define ' + function_name + '\n')
+        sys.exit(1)
+
+    lines = sys.stdin.read().split('\n')
+    args = []
+    for i, t in enumerate(param_types):
+        if i >= len(lines) or not lines[i].strip():
+            fail('missing value for ' + param_names[i])
+        try:
+            args.append(read(json.loads(lines[i]), t))
+        except ValueError as e:
+            fail(param_names[i] + ': ' + str(e))
+    sys.stdout.write(write(solve(*args), return_type) + '\n')
+
+
+def main(code, signature):
+    try:
+        run(code, *json.loads(signature))
+    except Exception:
+        # Only show the frames of the user's code, if the error comes from there
+        frames = traceback.extract_tb(sys.exc_info()[2])
+        user = [i for i, frame in enumerate(frames) if frame.filename == '` + userSourceFile + `']
+        lines = traceback.format_exc().splitlines(True)
+        if user:
+            lines = [lines[0]] + traceback.format_list(frames[user[0]:]) + traceback.format_exception_only(*sys.exc_info()[:2])
+        sys.stderr.write(''.join(lines))
+        sys.exit(1)
+
+
+`
+
+// generateHarness builds the program that runs the user's code against a
+// canonical test input: the user's code defines a function matching sig, or a
+// class Solution with it as a method, which is called with one argument read
+// from each input line.
+func generateHarness(code string, sig runner.Signature) (string, error) {
+	params, ret, err := sig.Types()
+	if err != nil {
+		return "", err
+	}
+	if params == nil {
+		params = []*runner.Type{} // [] rather than null for functions without parameters
+	}
+	names := make([]string, len(sig.Params))
+	for i, p := range sig.Params {
+		names[i] = p.Name
+	}
+
+	signature, err := json.Marshal([]interface{}{sig.FunctionName, names, params, ret})
+	if err != nil {
+		return "", err
+	}
+	// A JSON string is also a valid Python string literal
+	codeLiteral, err := json.Marshal(code)
+	if err != nil {
+		return "", err
+	}
+	signatureLiteral, err := json.Marshal(string(signature))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%smain(%s, %s)\n", structuresLibrary, harnessRuntime, codeLiteral, signatureLiteral), nil
+}
+
+// writeStructures saves structuresLibrary as structures.py in dir, so the
+// program run from there can import it.
+func writeStructures(dir string) error {
+	return os.WriteFile(filepath.Join(dir, "structures.py"), []byte(structuresLibrary), 0644)
+}
@@ -1,16 +1,16 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"time"
+
+	"runner"
 )
 
 type ExecRequest struct {
@@ -19,19 +19,44 @@ type ExecRequest struct {
 	TimeLimitMs   int    `json:"time_limit_ms"`
 	MemoryLimitKB int    `json:"memory_limit_kb"`
 	Language      string `json:"language"` // ignored – container knows its language
+	// Signature, when set, makes the executor wrap Code in the harness
+	// generated for it, and Input is in the canonical format, see
+	// runner.Signature. Code is otherwise run as it is
+	Signature *runner.Signature `json:"signature,omitempty"`
+	// Interactor, when set, makes this an interactive run: Code is a complete
+	// program talking to the interactor, and Input is given to the interactor
+	Interactor *runner.Interactor `json:"interactor,omitempty"`
+}
+
+// BatchRequest runs one program against many tests, so compiled languages are
+// compiled once rather than once per test.
+type BatchRequest struct {
+	Code          string             `json:"code"`
+	Language      string             `json:"language"`
+	FunctionName  string             `json:"function_name"`
+	Parser        string             `json:"parser"`
+	Signature     *runner.Signature  `json:"signature,omitempty"`
+	Tests         []runner.BatchTest `json:"tests"`
+	StopOnFailure bool               `json:"stop_on_failure"` // Start no more tests once one has failed to run cleanly
+	Parallelism   int                `json:"parallelism"`     // Tests run at once, 1 if unset
 }
 
-type ExecResult struct {
-	Output          string `json:"output"`
-	ExecutionTimeMs int    `json:"execution_time_ms"`
-	MemoryUsedKB    int    `json:"memory_used_kb"`
-	Status          string `json:"status"`
+// info describes the executor on GET /info.
+var info = runner.Info{
+	Language:       "python",
+	DisplayName:    "Python",
+	Template:       "class Solution:\n    def solve(self):\n        pass\n",
+	TimeMultiplier: 3,
 }
 
 func main() {
-	http.HandleFunc("/execute", execHandler)
-	log.Println("🐍 Python-executor listening on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	runner.Init()
+
+	http.HandleFunc("/execute", runner.WithVersion(execHandler))
+	http.HandleFunc("/execute/batch", runner.WithVersion(batchHandler))
+	http.HandleFunc("/info", runner.WithVersion(runner.InfoHandler(info, "python3", "--version")))
+	log.Printf("🐍 Python-executor listening on %s", runner.Addr())
+	log.Fatal(http.ListenAndServe(runner.Addr(), nil))
 }
 
 func execHandler(w http.ResponseWriter, r *http.Request) {
@@ -51,22 +76,33 @@ func execHandler(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 
-	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(req.TimeLimitMs)*time.Millisecond)
-	defer cancel()
-
-	start := time.Now()
-
 	log.Printf("Code: %s", req.Code)
 	log.Printf("Input: %s", req.Input)
 
-	out, status := runCode(ctx, req.Code, req.Input)
-	execMs := int(time.Since(start).Milliseconds())
+	if req.Interactor != nil {
+		if req.Interactor.Language != "python" {
+			http.Error(w, fmt.Sprintf("unsupported interactor language %q", req.Interactor.Language), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(runInteractive(r.Context(), req))
+		return
+	}
 
-	res := ExecResult{
+	code, err := wrapPythonCode(req.Code, req.Signature)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to wrap code: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	out, status, usage := runCode(r.Context(), code, req.Input, req.TimeLimitMs, req.MemoryLimitKB)
+
+	res := runner.ExecResult{
 		Output:          out,
 		Status:          status,
-		ExecutionTimeMs: execMs,
-		MemoryUsedKB:    0, // TODO: parse /usr/bin/time for real value
+		ExecutionTimeMs: usage.CPUTimeMs,
+		WallTimeMs:      usage.WallTimeMs,
+		MemoryUsedKB:    usage.MemoryUsedKB,
 	}
 
 	log.Printf("Output: %s", out)
@@ -76,50 +112,146 @@ func execHandler(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(res)
 }
 
-func runCode(ctx context.Context, code, input string) (output, status string) {
+func runCode(ctx context.Context, code, input string, timeLimitMs, memoryLimitKB int) (output, status string, usage runner.Usage) {
 	log.Println("Running code...")
 	dir, _ := os.MkdirTemp("", "exec-*")
 	defer os.RemoveAll(dir)
 
 	script := filepath.Join(dir, "code.py")
 	_ = os.WriteFile(script, []byte(code), 0644)
+	_ = writeStructures(dir)
+	return runScript(ctx, script, input, timeLimitMs, memoryLimitKB)
+}
 
+// runScript runs script against one input.
+func runScript(ctx context.Context, script, input string, timeLimitMs, memoryLimitKB int) (output, status string, usage runner.Usage) {
 	log.Printf("Input: %s", input)
-	cmd := exec.CommandContext(ctx, "python3", script)
-	if input != "" {
-		cmd.Stdin = strings.NewReader(input)
-	}
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout, cmd.Stderr = &stdout, &stderr
-
-	err := cmd.Run()
+	res := runner.Run(ctx, input, solutionLimits(filepath.Dir(script), timeLimitMs, memoryLimitKB), "python3", script)
 
 	// Always capture stderr for debugging purposes, even on success
-	if stderr.Len() > 0 {
-		log.Printf("Stderr: %s", stderr.String())
+	if res.Stderr != "" {
+		log.Printf("Stderr: %s", res.Stderr)
 	}
 
-	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "time limit exceeded", "time_limit_exceeded"
-		}
-		// Prioritize stderr for more informative error messages
-		if stderr.Len() > 0 {
-			return stderr.String(), "runtime_error"
-		}
-		return err.Error(), "runtime_error"
+	if output, status, failed := runner.Failure(res, outOfMemory); failed {
+		return output, status, res.Usage
 	}
 
-	// This part is for when cmd.Run() succeeds but there's still output on stderr
+	// This part is for when the run succeeds but there's still output on stderr
 	// (e.g., warnings from libraries)
-	if stderr.Len() > 0 {
+	if res.Stderr != "" {
 		// Decide if stderr content should be treated as a failure or just informational
 		// For now, we'll treat any stderr output as a runtime_error if stdout is empty.
-		if stdout.Len() == 0 {
-			return stderr.String(), "runtime_error"
+		if res.Stdout == "" {
+			return res.Stderr, "runtime_error", res.Usage
 		}
 	}
 
-	return stdout.String(), "success"
+	return res.Stdout, "success", res.Usage
 }
+
+// batchHandler runs the code of a BatchRequest against each of its tests.
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code, err := wrapPythonCode(req.Code, req.Signature)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to wrap code: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Running batch of %d tests...", len(req.Tests))
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
+	script := filepath.Join(dir, "code.py")
+	_ = os.WriteFile(script, []byte(code), 0644)
+	_ = writeStructures(dir)
+
+	results := make([]runner.ExecResult, len(req.Tests))
+	ran := runner.Batch(len(req.Tests), req.Parallelism, req.StopOnFailure, func(i int) bool {
+		test := req.Tests[i]
+		out, status, usage := runScript(r.Context(), script, test.Input, test.TimeLimitMs, test.MemoryLimitKB)
+		results[i] = runner.ExecResult{
+			Output:          out,
+			Status:          status,
+			ExecutionTimeMs: usage.CPUTimeMs,
+			WallTimeMs:      usage.WallTimeMs,
+			MemoryUsedKB:    usage.MemoryUsedKB,
+		}
+		return status != "success"
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(runner.BatchResult{Results: results[:ran]})
+}
+
+// runInteractive runs req.Code against req.Interactor, which gets req.Input.
+func runInteractive(ctx context.Context, req ExecRequest) runner.ExecResult {
+	log.Println("Running interactive code...")
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "code.py")
+	_ = os.WriteFile(script, []byte(req.Code), 0644)
+	// Kept out of dir, which the sandboxed solution can read
+	interactorDir, _ := os.MkdirTemp("", "interactor-*")
+	defer os.RemoveAll(interactorDir)
+	interactor := filepath.Join(interactorDir, "interactor.py")
+	_ = os.WriteFile(interactor, []byte(req.Interactor.Code), 0644)
+
+	res := runner.Interact(ctx, runner.Interaction{
+		Input:            req.Input,
+		Solution:         runner.Command{Name: "python3", Args: []string{script}},
+		SolutionLimits:   solutionLimits(dir, req.TimeLimitMs, req.MemoryLimitKB),
+		Interactor:       runner.Command{Name: "python3", Args: []string{interactor}},
+		InteractorLimits: runner.Limits{CPUTime: time.Duration(req.Interactor.TimeLimitMs) * time.Millisecond},
+		QueryLimit:       req.Interactor.QueryLimit,
+	})
+	if res.Interactor.Stderr != "" {
+		log.Printf("Interactor stderr: %s", res.Interactor.Stderr)
+	}
+
+	output, status := "", "success"
+	if o, s, failed := runner.Failure(res.Solution, outOfMemory); failed {
+		output, status = o, s
+	}
+	output, status = res.Judge(output, status)
+
+	return runner.ExecResult{
+		Output:            output,
+		Status:            status,
+		ExecutionTimeMs:   res.Solution.CPUTimeMs,
+		WallTimeMs:        res.Solution.WallTimeMs,
+		MemoryUsedKB:      res.Solution.MemoryUsedKB,
+		Transcript:        res.Transcript,
+		InteractorMessage: res.Message,
+	}
+}
+
+// wrapPythonCode returns the program to run for code: the harness generated
+// for sig, or code itself, which the backend has already wrapped.
+func wrapPythonCode(code string, sig *runner.Signature) (string, error) {
+	if sig == nil {
+		return code, nil
+	}
+	return generateHarness(code, *sig)
+}
+
+// solutionLimits caps the address space so allocations past the limit raise
+// MemoryError, and sandboxes the run of the script in dir.
+func solutionLimits(dir string, timeLimitMs, memoryLimitKB int) runner.Limits {
+	return runner.Limits{
+		CPUTime:        time.Duration(timeLimitMs) * time.Millisecond,
+		AddressSpaceKB: memoryLimitKB,
+		MemoryLimitKB:  memoryLimitKB,
+		SandboxDir:     dir,
+	}
+}
+
+// outOfMemory is on stderr when an allocation past the address space limit
+// fails.
+const outOfMemory = "MemoryError"
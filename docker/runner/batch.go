@@ -0,0 +1,45 @@
+package runner
+
+import "sync"
+
+// Batch calls run for the tests 0 to n-1, starting them in order with at most
+// parallelism running at once. run reports whether its test failed. With
+// stopOnFailure, no test is started once one has failed; tests already running
+// are still waited for. Batch returns how many tests ran, which are always the
+// first ones.
+func Batch(n, parallelism int, stopOnFailure bool, run func(i int) (failed bool)) int {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		stopped bool
+		wg      sync.WaitGroup
+	)
+	slots := make(chan struct{}, parallelism)
+	started := 0
+	for ; started < n; started++ {
+		slots <- struct{}{}
+		mu.Lock()
+		stop := stopped
+		mu.Unlock()
+		if stop {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if run(i) && stopOnFailure {
+				mu.Lock()
+				stopped = true
+				mu.Unlock()
+			}
+		}(started)
+	}
+	wg.Wait()
+	return started
+}
@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits of a compiler's run. Compilers see the submission first, so they run
+// in the sandbox like it: source can include any file the compiler can read,
+// and templates or macros can keep it busy or allocating for ever.
+const (
+	CompileTimeout       = 30 * time.Second // CPU and wall time
+	CompileMemoryLimitKB = 1024 * 1024
+)
+
+// CompileLimits returns the Limits of a compiler building in dir, which it
+// can write to. Its memory is capped with RLIMIT_AS when capAddressSpace is
+// set; compilers running on a JVM or V8, which cannot start under it, cap
+// their heap at CompileMemoryLimitKB with their own flag instead.
+func CompileLimits(dir string, capAddressSpace bool) Limits {
+	limits := Limits{
+		CPUTime:         CompileTimeout,
+		WallTime:        CompileTimeout,
+		SandboxDir:      dir,
+		SandboxWritable: true,
+	}
+	if capAddressSpace {
+		limits.AddressSpaceKB = CompileMemoryLimitKB
+	}
+	return limits
+}
+
+// Compile runs the compiler name with args within limits, normally from
+// CompileLimits, from limits.SandboxDir. output is what the compiler wrote to
+// stdout and stderr, followed by why it was stopped if it hit its limits; err
+// is set when it did not succeed.
+func Compile(ctx context.Context, limits Limits, name string, args ...string) (output string, err error) {
+	res := Run(ctx, "", limits, name, args...)
+	// Some compilers, like tsc, report errors on stdout
+	output = res.Stdout + res.Stderr
+
+	var stopped string
+	switch {
+	case res.SecurityViolation:
+		stopped = "compilation stopped: forbidden system call"
+	case res.OutputExceeded:
+		stopped = "compilation stopped: output limit exceeded"
+	case res.TimedOut:
+		stopped = fmt.Sprintf("compilation stopped: took longer than %s", limits.CPUTime)
+	case res.MemoryExceeded:
+		stopped = "compilation stopped: memory limit exceeded"
+	default:
+		return output, res.Err
+	}
+	if output != "" && !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
+	if res.Err == nil {
+		res.Err = errors.New(stopped)
+	}
+	return output + stopped + "\n", res.Err
+}
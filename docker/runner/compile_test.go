@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// compileSandboxed runs the compiler name in dir within limits, normally from
+// CompileLimits.
+func compileSandboxed(t *testing.T, limits Limits, name string, args ...string) (output string, err error) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("entering the sandbox needs root")
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s is not installed", name)
+	}
+	output, err = Compile(context.Background(), limits, name, args...)
+	if strings.HasPrefix(output, "runner: sandbox:") {
+		t.Skipf("cannot enter the sandbox here: %s", output)
+	}
+	return output, err
+}
+
+// sourceDir returns a new directory holding source as main.c.
+func sourceDir(t *testing.T, source string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.c"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestCompileBuildsInItsDirectory(t *testing.T) {
+	dir := sourceDir(t, "int main(void) { return 0; }\n")
+	if output, err := compileSandboxed(t, CompileLimits(dir, true), "gcc", "-o", "main", "main.c"); err != nil {
+		t.Fatalf("compile failed: %v\n%s", err, output)
+	}
+	if res := Run(context.Background(), "", Limits{CPUTime: time.Second, SandboxDir: dir}, filepath.Join(dir, "main")); res.Err != nil {
+		t.Fatalf("compiled program failed: %v, stderr %q", res.Err, res.Stderr)
+	}
+	// Runs of the program must not be able to write to it
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid := info.Sys().(*syscall.Stat_t).Uid; uid != 0 {
+		t.Errorf("directory is left owned by UID %d", uid)
+	}
+}
+
+func TestCompileCannotReadRootOnlyFiles(t *testing.T) {
+	secret := "/var/tmp/compile-secret-" + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(secret, []byte("int leaked = 42;\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(secret)
+
+	dir := sourceDir(t, "#include \""+secret+"\"\nint main(void) { return leaked; }\n")
+	output, err := compileSandboxed(t, CompileLimits(dir, true), "gcc", "-o", "main", "main.c")
+	if err == nil {
+		t.Fatalf("a root-only file was included:\n%s", output)
+	}
+	if !strings.Contains(output, "Permission denied") {
+		t.Errorf("output = %q, want the include to be denied", output)
+	}
+}
+
+func TestCompileStopsAtTimeout(t *testing.T) {
+	limits := CompileLimits(t.TempDir(), true)
+	limits.CPUTime, limits.WallTime = time.Second, 2*time.Second
+	output, err := compileSandboxed(t, limits, "sh", "-c", "echo compiling; while :; do :; done")
+	if err == nil {
+		t.Fatal("compile never stopped")
+	}
+	if want := "compiling\ncompilation stopped: took longer than 1s\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
+
+func TestCompileSharesCacheDir(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "cache")
+	for i, script := range []string{`mkdir "$1/entries" && echo 1 > "$1/entries/a"`, `echo 2 >> "$1/entries/a"`} {
+		limits := CompileLimits(t.TempDir(), true)
+		limits.SandboxCacheDir = cache
+		if output, err := compileSandboxed(t, limits, "sh", "-c", script, "sh", cache); err != nil {
+			t.Fatalf("compile %d failed: %v\n%s", i, err, output)
+		}
+	}
+	if got, _ := os.ReadFile(filepath.Join(cache, "entries", "a")); string(got) != "1\n2\n" {
+		t.Errorf("cache entry = %q, want both compiles' lines", got)
+	}
+}
@@ -0,0 +1,237 @@
+package runner
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic is one message from a compiler, such as an error on a line of
+// the user's code.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"` // From 1, 0 when the compiler gives none
+	Severity string `json:"severity"`         // "error", "warning" or "note"
+	Message  string `json:"message"`
+}
+
+// WrapperFile is the file diagnostics are reported under when they point into
+// the code generated around the user's, e.g. the parser or main function.
+const WrapperFile = "<wrapper>"
+
+// SourceMap locates the user's code inside the program generated around it,
+// so compiler positions can be given in the user's own line numbers.
+type SourceMap struct {
+	File         string // Name the user's code is reported under, e.g. "solution.cpp"
+	CompiledFile string // Name the generated program is compiled under, e.g. "source.cpp", if not File
+	FirstLine    int    // Line of the generated program the user's code starts on, 0 if unknown
+	Lines        int    // Lines of user code
+	FirstIndent  int    // Characters the generator put before the user's first line
+}
+
+// NewSourceMap finds code inside generated, which contains it verbatim. For
+// code compiled as it is, pass the same string twice.
+func NewSourceMap(file, generated, code string) SourceMap {
+	i := strings.Index(generated, code)
+	if code == "" || i < 0 {
+		return SourceMap{File: file}
+	}
+	lineStart := strings.LastIndex(generated[:i], "\n") + 1
+	return SourceMap{
+		File:        file,
+		FirstLine:   strings.Count(generated[:i], "\n") + 1,
+		Lines:       strings.Count(strings.TrimSuffix(code, "\n"), "\n") + 1,
+		FirstIndent: i - lineStart,
+	}
+}
+
+// Map moves diagnostics from positions in the generated program to positions
+// in the user's code. Those in the generated program but outside the user's
+// code keep their position under WrapperFile, and those in other files are
+// left as they are.
+func (m SourceMap) Map(diagnostics []Diagnostic) []Diagnostic {
+	compiled := m.CompiledFile
+	if compiled == "" {
+		compiled = m.File
+	}
+	mapped := make([]Diagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		// Compilers give the path the file was passed as, e.g. "./main.go"
+		if filepath.Base(d.File) != compiled {
+			mapped[i] = d
+			continue
+		}
+		if m.FirstLine == 0 || d.Line < m.FirstLine || d.Line >= m.FirstLine+m.Lines {
+			d.File = WrapperFile
+			mapped[i] = d
+			continue
+		}
+		if d.Line == m.FirstLine && d.Column > m.FirstIndent {
+			d.Column -= m.FirstIndent
+		}
+		d.Line -= m.FirstLine - 1
+		d.File = m.File
+		mapped[i] = d
+	}
+	return mapped
+}
+
+// gccDiagnostic matches "source.cpp:12:5: error: message".
+var gccDiagnostic = regexp.MustCompile(`^(.+?):(\d+):(\d+): (fatal error|error|warning|note): (.*)$`)
+
+// ParseGCCDiagnostics extracts the diagnostics from g++ output. Context lines
+// such as "In function 'int main()'" and the quoted source are skipped.
+func ParseGCCDiagnostics(output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		m := gccDiagnostic.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		severity := m[4]
+		if severity == "fatal error" {
+			severity = "error"
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			File:     m[1],
+			Line:     lineNo,
+			Column:   column,
+			Severity: severity,
+			Message:  m[5],
+		})
+	}
+	return diagnostics
+}
+
+// javacDiagnostic matches "Main.java:12: error: message".
+var javacDiagnostic = regexp.MustCompile(`^(.+?\.java):(\d+): (error|warning): (.*)$`)
+
+// javacSummary matches the count javac ends with, e.g. "2 errors".
+var javacSummary = regexp.MustCompile(`^\d+ (errors?|warnings?)$`)
+
+// ParseJavacDiagnostics extracts the diagnostics from javac output. javac
+// follows each message with the offending source line and a caret under the
+// column, then optional detail lines like "symbol: variable x", which are
+// added to the message.
+func ParseJavacDiagnostics(output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	var current *Diagnostic
+	afterSource := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := javacDiagnostic.FindStringSubmatch(line); m != nil {
+			lineNo, _ := strconv.Atoi(m[2])
+			diagnostics = append(diagnostics, Diagnostic{
+				File:     m[1],
+				Line:     lineNo,
+				Severity: m[3],
+				Message:  m[4],
+			})
+			current = &diagnostics[len(diagnostics)-1]
+			afterSource = false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if javacSummary.MatchString(trimmed) || strings.HasPrefix(trimmed, "Note: ") {
+			current = nil
+			continue
+		}
+		switch {
+		case !afterSource && trimmed == "^":
+			current.Column = strings.Index(line, "^") + 1
+			afterSource = true
+		case afterSource && trimmed != "":
+			current.Message += "\n" + trimmed
+		}
+	}
+	return diagnostics
+}
+
+// goDiagnostic matches "./main.go:12:5: message"; the column is optional.
+var goDiagnostic = regexp.MustCompile(`^(.+?\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// ParseGoDiagnostics extracts the diagnostics from go build output, which
+// are all errors. The "# command-line-arguments" header is skipped.
+func ParseGoDiagnostics(output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		m := goDiagnostic.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		diagnostics = append(diagnostics, Diagnostic{
+			File:     m[1],
+			Line:     lineNo,
+			Column:   column,
+			Severity: "error",
+			Message:  m[4],
+		})
+	}
+	return diagnostics
+}
+
+// rustcDiagnostic matches rustc's --error-format=short, e.g.
+// "main.rs:12:5: error[E0425]: message".
+var rustcDiagnostic = regexp.MustCompile(`^(.+?):(\d+):(\d+): (error|warning|note)(?:\[\w+\])?: (.*)$`)
+
+// ParseRustcDiagnostics extracts the diagnostics from rustc output in the
+// short error format. The closing "error: aborting due to..." has no position
+// and is skipped.
+func ParseRustcDiagnostics(output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		m := rustcDiagnostic.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		diagnostics = append(diagnostics, Diagnostic{
+			File:     m[1],
+			Line:     lineNo,
+			Column:   column,
+			Severity: m[4],
+			Message:  m[5],
+		})
+	}
+	return diagnostics
+}
+
+// tscDiagnostic matches "main.ts(12,5): error TS2304: message".
+var tscDiagnostic = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): (error|warning|message) (TS\d+: .*)$`)
+
+// ParseTscDiagnostics extracts the diagnostics from tsc output with
+// --pretty false. The error code is kept at the start of the message.
+func ParseTscDiagnostics(output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		m := tscDiagnostic.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		severity := m[4]
+		if severity == "message" {
+			severity = "note"
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			File:     m[1],
+			Line:     lineNo,
+			Column:   column,
+			Severity: severity,
+			Message:  m[5],
+		})
+	}
+	return diagnostics
+}
@@ -0,0 +1,27 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSourceMapLeavesHeaderDiagnostics(t *testing.T) {
+	code := strings.Repeat("int f();\n", 5) + "int main() { return 0 }\n"
+	generated := "#include <vector>\n" + code + "// parser\n"
+	sourceMap := NewSourceMap("solution.cpp", generated, code)
+	sourceMap.CompiledFile = "source.cpp"
+
+	output := "/tmp/exec-1/source.cpp:7:23: error: expected ';' before '}' token\n" +
+		"/tmp/exec-1/source.cpp:8:1: error: expected declaration\n" +
+		"/usr/include/c++/12/bits/stl_vector.h:3:7: note: candidate here\n"
+	got := sourceMap.Map(ParseGCCDiagnostics(output))
+	want := []Diagnostic{
+		{File: "solution.cpp", Line: 6, Column: 23, Severity: "error", Message: "expected ';' before '}' token"},
+		{File: WrapperFile, Line: 8, Column: 1, Severity: "error", Message: "expected declaration"},
+		{File: "/usr/include/c++/12/bits/stl_vector.h", Line: 3, Column: 7, Severity: "note", Message: "candidate here"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Map = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Info describes the language an executor runs, as served by GET /info. The
+// backend's language registry is filled in from it, so what the editor shows
+// is what the deployed images actually run.
+type Info struct {
+	Language     string   `json:"language"` // Canonical name in the backend's registry, e.g. "cpp"
+	DisplayName  string   `json:"display_name"`
+	Version      string   `json:"version"` // Filled in by InfoHandler
+	CompileFlags []string `json:"compile_flags,omitempty"`
+	// Template is the code the editor starts a new solution from
+	Template string `json:"template"`
+	// TimeMultiplier scales a problem's time limit to make up for a slower
+	// runtime
+	TimeMultiplier  float64 `json:"time_multiplier"`
+	ExecutorVersion string  `json:"executor_version"` // Filled in by InfoHandler, see Version
+}
+
+// InfoHandler serves info on GET /info. Its Version is the first line the
+// toolchain prints for versionCommand, e.g. "g++ --version", which is run on
+// the first request.
+func InfoHandler(info Info, versionCommand ...string) http.HandlerFunc {
+	var once sync.Once
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		once.Do(func() {
+			info.Version = toolchainVersion(versionCommand)
+			info.ExecutorVersion = Version
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	}
+}
+
+// toolchainVersion returns the first line command prints, on stdout or, as
+// for javac, stderr. It is empty if the command fails.
+func toolchainVersion(command []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line)
+}
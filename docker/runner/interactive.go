@@ -0,0 +1,353 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxTranscriptBytes caps the transcript kept of an interactive run.
+const maxTranscriptBytes = 64 * 1024
+
+// Command is a program and its arguments.
+type Command struct {
+	Name string
+	Args []string
+}
+
+// Interaction describes an interactive run. The interactor is started with two
+// extra arguments, the path of a file holding Input and the path of a verdict
+// file, and talks to the solution over its stdin and stdout. Before exiting it
+// writes its verdict: OK (or AC) on the first line to accept, QLE when the
+// solution asked too many queries, anything else to reject, optionally
+// followed by a message for the user.
+type Interaction struct {
+	Input            string
+	Solution         Command
+	SolutionLimits   Limits
+	Interactor       Command
+	InteractorLimits Limits
+	// QueryLimit is the number of lines the solution may send the interactor.
+	// The run is stopped as soon as it sends more. Zero means no limit.
+	QueryLimit int
+}
+
+// Verdict is the interactor's decision on a run.
+type Verdict string
+
+const (
+	VerdictAccepted           Verdict = "accepted"
+	VerdictRejected           Verdict = "rejected"
+	VerdictQueryLimitExceeded Verdict = "query_limit_exceeded"
+	VerdictInteractorFailed   Verdict = "interactor_failed" // Crashed, timed out or wrote no verdict
+)
+
+// InteractiveResult describes a finished interactive run.
+type InteractiveResult struct {
+	Solution   Result // Stdout is empty, it went to the interactor
+	Interactor Result
+	Verdict    Verdict
+	Message    string // From the interactor's verdict file
+	// Transcript holds the traffic of the run, one line per message: lines the
+	// solution sent start with "> " and lines the interactor sent with "< ".
+	Transcript string
+	Queries    int // Lines the solution sent
+	// InteractorExitedFirst is set when the interactor finished before the
+	// solution, e.g. because it gave up on a wrong answer
+	InteractorExitedFirst bool
+}
+
+// Interact runs the solution and the interactor of in with each one's stdout
+// connected to the other's stdin, and waits for both to finish or hit their
+// limits.
+func Interact(ctx context.Context, in Interaction) InteractiveResult {
+	// Going over the query limit stops both programs
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "interact-*")
+	if err != nil {
+		return interactorFailed(err)
+	}
+	defer os.RemoveAll(dir)
+	inputFile := filepath.Join(dir, "input.txt")
+	verdictFile := filepath.Join(dir, "verdict.txt")
+	if err := os.WriteFile(inputFile, []byte(in.Input), 0644); err != nil {
+		return interactorFailed(err)
+	}
+
+	solutionCtx, cancelSolution := withWallTime(ctx, in.SolutionLimits)
+	defer cancelSolution()
+	solution, cleanupSolution, err := command(solutionCtx, in.SolutionLimits, in.Solution.Name, in.Solution.Args...)
+	if err != nil {
+		return interactorFailed(err)
+	}
+	defer cleanupSolution()
+	interactorCtx, cancelInteractor := withWallTime(ctx, in.InteractorLimits)
+	defer cancelInteractor()
+	interactorArgs := append(append([]string{}, in.Interactor.Args...), inputFile, verdictFile)
+	interactor, cleanupInteractor, err := command(interactorCtx, in.InteractorLimits, in.Interactor.Name, interactorArgs...)
+	if err != nil {
+		return interactorFailed(err)
+	}
+	defer cleanupInteractor()
+
+	// The programs talk through pipes relayed by this process, so the traffic
+	// can be recorded and the solution's queries counted
+	var pipes []*os.File
+	defer func() {
+		for _, f := range pipes {
+			f.Close()
+		}
+	}()
+	pipe := func() (r, w *os.File, err error) {
+		r, w, err = os.Pipe()
+		if err == nil {
+			pipes = append(pipes, r, w)
+		}
+		return r, w, err
+	}
+	fromSolution, solutionStdout, err := pipe()
+	if err != nil {
+		return interactorFailed(err)
+	}
+	solutionStdin, toSolution, err := pipe()
+	if err != nil {
+		return interactorFailed(err)
+	}
+	fromInteractor, interactorStdout, err := pipe()
+	if err != nil {
+		return interactorFailed(err)
+	}
+	interactorStdin, toInteractor, err := pipe()
+	if err != nil {
+		return interactorFailed(err)
+	}
+
+	// The solution's stdout goes to the interactor, capped at OutputLimitKB like
+	// its stderr by the relay below
+	solutionStderr := newOutputBuffer(in.SolutionLimits, cancelSolution)
+	var interactorStderr bytes.Buffer
+	solution.Stdin, solution.Stdout, solution.Stderr = solutionStdin, solutionStdout, solutionStderr
+	interactor.Stdin, interactor.Stdout, interactor.Stderr = interactorStdin, interactorStdout, &interactorStderr
+
+	interactorStart := time.Now()
+	if err := interactor.Start(); err != nil {
+		return interactorFailed(err)
+	}
+	solutionStart := time.Now()
+	if err := solution.Start(); err != nil {
+		cancel()
+		_ = interactor.Wait()
+		return InteractiveResult{Solution: Result{Err: err, Stderr: err.Error()}, Verdict: VerdictInteractorFailed}
+	}
+	// Only the children write to their stdout and read their stdin now, so the
+	// relays see end of file once they exit
+	solutionStdin.Close()
+	solutionStdout.Close()
+	interactorStdin.Close()
+	interactorStdout.Close()
+
+	var (
+		traffic                          transcript
+		queries                          atomic.Int64
+		queryLimitExceeded               atomic.Bool
+		outputExceeded                   atomic.Bool
+		solutionErr, interactorErr       error
+		solutionExited, interactorExited time.Time
+		wg                               sync.WaitGroup
+	)
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		traffic.relay(toInteractor, fromSolution, "> ", outputLimit(in.SolutionLimits), func(lines int) {
+			if n := queries.Add(int64(lines)); in.QueryLimit > 0 && n > int64(in.QueryLimit) {
+				queryLimitExceeded.Store(true)
+				cancel()
+			}
+		}, func() {
+			outputExceeded.Store(true)
+			cancelSolution()
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		traffic.relay(toSolution, fromInteractor, "< ", 0, nil, nil)
+	}()
+	go func() {
+		defer wg.Done()
+		solutionErr = solution.Wait()
+		solutionExited = time.Now()
+	}()
+	go func() {
+		defer wg.Done()
+		interactorErr = interactor.Wait()
+		interactorExited = time.Now()
+	}()
+	wg.Wait()
+
+	res := InteractiveResult{
+		Solution:              result(solutionCtx, solution, in.SolutionLimits, solutionStart, solutionErr, "", solutionStderr.String(), solutionStderr.exceeded || outputExceeded.Load()),
+		Interactor:            result(interactorCtx, interactor, in.InteractorLimits, interactorStart, interactorErr, "", interactorStderr.String(), false),
+		Transcript:            traffic.String(),
+		Queries:               int(queries.Load()),
+		InteractorExitedFirst: interactorExited.Before(solutionExited),
+	}
+
+	if queryLimitExceeded.Load() {
+		res.Verdict = VerdictQueryLimitExceeded
+		return res
+	}
+	if res.Interactor.Err != nil || res.Interactor.TimedOut {
+		res.Verdict = VerdictInteractorFailed
+		return res
+	}
+	verdict, err := os.ReadFile(verdictFile)
+	if err != nil || strings.TrimSpace(string(verdict)) == "" {
+		res.Verdict = VerdictInteractorFailed
+		return res
+	}
+	res.Verdict, res.Message = parseVerdict(string(verdict))
+	return res
+}
+
+// Judge combines the verdict of an interactive run with the output and status
+// the executor gave the solution's own run ("success", "time_limit_exceeded",
+// "runtime_error", ...). Going over the query limit, time or memory limit, or
+// a security violation, wins over the interactor's verdict. A rejection wins
+// over a runtime error if the interactor finished first, since a solution
+// commonly dies writing to an interactor that has already given up.
+func (r InteractiveResult) Judge(output, status string) (string, string) {
+	switch {
+	case r.Verdict == VerdictQueryLimitExceeded:
+		return "query limit exceeded", "query_limit_exceeded"
+	case status == "time_limit_exceeded" || status == "memory_limit_exceeded" ||
+		status == "security_violation" || status == "output_limit_exceeded":
+		return output, status
+	case r.Verdict == VerdictRejected && (status == "success" || r.InteractorExitedFirst):
+		return r.Message, "wrong_answer"
+	case status != "success":
+		return output, status
+	case r.Verdict == VerdictInteractorFailed:
+		if r.Interactor.Stderr != "" {
+			return r.Interactor.Stderr, "interactor_error"
+		}
+		return "interactor did not write a verdict", "interactor_error"
+	default:
+		return output, status
+	}
+}
+
+func interactorFailed(err error) InteractiveResult {
+	return InteractiveResult{
+		Interactor: Result{Err: err, Stderr: err.Error()},
+		Verdict:    VerdictInteractorFailed,
+	}
+}
+
+// parseVerdict reads a verdict file: the first line is the verdict and the
+// remaining lines are a message for the user.
+func parseVerdict(s string) (Verdict, string) {
+	verdict, message, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	message = strings.TrimSpace(message)
+	switch strings.ToUpper(strings.TrimSpace(verdict)) {
+	case "OK", "AC":
+		return VerdictAccepted, message
+	case "QLE":
+		return VerdictQueryLimitExceeded, message
+	default:
+		return VerdictRejected, message
+	}
+}
+
+// transcript records the lines passing between the solution and the
+// interactor, in the order they were relayed.
+type transcript struct {
+	mu        sync.Mutex
+	buf       strings.Builder
+	truncated bool
+}
+
+// relay copies src to dst as it arrives, recording each line with prefix.
+// onLines, if set, is told how many complete lines each read brought. Past
+// limit bytes, unless it is 0, nothing more is copied and onExceeded is
+// called once. dst is closed once src ends; if dst stops accepting input, src
+// is still drained so its writer does not block.
+func (t *transcript) relay(dst io.WriteCloser, src io.Reader, prefix string, limit int, onLines func(int), onExceeded func()) {
+	defer dst.Close()
+
+	var line []byte
+	dstGone := false
+	exceeded := false
+	relayed := 0
+	buf := make([]byte, 4096)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 && !exceeded {
+			chunk := buf[:n]
+			if limit > 0 && relayed+n > limit {
+				chunk = chunk[:limit-relayed]
+				exceeded = true
+			}
+			relayed += len(chunk)
+			if !dstGone {
+				if _, werr := dst.Write(chunk); werr != nil {
+					dstGone = true
+				}
+			}
+			lines := 0
+			for _, b := range chunk {
+				if b != '\n' {
+					// The transcript is cut long before a line this long
+					if len(line) <= maxTranscriptBytes {
+						line = append(line, b)
+					}
+					continue
+				}
+				t.add(prefix, line)
+				line = line[:0]
+				lines++
+			}
+			if lines > 0 && onLines != nil {
+				onLines(lines)
+			}
+			if exceeded && onExceeded != nil {
+				onExceeded()
+			}
+		}
+		if err != nil {
+			if len(line) > 0 {
+				t.add(prefix, line)
+			}
+			return
+		}
+	}
+}
+
+func (t *transcript) add(prefix string, line []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.truncated {
+		return
+	}
+	if t.buf.Len()+len(prefix)+len(line)+1 > maxTranscriptBytes {
+		t.buf.WriteString("... transcript truncated\n")
+		t.truncated = true
+		return
+	}
+	t.buf.WriteString(prefix)
+	t.buf.Write(line)
+	t.buf.WriteByte('\n')
+}
+
+func (t *transcript) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf.String()
+}
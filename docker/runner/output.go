@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// DefaultOutputLimitKB caps each of stdout and stderr of runs whose
+// Limits.OutputLimitKB is unset. It is set with EXECUTOR_OUTPUT_LIMIT_KB.
+var DefaultOutputLimitKB = 16 * 1024
+
+func init() {
+	if v, err := strconv.Atoi(os.Getenv("EXECUTOR_OUTPUT_LIMIT_KB")); err == nil && v > 0 {
+		DefaultOutputLimitKB = v
+	}
+}
+
+// outputBuffer collects what a program writes to one of its streams up to a
+// limit. The first write past the limit calls kill, so a program printing in
+// a loop is stopped as soon as it goes over rather than when its time is up,
+// and is never held in memory beyond the limit.
+type outputBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	kill     func()
+	exceeded bool
+}
+
+// newOutputBuffer returns the buffer for one stream of a run with limits,
+// which kill stops.
+func newOutputBuffer(limits Limits, kill func()) *outputBuffer {
+	return &outputBuffer{limit: outputLimit(limits), kill: kill}
+}
+
+// outputLimit returns how many bytes a run with limits may write to each of
+// its streams.
+func outputLimit(limits Limits) int {
+	limitKB := limits.OutputLimitKB
+	if limitKB == 0 {
+		limitKB = DefaultOutputLimitKB
+	}
+	return limitKB * 1024
+}
+
+// Write never fails, as the program would otherwise see EPIPE and could exit
+// cleanly before it is killed.
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	if b.exceeded {
+		return len(p), nil
+	}
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:room])
+		b.exceeded = true
+		b.kill()
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *outputBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunStopsAtOutputLimit(t *testing.T) {
+	if _, err := exec.LookPath("yes"); err != nil {
+		t.Skip("yes is not installed")
+	}
+	limits := Limits{CPUTime: 5 * time.Second, OutputLimitKB: 64}
+	res := Run(context.Background(), "", limits, "yes")
+	if !res.OutputExceeded {
+		t.Fatalf("output limit not reported: %+v", res.Usage)
+	}
+	if res.TimedOut || res.MemoryExceeded {
+		t.Errorf("TimedOut = %v, MemoryExceeded = %v, want only the output limit", res.TimedOut, res.MemoryExceeded)
+	}
+	if len(res.Stdout) != 64*1024 {
+		t.Errorf("kept %d bytes of output, want %d", len(res.Stdout), 64*1024)
+	}
+	if res.WallTimeMs >= 5000 {
+		t.Errorf("run took %dms, it should stop once over the limit", res.WallTimeMs)
+	}
+}
+
+func TestRunKeepsOutputWithinLimit(t *testing.T) {
+	res := Run(context.Background(), "line\n", Limits{OutputLimitKB: 1}, "cat")
+	if res.Err != nil || res.OutputExceeded {
+		t.Fatalf("run failed: %v, OutputExceeded = %v", res.Err, res.OutputExceeded)
+	}
+	if res.Stdout != "line\n" {
+		t.Errorf("output = %q, want %q", res.Stdout, "line\n")
+	}
+}
+
+func TestInteractStopsAtOutputLimit(t *testing.T) {
+	if _, err := exec.LookPath("yes"); err != nil {
+		t.Skip("yes is not installed")
+	}
+	res := Interact(context.Background(), Interaction{
+		Solution:         Command{Name: "yes"},
+		SolutionLimits:   Limits{CPUTime: 5 * time.Second, OutputLimitKB: 64},
+		Interactor:       Command{Name: "sh", Args: []string{"-c", `cat >/dev/null; echo OK > "$2"`, "interactor"}},
+		InteractorLimits: Limits{CPUTime: 5 * time.Second},
+	})
+	if !res.Solution.OutputExceeded {
+		t.Fatalf("output limit not reported: %+v", res.Solution.Usage)
+	}
+	if res.Solution.WallTimeMs >= 5000 {
+		t.Errorf("run took %dms, it should stop once over the limit", res.Solution.WallTimeMs)
+	}
+	if !strings.HasSuffix(res.Transcript, "... transcript truncated\n") {
+		t.Errorf("transcript of %d bytes is not truncated", len(res.Transcript))
+	}
+}
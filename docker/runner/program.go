@@ -0,0 +1,325 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Toolchain builds and runs the programs of one language. Executors of
+// languages that run submissions as complete programs, reading the test input
+// from stdin with no parser or harness generated around them, are a Toolchain
+// passed to Serve.
+type Toolchain struct {
+	Name string // In logs, e.g. "Go"
+	// SourceFile is the name code is saved under in the run's directory, which
+	// diagnostics in it are also reported under, e.g. "main.go"
+	SourceFile string
+	// Compile returns the command building SourceFile in dir, which is its
+	// working directory. It runs sandboxed within CompileLimits. Nil for
+	// languages run from source
+	Compile func(dir string) Command
+	// CompileCapAddressSpace caps the compiler's memory with RLIMIT_AS. Leave
+	// it unset for compilers on a JVM or V8, whose Compile passes a heap flag
+	CompileCapAddressSpace bool
+	// CompileCacheDir is a directory the compiler may keep a cache in across
+	// builds, see Limits.SandboxCacheDir
+	CompileCacheDir string
+	// Diagnostics extracts the compiler's messages from its output
+	Diagnostics func(output string) []Diagnostic
+	// Run returns the command running the program built in dir. Runtimes with
+	// their own heap flag cap their heap at memoryLimitKB, unless it is 0
+	Run func(dir string, memoryLimitKB int) Command
+	// CapAddressSpace makes allocations past the memory limit fail, see
+	// Limits.AddressSpaceKB. Leave it unset for runtimes with a heap flag
+	CapAddressSpace bool
+	// OutOfMemory are messages on stderr that mean an allocation failed, see
+	// Failure
+	OutOfMemory []string
+	// Info is served on GET /info, with the version printed by VersionCommand
+	Info           Info
+	VersionCommand []string
+}
+
+// ExecRequest is the body of POST /execute: one run of Code against Input.
+type ExecRequest struct {
+	Code          string `json:"code"`
+	Input         string `json:"input"`
+	TimeLimitMs   int    `json:"time_limit_ms"`
+	MemoryLimitKB int    `json:"memory_limit_kb"`
+	Language      string `json:"language"` // Ignored, the executor knows its language
+	// Signature is not supported, as Code is always a complete program
+	Signature *Signature `json:"signature,omitempty"`
+	// Interactor, when set, makes this an interactive run: Code talks to the
+	// interactor, and Input is given to the interactor
+	Interactor *Interactor `json:"interactor,omitempty"`
+}
+
+// Interactor is the judge-side program of an interactive problem, see
+// Interaction.
+type Interactor struct {
+	Code        string `json:"code"`
+	Language    string `json:"language"`      // Only python is supported
+	TimeLimitMs int    `json:"time_limit_ms"` // CPU time
+	QueryLimit  int    `json:"query_limit"`   // Lines the solution may send, 0 for no limit
+}
+
+// ExecResult is the response to POST /execute, and each result of a batch.
+type ExecResult struct {
+	Output          string `json:"output"`
+	ExecutionTimeMs int    `json:"execution_time_ms"` // CPU time
+	WallTimeMs      int    `json:"wall_time_ms"`
+	MemoryUsedKB    int    `json:"memory_used_kb"`
+	Status          string `json:"status"`
+	// Set for interactive runs
+	Transcript        string `json:"transcript,omitempty"`
+	InteractorMessage string `json:"interactor_message,omitempty"`
+	// Set with status compile_error, in the user's line numbers
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// BatchRequest is the body of POST /execute/batch: Code is built once and run
+// against each of Tests.
+type BatchRequest struct {
+	Code          string      `json:"code"`
+	Language      string      `json:"language"`
+	Signature     *Signature  `json:"signature,omitempty"`
+	Tests         []BatchTest `json:"tests"`
+	StopOnFailure bool        `json:"stop_on_failure"` // Start no more tests once one has failed to run cleanly
+	Parallelism   int         `json:"parallelism"`     // Tests run at once, 1 if unset
+}
+
+// BatchTest is one input of a BatchRequest with its limits.
+type BatchTest struct {
+	Input         string `json:"input"`
+	TimeLimitMs   int    `json:"time_limit_ms"`
+	MemoryLimitKB int    `json:"memory_limit_kb"`
+}
+
+// BatchResult holds a result for each test that ran, in the order of the
+// tests. With StopOnFailure there may be fewer results than tests.
+type BatchResult struct {
+	Results []ExecResult `json:"results"`
+}
+
+// Serve runs the executor for tc on Addr. It must be all of the executor's
+// main, as it calls Init first.
+func Serve(tc Toolchain) {
+	Init()
+
+	http.HandleFunc("/execute", WithVersion(tc.execHandler))
+	http.HandleFunc("/execute/batch", WithVersion(tc.batchHandler))
+	http.HandleFunc("/info", WithVersion(InfoHandler(tc.Info, tc.VersionCommand...)))
+	log.Printf("%s-executor listening on %s", tc.Name, Addr())
+	log.Fatal(http.ListenAndServe(Addr(), nil))
+}
+
+func (tc Toolchain) execHandler(w http.ResponseWriter, r *http.Request) {
+	var req ExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Signature != nil {
+		http.Error(w, fmt.Sprintf("function signatures are not supported for %s", tc.Name), http.StatusBadRequest)
+		return
+	}
+	if req.Interactor != nil && req.Interactor.Language != "python" {
+		http.Error(w, fmt.Sprintf("unsupported interactor language %q", req.Interactor.Language), http.StatusBadRequest)
+		return
+	}
+
+	log.Println("Running code...")
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
+
+	var res ExecResult
+	if compileOut, err := tc.build(r.Context(), dir, req.Code); err != nil {
+		res = tc.compileError(compileOut, req.Code)
+	} else if req.Interactor != nil {
+		res = tc.runInteractive(r.Context(), dir, req)
+	} else {
+		res = tc.run(r.Context(), dir, req.Input, req.TimeLimitMs, req.MemoryLimitKB)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// batchHandler builds the code of a BatchRequest once and runs it against
+// each of its tests.
+func (tc Toolchain) batchHandler(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Signature != nil {
+		http.Error(w, fmt.Sprintf("function signatures are not supported for %s", tc.Name), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Running batch of %d tests...", len(req.Tests))
+	dir, _ := os.MkdirTemp("", "exec-*")
+	defer os.RemoveAll(dir)
+	if compileOut, err := tc.build(r.Context(), dir, req.Code); err != nil {
+		// Every test would fail the same way, so only the first is reported
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(BatchResult{Results: []ExecResult{tc.compileError(compileOut, req.Code)}})
+		return
+	}
+
+	results := make([]ExecResult, len(req.Tests))
+	ran := Batch(len(req.Tests), req.Parallelism, req.StopOnFailure, func(i int) bool {
+		test := req.Tests[i]
+		results[i] = tc.run(r.Context(), dir, test.Input, test.TimeLimitMs, test.MemoryLimitKB)
+		return results[i].Status != "success"
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(BatchResult{Results: results[:ran]})
+}
+
+// build saves code as SourceFile in dir and compiles it there, returning the
+// compiler's output if it fails.
+func (tc Toolchain) build(ctx context.Context, dir, code string) (output string, err error) {
+	_ = os.WriteFile(filepath.Join(dir, tc.SourceFile), []byte(code), 0644)
+	if tc.Compile == nil {
+		return "", nil
+	}
+
+	compiler := tc.Compile(dir)
+	limits := CompileLimits(dir, tc.CompileCapAddressSpace)
+	limits.SandboxCacheDir = tc.CompileCacheDir
+	if output, err := Compile(ctx, limits, compiler.Name, compiler.Args...); err != nil {
+		return output, err
+	}
+	return "", nil
+}
+
+// compileError is the result for code that failed to compile with output.
+func (tc Toolchain) compileError(output, code string) ExecResult {
+	var diagnostics []Diagnostic
+	if tc.Diagnostics != nil {
+		diagnostics = NewSourceMap(tc.SourceFile, code, code).Map(tc.Diagnostics(output))
+	}
+	return ExecResult{
+		Output:      output,
+		Status:      "compile_error",
+		Diagnostics: diagnostics,
+	}
+}
+
+// run runs the program built in dir against one input.
+func (tc Toolchain) run(ctx context.Context, dir, input string, timeLimitMs, memoryLimitKB int) ExecResult {
+	program := tc.Run(dir, memoryLimitKB)
+	res := Run(ctx, input, tc.limits(dir, timeLimitMs, memoryLimitKB), program.Name, program.Args...)
+	output, status := res.Stdout, "success"
+	if o, s, failed := Failure(res, tc.OutOfMemory...); failed {
+		output, status = o, s
+	} else if res.Stderr != "" {
+		output, status = res.Stderr, "runtime_error"
+	}
+	return ExecResult{
+		Output:          output,
+		Status:          status,
+		ExecutionTimeMs: res.CPUTimeMs,
+		WallTimeMs:      res.WallTimeMs,
+		MemoryUsedKB:    res.MemoryUsedKB,
+	}
+}
+
+// runInteractive runs the program built in dir against req.Interactor, which
+// gets req.Input.
+func (tc Toolchain) runInteractive(ctx context.Context, dir string, req ExecRequest) ExecResult {
+	// Kept out of dir, which the sandboxed solution can read
+	interactorDir, _ := os.MkdirTemp("", "interactor-*")
+	defer os.RemoveAll(interactorDir)
+	interactor := filepath.Join(interactorDir, "interactor.py")
+	_ = os.WriteFile(interactor, []byte(req.Interactor.Code), 0644)
+
+	res := Interact(ctx, Interaction{
+		Input:            req.Input,
+		Solution:         tc.Run(dir, req.MemoryLimitKB),
+		SolutionLimits:   tc.limits(dir, req.TimeLimitMs, req.MemoryLimitKB),
+		Interactor:       Command{Name: "python3", Args: []string{interactor}},
+		InteractorLimits: Limits{CPUTime: time.Duration(req.Interactor.TimeLimitMs) * time.Millisecond},
+		QueryLimit:       req.Interactor.QueryLimit,
+	})
+	if res.Interactor.Stderr != "" {
+		log.Printf("Interactor stderr: %s", res.Interactor.Stderr)
+	}
+
+	output, status := "", "success"
+	if o, s, failed := Failure(res.Solution, tc.OutOfMemory...); failed {
+		output, status = o, s
+	}
+	output, status = res.Judge(output, status)
+
+	return ExecResult{
+		Output:            output,
+		Status:            status,
+		ExecutionTimeMs:   res.Solution.CPUTimeMs,
+		WallTimeMs:        res.Solution.WallTimeMs,
+		MemoryUsedKB:      res.Solution.MemoryUsedKB,
+		Transcript:        res.Transcript,
+		InteractorMessage: res.Message,
+	}
+}
+
+// limits are the Limits of one run of the program built in dir.
+func (tc Toolchain) limits(dir string, timeLimitMs, memoryLimitKB int) Limits {
+	limits := Limits{
+		CPUTime:       time.Duration(timeLimitMs) * time.Millisecond,
+		MemoryLimitKB: memoryLimitKB,
+		SandboxDir:    dir,
+	}
+	if tc.CapAddressSpace {
+		limits.AddressSpaceKB = memoryLimitKB
+	}
+	return limits
+}
+
+// Failure classifies a run that did not finish cleanly within its limits.
+// outOfMemory are messages on stderr that mean an allocation failed, such as
+// "std::bad_alloc".
+func Failure(res Result, outOfMemory ...string) (output, status string, failed bool) {
+	if res.SecurityViolation {
+		return "security violation: forbidden system call", "security_violation", true
+	}
+	if res.OutputExceeded {
+		return "output limit exceeded", "output_limit_exceeded", true
+	}
+	// A program can finish over its CPU budget, so check time before the exit status
+	if res.TimedOut {
+		return "time limit exceeded", "time_limit_exceeded", true
+	}
+	if res.Err != nil {
+		if res.MemoryExceeded || containsAny(res.Stderr, outOfMemory) {
+			return "memory limit exceeded", "memory_limit_exceeded", true
+		}
+		if res.Stderr != "" {
+			return res.Stderr, "runtime_error", true
+		}
+		return res.Err.Error(), "runtime_error", true
+	}
+	if res.MemoryExceeded {
+		return "memory limit exceeded", "memory_limit_exceeded", true
+	}
+	return "", "", false
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+// Package runner runs submitted programs for the language executors with
+// resource limits applied, and reports how much time and memory they used.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// rlimitArg marks a re-execution of the executor binary whose only job is to
+// apply resource limits to itself, enter the sandbox if the run has one, and
+// then exec the target program. It is followed by the address space limit,
+// the CPU time limit, the sandbox as "uid:dir", the directories the sandboxed
+// program may write to as a list, and the target program with its arguments.
+const rlimitArg = "__rlimit"
+
+// Limits are the resource limits for a single run.
+type Limits struct {
+	// CPUTime is the CPU time (user + system) the program may use. It is
+	// enforced with RLIMIT_CPU and checked against rusage after the run. Zero
+	// means no limit.
+	CPUTime time.Duration
+	// WallTime kills the program once it has run this long, so one that sleeps
+	// or blocks on input still stops. Zero defaults to twice CPUTime plus a
+	// second when CPUTime is set, and no limit otherwise.
+	WallTime time.Duration
+	// AddressSpaceKB caps the program's virtual memory (RLIMIT_AS), making
+	// allocations beyond it fail. Zero means no cap. Runtimes that reserve
+	// large address ranges up front (JVM, V8) should leave this unset and use
+	// their own heap flags instead.
+	AddressSpaceKB int
+	// MemoryLimitKB is the limit peak RSS is checked against after the run.
+	// Zero disables the check.
+	MemoryLimitKB int
+	// SandboxDir, when set, runs the program in the sandbox (see Sandbox),
+	// where the directory holding its files is all it can see of the
+	// executor's. Leave it unset for trusted programs such as interactors.
+	SandboxDir string
+	// SandboxWritable lets the sandboxed program write to SandboxDir, which it
+	// also starts in, e.g. for a compiler building there.
+	SandboxWritable bool
+	// SandboxCacheDir is a directory kept across sandboxed runs, mounted at the
+	// same path, which they may all write to, e.g. a compiler's cache. Files
+	// runs create in it are writable by later runs.
+	SandboxCacheDir string
+	// OutputLimitKB caps each of the program's stdout and stderr. The program
+	// is killed once it writes past it. Zero means DefaultOutputLimitKB.
+	OutputLimitKB int
+}
+
+// Usage is what a run consumed.
+type Usage struct {
+	CPUTimeMs    int // User + system time
+	WallTimeMs   int
+	MemoryUsedKB int // Peak resident set size
+}
+
+// Result describes a finished run.
+type Result struct {
+	Usage
+	Stdout string
+	Stderr string
+	// Err is the error returned by the process, e.g. a non-zero exit status.
+	Err error
+	// TimedOut is set when the program went over its CPU or wall time limit.
+	TimedOut bool
+	// MemoryExceeded is set when peak RSS went over Limits.MemoryLimitKB or the
+	// program was killed by the kernel OOM killer.
+	MemoryExceeded bool
+	// SecurityViolation is set when the sandbox killed the program for a
+	// forbidden system call, e.g. opening a network socket.
+	SecurityViolation bool
+	// OutputExceeded is set when the program was killed for writing more than
+	// Limits.OutputLimitKB. Stdout and Stderr then hold what it wrote up to
+	// the limit.
+	OutputExceeded bool
+}
+
+// Init must be called at the start of the executor's main. When the executor
+// has re-executed itself to apply limits to a child, Init applies them and
+// replaces the process with the target program; otherwise it returns
+// immediately.
+func Init() {
+	if len(os.Args) < 7 || os.Args[1] != rlimitArg {
+		return
+	}
+	// The sandbox's seccomp filter only applies to the thread installing it,
+	// which must then be the one calling exec
+	runtime.LockOSThread()
+
+	addressSpaceKB, err := strconv.ParseUint(os.Args[2], 10, 64)
+	if err != nil {
+		fail("invalid address space limit %q", os.Args[2])
+	}
+	if addressSpaceKB > 0 {
+		limit := syscall.Rlimit{Cur: addressSpaceKB * 1024, Max: addressSpaceKB * 1024}
+		if err := syscall.Setrlimit(syscall.RLIMIT_AS, &limit); err != nil {
+			fail("setrlimit: %v", err)
+		}
+	}
+
+	cpuSeconds, err := strconv.ParseUint(os.Args[3], 10, 64)
+	if err != nil {
+		fail("invalid CPU time limit %q", os.Args[3])
+	}
+	if cpuSeconds > 0 {
+		// SIGXCPU at the soft limit, SIGKILL a second later if it is ignored
+		limit := syscall.Rlimit{Cur: cpuSeconds, Max: cpuSeconds + 1}
+		if err := syscall.Setrlimit(syscall.RLIMIT_CPU, &limit); err != nil {
+			fail("setrlimit: %v", err)
+		}
+	}
+
+	path, err := exec.LookPath(os.Args[6])
+	if err != nil {
+		fail("%v", err)
+	}
+	env := os.Environ()
+	if os.Args[4] != "" {
+		uid, dir, _ := strings.Cut(os.Args[4], ":")
+		sandboxUID, err := strconv.Atoi(uid)
+		if err != nil {
+			fail("invalid sandbox %q", os.Args[4])
+		}
+		if err := enterSandbox(sandboxUID, dir, filepath.SplitList(os.Args[5])); err != nil {
+			fail("sandbox: %v", err)
+		}
+		env = append(env, "HOME=/tmp", "TMPDIR=/tmp")
+	}
+	err = syscall.Exec(path, os.Args[6:], env)
+	fail("exec %s: %v", path, err)
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "runner: "+format+"\n", args...)
+	os.Exit(127)
+}
+
+// Run executes name with args, feeding it input on stdin, and waits for it to
+// finish or hit its limits.
+func Run(ctx context.Context, input string, limits Limits, name string, args ...string) Result {
+	ctx, cancel := withWallTime(ctx, limits)
+	defer cancel()
+
+	cmd, cleanup, err := command(ctx, limits, name, args...)
+	if err != nil {
+		return Result{Err: err, Stderr: err.Error()}
+	}
+	defer cleanup()
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	stdout, stderr := newOutputBuffer(limits, cancel), newOutputBuffer(limits, cancel)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	return result(ctx, cmd, limits, start, err, stdout.String(), stderr.String(), stdout.exceeded || stderr.exceeded)
+}
+
+// withWallTime bounds ctx by the wall-clock limit of limits.
+func withWallTime(ctx context.Context, limits Limits) (context.Context, context.CancelFunc) {
+	wallTime := limits.WallTime
+	if wallTime == 0 && limits.CPUTime > 0 {
+		wallTime = 2*limits.CPUTime + time.Second
+	}
+	if wallTime > 0 {
+		return context.WithTimeout(ctx, wallTime)
+	}
+	return context.WithCancel(ctx)
+}
+
+// command builds the command for name, going through the rlimit shim when
+// limits need to be applied in the child. cleanup must be called once the
+// command has finished.
+func command(ctx context.Context, limits Limits, name string, args ...string) (cmd *exec.Cmd, cleanup func(), err error) {
+	sandboxed := limits.SandboxDir != "" && Sandboxing.Enabled
+	if limits.AddressSpaceKB == 0 && limits.CPUTime == 0 && !sandboxed {
+		cmd = exec.CommandContext(ctx, name, args...)
+		if limits.SandboxWritable {
+			cmd.Dir = limits.SandboxDir
+		}
+		return cmd, func() {}, nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, nil, err
+	}
+	// RLIMIT_CPU has one-second granularity, so round up and leave the exact
+	// check to the rusage comparison in result
+	cpuSeconds := 0
+	if limits.CPUTime > 0 {
+		cpuSeconds = int((limits.CPUTime + time.Second - 1) / time.Second)
+	}
+	sandbox, writable := "", ""
+	cleanup = func() {}
+	if sandboxed {
+		uid := acquireSandboxUID()
+		sandbox = fmt.Sprintf("%d:%s", uid, limits.SandboxDir)
+		writable = strings.Join(writableDirs(limits), string(filepath.ListSeparator))
+		cleanup = func() {
+			killSandbox(cmd)
+			unshareWritable(limits)
+			releaseSandboxUID(uid)
+		}
+		if err := shareWritable(limits, uid); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+	shimArgs := append([]string{rlimitArg, strconv.Itoa(limits.AddressSpaceKB), strconv.Itoa(cpuSeconds), sandbox, writable, name}, args...)
+	cmd = exec.CommandContext(ctx, self, shimArgs...)
+	if sandboxed {
+		if err := isolate(cmd, limits.SandboxDir); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+	if limits.SandboxWritable {
+		cmd.Dir = limits.SandboxDir
+	}
+	return cmd, cleanup, nil
+}
+
+// result builds the Result of a finished command from its exit state and
+// resource usage. outputExceeded is set when the command was killed for
+// writing past its output limit.
+func result(ctx context.Context, cmd *exec.Cmd, limits Limits, start time.Time, err error, stdout, stderr string, outputExceeded bool) Result {
+	res := Result{
+		Stdout:         stdout,
+		Stderr:         stderr,
+		Err:            err,
+		TimedOut:       errors.Is(ctx.Err(), context.DeadlineExceeded),
+		OutputExceeded: outputExceeded,
+	}
+	res.WallTimeMs = int(time.Since(start).Milliseconds())
+
+	if cmd.ProcessState != nil {
+		if usage, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage); ok {
+			res.CPUTimeMs = int((time.Duration(usage.Utime.Nano()) + time.Duration(usage.Stime.Nano())).Milliseconds())
+			res.MemoryUsedKB = int(usage.Maxrss) // Kilobytes on Linux
+		}
+		if limits.CPUTime > 0 && res.CPUTimeMs > int(limits.CPUTime.Milliseconds()) {
+			res.TimedOut = true
+		}
+		if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() && !res.TimedOut {
+			switch status.Signal() {
+			case syscall.SIGXCPU:
+				res.TimedOut = true
+			case syscall.SIGSYS:
+				// What the seccomp filter kills forbidden calls with
+				res.SecurityViolation = true
+			case syscall.SIGKILL:
+				// Nothing but the kernel OOM killer sends SIGKILL to a run
+				// that is within its time and output limits
+				res.MemoryExceeded = !res.OutputExceeded
+			}
+		}
+	}
+	if limits.MemoryLimitKB > 0 && res.MemoryUsedKB > limits.MemoryLimitKB {
+		res.MemoryExceeded = true
+	}
+
+	return res
+}
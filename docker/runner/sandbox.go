@@ -0,0 +1,324 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Sandbox is how runs with Limits.SandboxDir set are isolated from the
+// executor and from each other. Each run gets an unprivileged UID no other
+// run is using, an empty network namespace, a read-only view of the
+// filesystem with a fresh tmpfs on /tmp, and a seccomp filter killing it on
+// forbidden system calls (see deniedSyscalls). Entering the sandbox needs
+// root with CAP_SYS_ADMIN, which the executor containers are given.
+type Sandbox struct {
+	// Enabled is cleared with EXECUTOR_SANDBOX=off, e.g. to run an executor
+	// outside its container without root. SandboxDir is then ignored.
+	Enabled bool
+	// Runs get UIDs from [UIDBase, UIDBase+UIDCount). Executors sharing a host
+	// need ranges that do not overlap, as RLIMIT_NPROC is counted per UID
+	// across the host. UIDBase is set with SANDBOX_UID_BASE
+	UIDBase  int
+	UIDCount int
+	// TmpfsSizeKB is the size of the run's /tmp
+	TmpfsSizeKB int
+	// MaxProcesses caps the run's processes and threads (RLIMIT_NPROC), so a
+	// fork bomb runs out of processes rather than taking the host down
+	MaxProcesses int
+	// MaxOpenFiles caps the run's file descriptors (RLIMIT_NOFILE)
+	MaxOpenFiles int
+	// MaxFileSizeKB caps the size of a file the run writes (RLIMIT_FSIZE)
+	MaxFileSizeKB int
+}
+
+// Sandboxing is the Sandbox runs are isolated with.
+var Sandboxing = Sandbox{
+	Enabled:       true,
+	UIDBase:       20000,
+	UIDCount:      1000,
+	TmpfsSizeKB:   64 * 1024,
+	MaxProcesses:  128, // The JVM starts dozens of threads
+	MaxOpenFiles:  256,
+	MaxFileSizeKB: 16 * 1024,
+}
+
+func init() {
+	if v := os.Getenv("EXECUTOR_SANDBOX"); v == "off" || v == "false" || v == "0" {
+		Sandboxing.Enabled = false
+	}
+	if v, err := strconv.Atoi(os.Getenv("SANDBOX_UID_BASE")); err == nil && v > 0 {
+		Sandboxing.UIDBase = v
+	}
+}
+
+// sandboxUIDs holds the UIDs no run is using, least recently used first, so
+// a UID is not reused while anything its last run left behind is being
+// killed.
+var (
+	sandboxUIDsOnce sync.Once
+	sandboxUIDs     chan int
+)
+
+// acquireSandboxUID takes a UID for a run, waiting for one to be released if
+// all are in use.
+func acquireSandboxUID() int {
+	sandboxUIDsOnce.Do(func() {
+		sandboxUIDs = make(chan int, Sandboxing.UIDCount)
+		for i := 0; i < Sandboxing.UIDCount; i++ {
+			sandboxUIDs <- Sandboxing.UIDBase + i
+		}
+	})
+	return <-sandboxUIDs
+}
+
+func releaseSandboxUID(uid int) {
+	sandboxUIDs <- uid
+}
+
+// isolate makes cmd, the shim of a sandboxed run of the program in dir, start
+// in new namespaces and its own process group, which killSandbox kills as a
+// whole. The shim can only drop to the run's UID once dir is readable by it.
+func isolate(cmd *exec.Cmd, dir string) error {
+	if err := os.Chmod(dir, 0755); err != nil {
+		return err
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNET | syscall.CLONE_NEWNS | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		Setpgid:    true,
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// Processes the program leaves behind may hold its stdout open, which
+	// would otherwise keep Wait waiting for them
+	cmd.WaitDelay = 100 * time.Millisecond
+	return nil
+}
+
+// killSandbox kills whatever the sandboxed run of cmd left running. The
+// seccomp filter keeps its processes from leaving the process group.
+func killSandbox(cmd *exec.Cmd) {
+	if cmd != nil && cmd.Process != nil {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// writableDirs lists the directories a sandboxed run with limits may write
+// to.
+func writableDirs(limits Limits) []string {
+	var dirs []string
+	if limits.SandboxWritable {
+		dirs = append(dirs, limits.SandboxDir)
+	}
+	if limits.SandboxCacheDir != "" {
+		dirs = append(dirs, limits.SandboxCacheDir)
+	}
+	return dirs
+}
+
+// shareWritable gives the run's UID, uid, write access to the directories of
+// limits it may write to. SandboxDir is given back by unshareWritable.
+func shareWritable(limits Limits, uid int) error {
+	if limits.SandboxCacheDir != "" {
+		if err := os.MkdirAll(limits.SandboxCacheDir, 0777); err != nil {
+			return err
+		}
+		if err := os.Chmod(limits.SandboxCacheDir, 0777); err != nil {
+			return err
+		}
+	}
+	if limits.SandboxWritable {
+		return os.Chown(limits.SandboxDir, uid, uid)
+	}
+	return nil
+}
+
+// unshareWritable gives SandboxDir back to the executor once the run that
+// could write to it is over, so the next run in it cannot.
+func unshareWritable(limits Limits) {
+	if limits.SandboxWritable {
+		_ = os.Chown(limits.SandboxDir, os.Getuid(), os.Getgid())
+	}
+}
+
+// enterSandbox is run by the shim, as root, before it execs the program of a
+// sandboxed run. It makes every mount read-only, mounts a tmpfs on /tmp with
+// dir mounted read-only at the same path within it, applies the sandbox's
+// rlimits, drops to uid and installs the seccomp filter. Directories in
+// writable, dir among them or not, are mounted writable at their paths, and
+// a program that may write to dir starts in it.
+func enterSandbox(uid int, dir string, writable []string) error {
+	// Nothing done to the mounts of the new namespace may reach the executor's
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	// dir and writable are hidden by the tmpfs once it is mounted, so keep a
+	// handle on each
+	dirFile, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer dirFile.Close()
+	dirWritable := false
+	var writableFiles []*os.File
+	for _, path := range writable {
+		if path == dir {
+			dirWritable = true
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		writableFiles = append(writableFiles, f)
+	}
+
+	mountPoints, err := readMountPoints()
+	if err != nil {
+		return err
+	}
+	for _, mountPoint := range mountPoints {
+		if err := remount(mountPoint, true); err != nil {
+			return err
+		}
+	}
+
+	tmpfsOptions := fmt.Sprintf("size=%dk,mode=1777", Sandboxing.TmpfsSizeKB)
+	if err := syscall.Mount("tmpfs", "/tmp", "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, tmpfsOptions); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+	if err := bindMount(dirFile, !dirWritable); err != nil {
+		return err
+	}
+	for _, f := range writableFiles {
+		if err := bindMount(f, false); err != nil {
+			return err
+		}
+	}
+	if len(writableFiles) > 0 {
+		// Later runs, with other UIDs, must be able to write what this one adds
+		syscall.Umask(0)
+	}
+	workDir := "/tmp"
+	if dirWritable {
+		workDir = dir
+	}
+	if err := os.Chdir(workDir); err != nil {
+		return err
+	}
+
+	rlimits := []struct {
+		resource int
+		value    uint64
+	}{
+		{rlimitNproc, uint64(Sandboxing.MaxProcesses)},
+		{syscall.RLIMIT_NOFILE, uint64(Sandboxing.MaxOpenFiles)},
+		{syscall.RLIMIT_FSIZE, uint64(Sandboxing.MaxFileSizeKB) * 1024},
+		{syscall.RLIMIT_CORE, 0},
+	}
+	for _, rlimit := range rlimits {
+		limit := syscall.Rlimit{Cur: rlimit.value, Max: rlimit.value}
+		if err := syscall.Setrlimit(rlimit.resource, &limit); err != nil {
+			return fmt.Errorf("setrlimit: %w", err)
+		}
+	}
+
+	if err := syscall.Setgroups(nil); err != nil {
+		return fmt.Errorf("setgroups: %w", err)
+	}
+	if err := syscall.Setgid(uid); err != nil {
+		return fmt.Errorf("setgid: %w", err)
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return fmt.Errorf("setuid: %w", err)
+	}
+	return installSeccompFilter()
+}
+
+// bindMount mounts the directory open as f at its own path, which it may be
+// hidden under, read-only or not.
+func bindMount(f *os.File, readOnly bool) error {
+	if err := os.MkdirAll(f.Name(), 0755); err != nil {
+		return err
+	}
+	handle := fmt.Sprintf("/proc/self/fd/%d", f.Fd())
+	if err := syscall.Mount(handle, f.Name(), "", syscall.MS_BIND, ""); err != nil {
+		return fmt.Errorf("mount %s: %w", f.Name(), err)
+	}
+	// The bind mount starts out as read-only as its source now is
+	return remount(f.Name(), readOnly)
+}
+
+// rlimitNproc is RLIMIT_NPROC, which package syscall does not define.
+const rlimitNproc = 6
+
+// readMountPoints lists the mount points of the shim's mount namespace.
+func readMountPoints() ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mountPoints []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// "36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mountPoints = append(mountPoints, unescapeMountPoint(fields[4]))
+	}
+	return mountPoints, scanner.Err()
+}
+
+// unescapeMountPoint undoes the octal escapes of spaces, tabs, newlines and
+// backslashes in mountinfo.
+func unescapeMountPoint(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// remount makes the mount at mountPoint read-only or writable, keeping its
+// other flags, such as nosuid, as they are.
+func remount(mountPoint string, readOnly bool) error {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(mountPoint, &fs); err != nil {
+		// Mount points under one hidden by a later mount cannot be reached
+		if os.IsNotExist(err) || err == syscall.EACCES {
+			return nil
+		}
+		return fmt.Errorf("statfs %s: %w", mountPoint, err)
+	}
+	// The ST_ flags statfs reports have the values of the matching MS_ flags
+	kept := uintptr(fs.Flags) & (syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC |
+		syscall.MS_NOATIME | syscall.MS_NODIRATIME | syscall.MS_RELATIME)
+	flags := syscall.MS_REMOUNT | syscall.MS_BIND | kept
+	if readOnly {
+		flags |= syscall.MS_RDONLY
+	}
+	if err := syscall.Mount("", filepath.Clean(mountPoint), "", flags, ""); err != nil {
+		return fmt.Errorf("remount %s: %w", mountPoint, err)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The sandbox's shim is a re-execution of the test binary.
+func TestMain(m *testing.M) {
+	Init()
+	os.Exit(m.Run())
+}
+
+// runSandboxed runs a Python program in the sandbox, with the limits the
+// executors give submissions.
+func runSandboxed(t *testing.T, program string) Result {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("entering the sandbox needs root")
+	}
+	python := sandboxPython()
+	if python == "" {
+		t.Skip("python3 is not installed")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "code.py")
+	if err := os.WriteFile(script, []byte(program), 0644); err != nil {
+		t.Fatal(err)
+	}
+	limits := Limits{
+		CPUTime:        2 * time.Second,
+		AddressSpaceKB: 512 * 1024,
+		SandboxDir:     dir,
+	}
+	res := Run(context.Background(), "", limits, python, script)
+	if strings.HasPrefix(res.Stderr, "runner: sandbox:") {
+		t.Skipf("cannot enter the sandbox here: %s", res.Stderr)
+	}
+	return res
+}
+
+// sandboxPython finds a python3 the sandbox's UIDs can run, as one installed
+// for root alone, e.g. by pyenv, is not readable by them.
+func sandboxPython() string {
+	for _, path := range []string{"/usr/local/bin/python3", "/usr/bin/python3"} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	path, _ := exec.LookPath("python3")
+	return path
+}
+
+func TestSandboxRunsOrdinaryPrograms(t *testing.T) {
+	res := runSandboxed(t, `
+import os, sys
+with open("/tmp/scratch.txt", "w") as f:
+    f.write("scratch")
+print(os.getuid(), open("/tmp/scratch.txt").read(), sys.stdin.read() == "")
+`)
+	if res.Err != nil || res.SecurityViolation {
+		t.Fatalf("run failed: %v, stderr %q", res.Err, res.Stderr)
+	}
+	fields := strings.Fields(res.Stdout)
+	if len(fields) != 3 || fields[1] != "scratch" {
+		t.Fatalf("output = %q", res.Stdout)
+	}
+	if uid, _ := strconv.Atoi(fields[0]); uid < Sandboxing.UIDBase || uid >= Sandboxing.UIDBase+Sandboxing.UIDCount {
+		t.Errorf("program ran as UID %s, want one of the sandbox's", fields[0])
+	}
+	if _, err := os.Stat("/tmp/scratch.txt"); err == nil {
+		t.Errorf("the program's /tmp is the executor's")
+	}
+}
+
+func TestSandboxDeniesSockets(t *testing.T) {
+	res := runSandboxed(t, `
+import socket
+s = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
+s.connect(("1.1.1.1", 80))
+print("connected")
+`)
+	if !res.SecurityViolation {
+		t.Errorf("opening a socket is not a security violation: err %v, stdout %q, stderr %q", res.Err, res.Stdout, res.Stderr)
+	}
+}
+
+func TestSandboxDeniesNamespaces(t *testing.T) {
+	res := runSandboxed(t, `
+import ctypes
+ctypes.CDLL(None).unshare(0x10000000)  # CLONE_NEWUSER
+print("unshared")
+`)
+	if !res.SecurityViolation {
+		t.Errorf("unshare is not a security violation: err %v, stdout %q, stderr %q", res.Err, res.Stdout, res.Stderr)
+	}
+}
+
+func TestSandboxFilesystemIsReadOnly(t *testing.T) {
+	target := "/var/tmp/sandbox-escape-" + strconv.Itoa(os.Getpid())
+	defer os.Remove(target)
+
+	res := runSandboxed(t, `
+import os, sys
+failures = 0
+for path in [sys.argv[0] + ".out", "/etc/sandbox-escape", "`+target+`"]:
+    try:
+        with open(path, "w") as f:
+            f.write("escaped")
+        print("wrote", path)
+    except OSError as e:
+        failures += 1
+print("failures", failures)
+`)
+	if res.Err != nil {
+		t.Fatalf("run failed: %v, stderr %q", res.Err, res.Stderr)
+	}
+	if !strings.Contains(res.Stdout, "failures 3") {
+		t.Errorf("program wrote outside /tmp: %q", res.Stdout)
+	}
+	if _, err := os.Stat(target); err == nil {
+		t.Errorf("%s was written", target)
+	}
+}
+
+func TestSandboxHidesOtherRuns(t *testing.T) {
+	other := t.TempDir()
+	secret := filepath.Join(other, "secret.txt")
+	if err := os.WriteFile(secret, []byte("expected output"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res := runSandboxed(t, `
+try:
+    print(open("`+secret+`").read())
+except OSError:
+    print("hidden")
+`)
+	if strings.TrimSpace(res.Stdout) != "hidden" {
+		t.Errorf("program read another run's files: %q, stderr %q", res.Stdout, res.Stderr)
+	}
+}
+
+func TestSandboxContainsForkBombs(t *testing.T) {
+	start := time.Now()
+	res := runSandboxed(t, `
+import os
+while True:
+    try:
+        os.fork()
+    except OSError:
+        pass
+`)
+	if res.Err == nil {
+		t.Errorf("fork bomb finished cleanly")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("fork bomb ran for %v", elapsed)
+	}
+	// Everything the bomb started was killed with it
+	time.Sleep(100 * time.Millisecond)
+	if n := sandboxProcesses(t); n > 0 {
+		t.Errorf("%d processes of the fork bomb are still running", n)
+	}
+}
+
+func TestSandboxLimitsFiles(t *testing.T) {
+	res := runSandboxed(t, `
+files = []
+try:
+    for i in range(10000):
+        files.append(open("/tmp/f%d" % i, "w"))
+except OSError:
+    print("open files capped at", len(files))
+`)
+	if !strings.Contains(res.Stdout, "open files capped") {
+		t.Errorf("open files were not capped: %q, stderr %q", res.Stdout, res.Stderr)
+	}
+
+	res = runSandboxed(t, `
+with open("/tmp/big", "w") as f:
+    for i in range(100):
+        f.write("x" * (1024 * 1024))
+print("wrote 100 MB")
+`)
+	if res.Err == nil || strings.Contains(res.Stdout, "wrote") {
+		t.Errorf("file size was not capped: %q", res.Stdout)
+	}
+}
+
+// sandboxProcesses counts the running processes with a sandbox UID.
+func sandboxProcesses(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, entry := range entries {
+		status, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "status"))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(status), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || fields[0] != "Uid:" {
+				continue
+			}
+			uid, _ := strconv.Atoi(fields[1])
+			if uid >= Sandboxing.UIDBase && uid < Sandboxing.UIDBase+Sandboxing.UIDCount && !strings.Contains(string(status), "State:\tZ") {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// Actions of a seccomp filter, see seccomp(2).
+const (
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000 // The low 16 bits are the errno
+	seccompRetAllow       = 0x7fff0000
+)
+
+const (
+	prSetNoNewPrivs   = 38
+	prSetSeccomp      = 22
+	seccompModeFilter = 2
+)
+
+// Offsets into struct seccomp_data, the input of a seccomp filter. Arguments
+// are 64-bit; the low 32 bits come first on the little-endian architectures
+// the executors run on.
+const (
+	seccompDataNr   = 0
+	seccompDataArch = 4
+	seccompDataArg0 = 16
+)
+
+// namespaceCloneFlags are the clone flags creating namespaces, which could be
+// used to get out of the sandbox's.
+const namespaceCloneFlags = syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS | syscall.CLONE_NEWIPC |
+	syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID | syscall.CLONE_NEWNET | 0x02000000 // CLONE_NEWCGROUP
+
+// deniedSyscalls are killed with SIGSYS, which the run reports as a security
+// violation. They either reach outside the sandbox (sockets other than Unix
+// ones, tracing other processes, namespaces) or are for administering the
+// system, which the run's UID could not do anyway.
+var deniedSyscalls = []string{
+	"ptrace", "process_vm_readv", "process_vm_writev",
+	"mount", "umount2", "pivot_root", "chroot", "unshare", "setns",
+	"open_tree", "move_mount", "fsopen", "fsconfig", "fsmount", "fspick", "mount_setattr",
+	"kexec_load", "kexec_file_load", "init_module", "finit_module", "delete_module",
+	"reboot", "swapon", "swapoff", "acct", "quotactl", "syslog",
+	"settimeofday", "clock_settime", "open_by_handle_at",
+	"bpf", "perf_event_open", "userfaultfd", "keyctl", "add_key", "request_key",
+}
+
+// failedSyscalls fail with the given errno rather than killing the run, as
+// ordinary programs make them. A process leaving its process group would
+// survive the run being killed; clone3 cannot be filtered on its flags, and
+// the C library falls back to clone when it is not implemented.
+var failedSyscalls = []struct {
+	name  string
+	errno syscall.Errno
+}{
+	{"setsid", syscall.EPERM},
+	{"setpgid", syscall.EPERM},
+	{"clone3", syscall.ENOSYS},
+}
+
+// installSeccompFilter installs the sandbox's seccomp filter on the calling
+// thread, which the program it then execs inherits.
+func installSeccompFilter() error {
+	filter, err := seccompFilter()
+	if err != nil {
+		return err
+	}
+	// Needed to install a filter without CAP_SYS_ADMIN, and keeps setuid
+	// binaries from regaining privileges
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("prctl(PR_SET_NO_NEW_PRIVS): %w", errno)
+	}
+	prog := syscall.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&prog)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("prctl(PR_SET_SECCOMP): %w", errno)
+	}
+	return nil
+}
+
+// seccompFilter assembles the BPF program of the filter. Every rule loads the
+// syscall number first, as the rules checking arguments overwrite it.
+func seccompFilter() ([]syscall.SockFilter, error) {
+	var filter []syscall.SockFilter
+	stmt := func(code uint16, k uint32) {
+		filter = append(filter, syscall.SockFilter{Code: code, K: k})
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) {
+		filter = append(filter, syscall.SockFilter{Code: code, Jt: jt, Jf: jf, K: k})
+	}
+	loadNr := func() { stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataNr) }
+	number := func(name string) (uint32, error) {
+		nr, ok := syscallNumbers[name]
+		if !ok {
+			return 0, fmt.Errorf("no syscall number for %s on this architecture", name)
+		}
+		return nr, nil
+	}
+
+	// Syscall numbers are only meaningful for the architecture they are for
+	stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataArch)
+	jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, auditArch, 1, 0)
+	stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess)
+	loadNr()
+	if x32SyscallBit != 0 {
+		jump(syscall.BPF_JMP|syscall.BPF_JSET|syscall.BPF_K, x32SyscallBit, 0, 1)
+		stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess)
+	}
+
+	for _, name := range deniedSyscalls {
+		nr, err := number(name)
+		if err != nil {
+			return nil, err
+		}
+		jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, nr, 0, 1)
+		stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess)
+	}
+	for _, failed := range failedSyscalls {
+		nr, err := number(failed.name)
+		if err != nil {
+			return nil, err
+		}
+		jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, nr, 0, 1)
+		stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetErrno|uint32(failed.errno))
+	}
+
+	// socket: only Unix sockets, which the C library uses to talk to local
+	// daemons; the network namespace has no interface but loopback
+	socket, err := number("socket")
+	if err != nil {
+		return nil, err
+	}
+	jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, socket, 0, 4)
+	stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataArg0)
+	jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, syscall.AF_UNIX, 1, 0)
+	stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess)
+	stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetAllow)
+	loadNr()
+
+	// clone: threads and processes, but no new namespaces
+	clone, err := number("clone")
+	if err != nil {
+		return nil, err
+	}
+	jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, clone, 0, 4)
+	stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataArg0)
+	jump(syscall.BPF_JMP|syscall.BPF_JSET|syscall.BPF_K, namespaceCloneFlags, 0, 1)
+	stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess)
+	stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetAllow)
+
+	stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetAllow)
+	return filter, nil
+}
@@ -0,0 +1,54 @@
+package runner
+
+// auditArch is AUDIT_ARCH_X86_64, which seccomp reports x86-64 syscalls with.
+const auditArch = 0xc000003e
+
+// x32SyscallBit marks syscalls of the x32 ABI, which have numbers of their
+// own and are denied outright.
+const x32SyscallBit = 0x40000000
+
+// syscallNumbers are the x86-64 numbers of the syscalls the seccomp filter
+// checks, many of which package syscall does not define.
+var syscallNumbers = map[string]uint32{
+	"socket":            41,
+	"clone":             56,
+	"ptrace":            101,
+	"syslog":            103,
+	"setpgid":           109,
+	"setsid":            112,
+	"chroot":            161,
+	"acct":              163,
+	"settimeofday":      164,
+	"mount":             165,
+	"umount2":           166,
+	"swapon":            167,
+	"swapoff":           168,
+	"reboot":            169,
+	"init_module":       175,
+	"delete_module":     176,
+	"quotactl":          179,
+	"pivot_root":        155,
+	"clock_settime":     227,
+	"add_key":           248,
+	"request_key":       249,
+	"keyctl":            250,
+	"kexec_load":        246,
+	"unshare":           272,
+	"perf_event_open":   298,
+	"open_by_handle_at": 304,
+	"setns":             308,
+	"process_vm_readv":  310,
+	"process_vm_writev": 311,
+	"finit_module":      313,
+	"kexec_file_load":   320,
+	"bpf":               321,
+	"userfaultfd":       323,
+	"open_tree":         428,
+	"move_mount":        429,
+	"fsopen":            430,
+	"fsconfig":          431,
+	"fsmount":           432,
+	"fspick":            433,
+	"clone3":            435,
+	"mount_setattr":     442,
+}
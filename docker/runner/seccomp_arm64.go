@@ -0,0 +1,53 @@
+package runner
+
+// auditArch is AUDIT_ARCH_AARCH64, which seccomp reports arm64 syscalls with.
+const auditArch = 0xc00000b7
+
+// x32SyscallBit is only meaningful on x86-64.
+const x32SyscallBit = 0
+
+// syscallNumbers are the arm64 numbers of the syscalls the seccomp filter
+// checks, many of which package syscall does not define.
+var syscallNumbers = map[string]uint32{
+	"umount2":           39,
+	"mount":             40,
+	"pivot_root":        41,
+	"chroot":            51,
+	"quotactl":          60,
+	"acct":              89,
+	"unshare":           97,
+	"kexec_load":        104,
+	"init_module":       105,
+	"delete_module":     106,
+	"clock_settime":     112,
+	"syslog":            116,
+	"ptrace":            117,
+	"reboot":            142,
+	"setpgid":           154,
+	"setsid":            157,
+	"settimeofday":      170,
+	"socket":            198,
+	"add_key":           217,
+	"request_key":       218,
+	"keyctl":            219,
+	"clone":             220,
+	"swapon":            224,
+	"swapoff":           225,
+	"perf_event_open":   241,
+	"open_by_handle_at": 265,
+	"setns":             268,
+	"process_vm_readv":  270,
+	"process_vm_writev": 271,
+	"finit_module":      273,
+	"bpf":               280,
+	"userfaultfd":       282,
+	"kexec_file_load":   294,
+	"open_tree":         428,
+	"move_mount":        429,
+	"fsopen":            430,
+	"fsconfig":          431,
+	"fsmount":           432,
+	"fspick":            433,
+	"clone3":            435,
+	"mount_setattr":     442,
+}
@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Signature is the typed signature of the function a problem asks for. When a
+// request carries one, the executor generates the harness around the user's
+// code itself instead of using parser code: each test input holds one JSON
+// value per parameter, one per line and in order, and the function's result
+// is printed as one line of canonical JSON, see Type.
+type Signature struct {
+	FunctionName string  `json:"function_name"`
+	Params       []Param `json:"params"`
+	ReturnType   string  `json:"return_type"`
+}
+
+// Param is one parameter of a Signature.
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Kind is the kind of a Type.
+type Kind string
+
+const (
+	KindInt       Kind = "int"
+	KindLong      Kind = "long"
+	KindDouble    Kind = "double"
+	KindBool      Kind = "bool"
+	KindString    Kind = "string"
+	KindArray     Kind = "array" // T[]
+	KindList      Kind = "list"  // List<T>, the same JSON as T[]
+	KindListNode  Kind = "ListNode"
+	KindTreeNode  Kind = "TreeNode"
+	KindGraphNode Kind = "GraphNode"
+)
+
+// Type is a parameter or return type of a Signature. In JSON, numbers and
+// booleans are plain values, strings are JSON strings, arrays and lists are
+// JSON arrays, a ListNode is the array of its values and a TreeNode is its
+// level-order array with null for missing children, e.g. [1,null,2,3]. A
+// GraphNode is the adjacency list of its graph: node i+1 has value i+1 and
+// its neighbors' values at index i, e.g. [[2,3],[1],[1]], and stands for
+// node 1. Doubles are printed with five decimals.
+type Type struct {
+	Kind Kind  `json:"kind"`
+	Elem *Type `json:"elem,omitempty"` // Element type of arrays and lists
+}
+
+// scalarTypes maps the accepted names of non-container types to their kind.
+// Java's boxed names are accepted so List<Integer> reads naturally.
+var scalarTypes = map[string]Kind{
+	"int":       KindInt,
+	"Integer":   KindInt,
+	"long":      KindLong,
+	"Long":      KindLong,
+	"double":    KindDouble,
+	"Double":    KindDouble,
+	"bool":      KindBool,
+	"boolean":   KindBool,
+	"Boolean":   KindBool,
+	"string":    KindString,
+	"String":    KindString,
+	"ListNode":  KindListNode,
+	"TreeNode":  KindTreeNode,
+	"GraphNode": KindGraphNode,
+}
+
+// ParseType parses a type name such as "int", "int[][]", "List<String>" or
+// "TreeNode".
+func ParseType(name string) (*Type, error) {
+	name = strings.TrimSpace(name)
+	if strings.HasSuffix(name, "[]") {
+		elem, err := ParseType(strings.TrimSuffix(name, "[]"))
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Kind: KindArray, Elem: elem}, nil
+	}
+	if strings.HasPrefix(name, "List<") && strings.HasSuffix(name, ">") {
+		elem, err := ParseType(name[len("List<") : len(name)-1])
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Kind: KindList, Elem: elem}, nil
+	}
+	if kind, ok := scalarTypes[name]; ok {
+		return &Type{Kind: kind}, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", name)
+}
+
+// String returns the canonical name of t, e.g. "int[]" or "List<string>".
+func (t *Type) String() string {
+	switch t.Kind {
+	case KindArray:
+		return t.Elem.String() + "[]"
+	case KindList:
+		return "List<" + t.Elem.String() + ">"
+	default:
+		return string(t.Kind)
+	}
+}
+
+// Container reports whether t is an array or a list.
+func (t *Type) Container() bool {
+	return t.Kind == KindArray || t.Kind == KindList
+}
+
+// Types parses the parameter and return types of s, checking that s
+// describes a function that can be called.
+func (s Signature) Types() (params []*Type, ret *Type, err error) {
+	if !isIdentifier(s.FunctionName) {
+		return nil, nil, fmt.Errorf("invalid function name %q", s.FunctionName)
+	}
+	seen := make(map[string]bool)
+	for _, p := range s.Params {
+		if !isIdentifier(p.Name) {
+			return nil, nil, fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		if seen[p.Name] {
+			return nil, nil, fmt.Errorf("duplicate parameter %q", p.Name)
+		}
+		seen[p.Name] = true
+		t, err := ParseType(p.Type)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		params = append(params, t)
+	}
+	ret, err = ParseType(s.ReturnType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("return type: %w", err)
+	}
+	return params, ret, nil
+}
+
+// isIdentifier reports whether s is a name every supported language accepts
+// for a function or variable.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"net/http"
+	"os"
+)
+
+// VersionHeader is the response header executors report Version in.
+const VersionHeader = "X-Executor-Version"
+
+// Version identifies the executor build, so a verdict can be traced back to
+// the executor that produced it. It is set through EXECUTOR_VERSION, which
+// the images take from the build argument of the same name.
+var Version = "dev"
+
+func init() {
+	if v := os.Getenv("EXECUTOR_VERSION"); v != "" {
+		Version = v
+	}
+}
+
+// WithVersion adds the VersionHeader to every response of next.
+func WithVersion(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(VersionHeader, Version)
+		next(w, r)
+	}
+}
+
+// Addr returns the address executors listen on: :8080, the port of the
+// images, unless EXECUTOR_ADDR is set, as the backend does for the executors
+// it starts on the developer's machine.
+func Addr() string {
+	if addr := os.Getenv("EXECUTOR_ADDR"); addr != "" {
+		return addr
+	}
+	return ":8080"
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"path/filepath"
+
+	"runner"
+)
+
+// compileFlags are passed to rustc before the source file.
+var compileFlags = []string{"-O", "--edition", "2021"}
+
+// Submissions are complete programs built as one crate with optimizations.
+// Allocations past the memory limit fail, which aborts the program.
+func main() {
+	runner.Serve(runner.Toolchain{
+		Name:       "Rust",
+		SourceFile: "main.rs",
+		Compile: func(dir string) runner.Command {
+			return runner.Command{Name: "rustc", Args: append(compileFlags, "--error-format=short", "-o", "main", "main.rs")}
+		},
+		CompileCapAddressSpace: true,
+		Diagnostics:            runner.ParseRustcDiagnostics,
+		Run: func(dir string, memoryLimitKB int) runner.Command {
+			return runner.Command{Name: filepath.Join(dir, "main")}
+		},
+		CapAddressSpace: true,
+		OutOfMemory:     []string{"memory allocation of"},
+		Info: runner.Info{
+			Language:       "rust",
+			DisplayName:    "Rust",
+			CompileFlags:   compileFlags,
+			Template:       template,
+			TimeMultiplier: 1,
+		},
+		VersionCommand: []string{"rustc", "--version"},
+	})
+}
+
+const template = `use std::io::{self, Read};
+
+fn main() {
+    let mut input = String::new();
+    io::stdin().read_to_string(&mut input).unwrap();
+    let mut tokens = input.split_whitespace();
+    let n: i64 = tokens.next().unwrap().parse().unwrap();
+    println!("{}", n);
+}
+`
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"runner"
+)
+
+// typeRoots is where the image installs @types/node, so programs can use
+// require and process.stdin.
+const typeRoots = "/usr/local/lib/node_modules/@types"
+
+// compileFlags are passed to tsc before the source file.
+var compileFlags = []string{"--target", "es2022", "--module", "commonjs"}
+
+// Submissions are complete programs, type-checked and compiled to CommonJS by
+// tsc, then run with node. Like JavaScript, the heap is capped with
+// --max-old-space-size.
+func main() {
+	runner.Serve(runner.Toolchain{
+		Name:       "TypeScript",
+		SourceFile: "main.ts",
+		Compile: func(dir string) runner.Command {
+			// tsc is a node script, run with node so its heap can be capped
+			tsc, _ := exec.LookPath("tsc")
+			args := []string{fmt.Sprintf("--max-old-space-size=%d", runner.CompileMemoryLimitKB/1024), tsc}
+			args = append(args, "--pretty", "false", "--noEmitOnError", "--typeRoots", typeRoots, "--types", "node")
+			return runner.Command{Name: "node", Args: append(append(args, compileFlags...), "main.ts")}
+		},
+		Diagnostics: runner.ParseTscDiagnostics,
+		Run: func(dir string, memoryLimitKB int) runner.Command {
+			args := []string{filepath.Join(dir, "main.js")}
+			if memoryLimitKB > 0 {
+				args = append([]string{fmt.Sprintf("--max-old-space-size=%d", max(memoryLimitKB/1024, 1))}, args...)
+			}
+			return runner.Command{Name: "node", Args: args}
+		},
+		OutOfMemory: []string{"heap out of memory"},
+		Info: runner.Info{
+			Language:       "typescript",
+			DisplayName:    "TypeScript",
+			CompileFlags:   compileFlags,
+			Template:       template,
+			TimeMultiplier: 1,
+		},
+		VersionCommand: []string{"tsc", "--version"},
+	})
+}
+
+const template = `import * as fs from 'fs';
+
+const lines = fs.readFileSync(0, 'utf8').trim().split('\n');
+console.log(lines[0]);
+`
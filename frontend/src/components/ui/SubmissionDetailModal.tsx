@@ -2,6 +2,7 @@ import React, { useState } from 'react';
 import { X, Code, Clock, User, FileText, AlertCircle, CheckCircle, XCircle, Copy, Download } from 'lucide-react';
 import { useTheme } from '@/providers/ThemeProvider';
 import AnimatedButton from './AnimatedButton';
+import { fileExtension } from '@/lib/languages';
 
 interface SubmissionDetailModalProps {
     isOpen: boolean;
@@ -90,24 +91,13 @@ const SubmissionDetailModal: React.FC<SubmissionDetailModalProps> = ({
             const element = document.createElement('a');
             const file = new Blob([submission.code], { type: 'text/plain' });
             element.href = URL.createObjectURL(file);
-            element.download = `submission_${submission.id}.${getFileExtension(submission.language)}`;
+            element.download = `submission_${submission.id}.${fileExtension(submission.language)}`;
             document.body.appendChild(element);
             element.click();
             document.body.removeChild(element);
         }
     };
 
-    const getFileExtension = (language: string) => {
-        const extensions: { [key: string]: string } = {
-            'python': 'py',
-            'javascript': 'js',
-            'cpp': 'cpp',
-            'java': 'java',
-            'c': 'c'
-        };
-        return extensions[language.toLowerCase()] || 'txt';
-    };
-
     return (
         <div className="fixed inset-0 z-50 overflow-y-auto">
             <div className="flex items-center justify-center min-h-screen px-4 pt-4 pb-20 text-center sm:p-0">
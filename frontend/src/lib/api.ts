@@ -177,6 +177,23 @@ export const getLastCode = async (problemId: string, language: string) => {
   return get(`/last-code?problem_id=${problemId}&language=${language}`);
 };
 
+// A language as reported by GET /api/languages, with what its executor
+// reported about its toolchain
+export interface LanguageInfo {
+  name: string;
+  display_name: string;
+  version: string;
+  time_multiplier: number;
+  compile_command?: string;
+  compile_flags?: string[];
+  template?: string;        // Code new solutions start from
+  available: boolean;       // The executor answered its last check
+}
+
+export const getLanguages = async () => {
+  return get<{ languages: LanguageInfo[] }>('/api/languages');
+};
+
 export const getRateLimits = async () => {
   return get('/api/rate-limits');
 };
@@ -0,0 +1,44 @@
+/**
+ * The languages submissions can be written in. This mirrors the backend's
+ * registry in backend/internal/languages, which is what the judge accepts.
+ */
+export interface Language {
+  name: string;       // Sent with submissions, e.g. 'cpp'
+  label: string;      // Shown to users, e.g. 'C++'
+  monaco: string;     // Monaco editor language id
+  extension: string;  // Without the dot
+  // Submissions are complete programs reading the input from stdin
+  completePrograms: boolean;
+}
+
+export const LANGUAGES: Language[] = [
+  { name: 'python', label: 'Python', monaco: 'python', extension: 'py', completePrograms: false },
+  { name: 'javascript', label: 'JavaScript', monaco: 'javascript', extension: 'js', completePrograms: false },
+  { name: 'cpp', label: 'C++', monaco: 'cpp', extension: 'cpp', completePrograms: false },
+  { name: 'java', label: 'Java', monaco: 'java', extension: 'java', completePrograms: false },
+  { name: 'go', label: 'Go', monaco: 'go', extension: 'go', completePrograms: true },
+  { name: 'rust', label: 'Rust', monaco: 'rust', extension: 'rs', completePrograms: true },
+  { name: 'c', label: 'C', monaco: 'c', extension: 'c', completePrograms: true },
+  { name: 'kotlin', label: 'Kotlin', monaco: 'kotlin', extension: 'kt', completePrograms: true },
+  { name: 'typescript', label: 'TypeScript', monaco: 'typescript', extension: 'ts', completePrograms: true },
+];
+
+// Pseudocode is converted to Python before it runs, so only the editor offers it
+export const PSEUDOCODE: Language = {
+  name: 'pseudocode', label: 'Pseudocode', monaco: 'pseudocode', extension: 'pseudo', completePrograms: false,
+};
+
+export const EDITOR_LANGUAGES: Language[] = [...LANGUAGES, PSEUDOCODE];
+
+export function getLanguage(name: string): Language | undefined {
+  const lower = name.toLowerCase();
+  return EDITOR_LANGUAGES.find((language) => language.name === lower);
+}
+
+export function languageLabel(name: string): string {
+  return getLanguage(name)?.label ?? name;
+}
+
+export function fileExtension(name: string): string {
+  return getLanguage(name)?.extension ?? 'txt';
+}
@@ -2,7 +2,7 @@
 import '@/app/globals.css';
 import Editor, { Monaco } from '@monaco-editor/react';
 import { ResizableHandle, ResizablePanel, ResizablePanelGroup } from '@/components/ui/resizable';
-import { executeCode, submitSolution, getCodeCompletion, getAIHint, getLastCode, getSubmissionDetails } from '@/lib/api';
+import { executeCode, submitSolution, getCodeCompletion, getAIHint, getLastCode, getSubmissionDetails, getLanguages, LanguageInfo } from '@/lib/api';
 import { AlertCircle, ChevronLeft, Loader, MessageSquare, ArrowUp, ArrowDown, Trash2, Lightbulb, Play, Send, Plus, X, Timer, MemoryStick, Target, BookOpen, Users, Award, Code2, Eye, Sparkles, CheckCircle, FileText } from 'lucide-react';
 import type { editor } from 'monaco-editor';
 import { useRouter } from 'next/router';
@@ -11,6 +11,7 @@ import Head from 'next/head';
 import Link from 'next/link';
 import type { ProblemType, ThreadType, CommentType, ThreadsResponse, CommentsResponse, ApiError } from '@/types/problem';
 import { ApiErrorResponse } from '@/lib/api';
+import { EDITOR_LANGUAGES, getLanguage } from '@/lib/languages';
 import { useTheme } from '@/providers/ThemeProvider';
 import GlassCard from '@/components/ui/GlassCard';
 import AnimatedButton from '@/components/ui/AnimatedButton';
@@ -173,8 +174,10 @@ const getStatusClass = (status: string, isDark: boolean) => {
         case 'WRONG_ANSWER': return `${baseClasses} ${isDark ? 'bg-red-900/50 text-red-400' : 'bg-red-100 text-red-700'}`;
         case 'TIME_LIMIT_EXCEEDED': return `${baseClasses} ${isDark ? 'bg-yellow-900/50 text-yellow-400' : 'bg-yellow-100 text-yellow-700'}`;
         case 'MEMORY_LIMIT_EXCEEDED': return `${baseClasses} ${isDark ? 'bg-yellow-900/50 text-yellow-400' : 'bg-yellow-100 text-yellow-700'}`;
+        case 'OUTPUT_LIMIT_EXCEEDED': return `${baseClasses} ${isDark ? 'bg-yellow-900/50 text-yellow-400' : 'bg-yellow-100 text-yellow-700'}`;
         case 'RUNTIME_ERROR': return `${baseClasses} ${isDark ? 'bg-orange-900/50 text-orange-400' : 'bg-orange-100 text-orange-700'}`;
         case 'COMPILATION_ERROR': return `${baseClasses} ${isDark ? 'bg-purple-900/50 text-purple-400' : 'bg-purple-100 text-purple-700'}`;
+        case 'SECURITY_VIOLATION': return `${baseClasses} ${isDark ? 'bg-red-900/50 text-red-400' : 'bg-red-100 text-red-700'}`;
         case 'PENDING':
         case 'PROCESSING':
         case 'IN_PROGRESS':
@@ -219,6 +222,8 @@ export default function SingleProblemPage() {
     // eslint-disable-next-line @typescript-eslint/no-explicit-any
     const [submissionResult, setSubmissionResult] = useState<any>(null);
     const [codeLoaded, setCodeLoaded] = useState<boolean>(false);
+    // What the executors report about each language, by name
+    const [languageInfo, setLanguageInfo] = useState<Record<string, LanguageInfo>>({});
     const [outputView, setOutputView] = useState<'run-results'>('run-results');
 
     // Track test case results
@@ -282,7 +287,7 @@ export default function SingleProblemPage() {
     useEffect(() => {
         if (!selectedSubmission?.id) return;
 
-        const isFinalStatus = ["ACCEPTED", "WRONG_ANSWER", "RUNTIME_ERROR", "COMPILATION_ERROR", "TIME_LIMIT_EXCEEDED", "MEMORY_LIMIT_EXCEEDED"].includes(selectedSubmission.status);
+        const isFinalStatus = ["ACCEPTED", "WRONG_ANSWER", "RUNTIME_ERROR", "COMPILATION_ERROR", "TIME_LIMIT_EXCEEDED", "MEMORY_LIMIT_EXCEEDED", "SECURITY_VIOLATION", "OUTPUT_LIMIT_EXCEEDED", "JUDGE_ERROR"].includes(selectedSubmission.status);
 
         if (isFinalStatus) {
             fetchSubmissions();
@@ -432,7 +437,7 @@ export default function SingleProblemPage() {
         };
 
         const providerRegistration = monacoInstance.languages.registerInlineCompletionsProvider(
-            ['python', 'javascript', 'cpp', 'java', 'pseudocode'],
+            EDITOR_LANGUAGES.map((language) => language.monaco),
             inlineCompletionProvider
         );
 
@@ -527,7 +532,8 @@ export default function SingleProblemPage() {
 
     // Handle language change
     const handleLanguageChange = (value: string) => {
-        if (code.trim() !== '' && code.trim() !== '// Start coding here...') {
+        const template = languageInfo[selectedLanguage]?.template?.trim();
+        if (code.trim() !== '' && code.trim() !== '// Start coding here...' && code.trim() !== template) {
             const confirmMessage = `You have existing code. Switching to ${value} will clear your current code. Continue?`;
             if (!confirm(confirmMessage)) {
                 return;
@@ -557,6 +563,23 @@ export default function SingleProblemPage() {
         checkLoginStatus();
     }, []);
 
+    // Load the versions and templates the executors report
+    useEffect(() => {
+        getLanguages()
+            .then(({ languages }) => {
+                setLanguageInfo(Object.fromEntries(languages.map((language) => [language.name, language])));
+            })
+            .catch((err) => console.info('Failed to fetch languages:', err));
+    }, []);
+
+    // Start from the language's template rather than the placeholder
+    useEffect(() => {
+        const template = languageInfo[selectedLanguage]?.template;
+        if (template) {
+            setCode((current) => (current === '// Start coding here...' ? template : current));
+        }
+    }, [languageInfo, selectedLanguage]);
+
     // Load code from localStorage or API
     useEffect(() => {
         if (!problemId || !isLoggedIn || codeLoaded) return;
@@ -1230,11 +1253,15 @@ export default function SingleProblemPage() {
                                                 : 'bg-white border-gray-300 text-gray-900 focus:border-blue-500'
                                                 } focus:ring-2 focus:ring-blue-500/20`}
                                         >
-                                            <option value="python">Python</option>
-                                            <option value="javascript">JavaScript</option>
-                                            <option value="cpp">C++</option>
-                                            <option value="java">Java</option>
-                                            <option value="pseudocode">Pseudocode</option>
+                                            {EDITOR_LANGUAGES.map((language) => (
+                                                <option
+                                                    key={language.name}
+                                                    value={language.name}
+                                                    title={languageInfo[language.name]?.version}
+                                                >
+                                                    {language.label}
+                                                </option>
+                                            ))}
                                         </select>
 
                                         <div className={`text-sm ${isDark ? 'text-gray-400' : 'text-gray-600'}`}>
@@ -1314,7 +1341,7 @@ export default function SingleProblemPage() {
                                                 <div className="flex-grow">
                                                     <Editor
                                                         height="100%"
-                                                        language={selectedLanguage === 'cpp' ? 'cpp' : selectedLanguage}
+                                                        language={getLanguage(selectedLanguage)?.monaco ?? selectedLanguage}
                                                         value={code}
                                                         onChange={(value) => setCode(value || '')}
                                                         onMount={onEditorMount}
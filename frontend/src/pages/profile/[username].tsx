@@ -46,6 +46,7 @@ import SkillRadarChart from '@/components/ui/SkillRadarChart';
 import { AchievementGrid, Achievement } from '@/components/ui/AchievementBadge';
 import { ProgressRing, StatCard, DifficultyProgress } from '@/components/ui/ProgressComponents';
 import Skeleton from '@/components/ui/Skeleton';
+import { languageLabel } from '@/lib/languages';
 
 interface Profile {
   bio: string;
@@ -1000,7 +1001,7 @@ const UserProfilePage = () => {
                       <div key={idx} className="space-y-1">
                         <div className="flex items-center justify-between">
                           <span className={`text-sm font-medium ${isDark ? 'text-gray-300' : 'text-gray-700'}`}>
-                            {lang.language}
+                            {languageLabel(lang.language)}
                           </span>
                           <span className={`text-xs ${isDark ? 'text-gray-400' : 'text-gray-600'}`}>
                             {lang.percentage.toFixed(0)}%
@@ -218,10 +218,14 @@ export default function SubmissionDetailPage() {
                 return 'bg-yellow-100 text-yellow-800';
             case 'MEMORY_LIMIT_EXCEEDED':
                 return 'bg-yellow-100 text-yellow-800';
+            case 'OUTPUT_LIMIT_EXCEEDED':
+                return 'bg-yellow-100 text-yellow-800';
             case 'RUNTIME_ERROR':
                 return 'bg-orange-100 text-orange-800';
             case 'COMPILATION_ERROR':
                 return 'bg-orange-100 text-orange-800';
+            case 'SECURITY_VIOLATION':
+                return 'bg-red-100 text-red-800';
             case 'PENDING':
                 return 'bg-blue-100 text-blue-800';
             case 'PROCESSING':
@@ -241,10 +245,16 @@ export default function SubmissionDetailPage() {
                 return '⏱️';
             case 'MEMORY_LIMIT_EXCEEDED':
                 return '📊';
+            case 'OUTPUT_LIMIT_EXCEEDED':
+                return '📜';
             case 'RUNTIME_ERROR':
                 return '💥';
             case 'COMPILATION_ERROR':
                 return '🔧';
+            case 'SECURITY_VIOLATION':
+                return '🚫';
+            case 'JUDGE_ERROR':
+                return '⚠️';
             case 'PENDING':
                 return '⏳';
             case 'PROCESSING':
@@ -264,10 +274,16 @@ export default function SubmissionDetailPage() {
                 return 'Your solution took too long to execute.';
             case 'MEMORY_LIMIT_EXCEEDED':
                 return 'Your solution used too much memory.';
+            case 'OUTPUT_LIMIT_EXCEEDED':
+                return 'Your solution printed too much output.';
             case 'RUNTIME_ERROR':
                 return 'Your solution encountered an error during execution.';
             case 'COMPILATION_ERROR':
                 return 'Your code failed to compile or had syntax errors.';
+            case 'SECURITY_VIOLATION':
+                return 'Your solution was stopped for a forbidden action, such as opening a network connection.';
+            case 'JUDGE_ERROR':
+                return 'Your submission could not be judged because of a problem on our side. It does not count against you.';
             case 'PENDING':
                 return 'Your submission is being processed...';
             case 'PROCESSING':
@@ -166,10 +166,14 @@ export default function SubmissionsPage() {
                 return 'bg-yellow-100 text-yellow-800';
             case 'MEMORY_LIMIT_EXCEEDED':
                 return 'bg-yellow-100 text-yellow-800';
+            case 'OUTPUT_LIMIT_EXCEEDED':
+                return 'bg-yellow-100 text-yellow-800';
             case 'RUNTIME_ERROR':
                 return 'bg-orange-100 text-orange-800';
             case 'COMPILATION_ERROR':
                 return 'bg-orange-100 text-orange-800';
+            case 'SECURITY_VIOLATION':
+                return 'bg-red-100 text-red-800';
             case 'PENDING':
                 return 'bg-blue-100 text-blue-800';
             default:
@@ -226,9 +230,11 @@ export default function SubmissionsPage() {
             case 'ACCEPTED': return 'success';
             case 'WRONG_ANSWER':
             case 'RUNTIME_ERROR':
-            case 'COMPILATION_ERROR': return 'error';
+            case 'COMPILATION_ERROR':
+            case 'SECURITY_VIOLATION': return 'error';
             case 'TIME_LIMIT_EXCEEDED':
-            case 'MEMORY_LIMIT_EXCEEDED': return 'warning';
+            case 'MEMORY_LIMIT_EXCEEDED':
+            case 'OUTPUT_LIMIT_EXCEEDED': return 'warning';
             case 'PENDING': return 'running';
             default: return 'error';
         }